@@ -0,0 +1,132 @@
+package scroll_engine
+
+import (
+	"sort"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// MarkerPair is two genetic markers and the number of scrolls carrying both.
+type MarkerPair struct {
+	A     string `json:"a"`
+	B     string `json:"b"`
+	Count int    `json:"count"`
+}
+
+// MarkerCooccurrence counts, in a single pass over scrolls, how many scrolls
+// carry each unordered pair of markers, and returns the pairs seen in at
+// least minSupport scrolls ordered by count descending, then by name. A
+// marker repeated within one scroll counts once.
+func MarkerCooccurrence(scrolls []types.Scroll, minSupport int) []MarkerPair {
+	counts := make(map[[2]string]int)
+	for _, s := range scrolls {
+		markers := uniqueSorted(s.GeneticMarkers)
+		for i := 0; i < len(markers); i++ {
+			for j := i + 1; j < len(markers); j++ {
+				counts[[2]string{markers[i], markers[j]}]++
+			}
+		}
+	}
+
+	pairs := []MarkerPair{}
+	for k, n := range counts {
+		if n >= minSupport {
+			pairs = append(pairs, MarkerPair{A: k[0], B: k[1], Count: n})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Count != pairs[j].Count {
+			return pairs[i].Count > pairs[j].Count
+		}
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	return pairs
+}
+
+// MarkerRelief is a targeted gene and the predicted relief attributed to it,
+// summed over the plans targeting it.
+type MarkerRelief struct {
+	Gene   string  `json:"gene"`
+	Relief float64 `json:"relief"`
+	Plans  int     `json:"plans"`
+}
+
+// TopReliefMarkers ranks the genes tenant's stored plans target by the
+// predicted relief attributed to them, in a single pass over the plans, and
+// returns the top k ordered by relief descending, then by name. Each plan's
+// PredictedRelief is split across its TargetDetails in proportion to their
+// relief contributions, evenly when they carry none; a plan stored without
+// details splits it evenly across its TargetedGenes.
+func TopReliefMarkers(store ScrollStore, tenant string, k int) ([]MarkerRelief, error) {
+	totals := make(map[string]*MarkerRelief)
+	err := store.EachPlan(tenant, func(plan types.GeneInterventionPlan) error {
+		for gene, relief := range attributeRelief(plan) {
+			m, ok := totals[gene]
+			if !ok {
+				m = &MarkerRelief{Gene: gene}
+				totals[gene] = m
+			}
+			m.Relief += relief
+			m.Plans++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := make([]MarkerRelief, 0, len(totals))
+	for _, m := range totals {
+		ranked = append(ranked, *m)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Relief != ranked[j].Relief {
+			return ranked[i].Relief > ranked[j].Relief
+		}
+		return ranked[i].Gene < ranked[j].Gene
+	})
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	return ranked, nil
+}
+
+// attributeRelief splits plan's PredictedRelief across its targeted genes.
+func attributeRelief(plan types.GeneInterventionPlan) map[string]float64 {
+	details := plan.TargetDetails
+	if len(details) == 0 {
+		for _, g := range plan.TargetedGenes {
+			details = append(details, types.MarkerContribution{Gene: g})
+		}
+	}
+	var total float64
+	for _, d := range details {
+		total += d.Relief
+	}
+	shares := make(map[string]float64, len(details))
+	for _, d := range details {
+		if total > 0 {
+			shares[d.Gene] += plan.PredictedRelief * d.Relief / total
+		} else {
+			shares[d.Gene] += plan.PredictedRelief / float64(len(details))
+		}
+	}
+	return shares
+}
+
+// uniqueSorted returns the distinct values of in, sorted.
+func uniqueSorted(in []string) []string {
+	out := make([]string, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, v := range in {
+		if !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
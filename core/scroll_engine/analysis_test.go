@@ -0,0 +1,128 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestMarkerCooccurrence(t *testing.T) {
+	scrolls := []types.Scroll{
+		{ID: "1", GeneticMarkers: []string{"NOD2", "ATG16L1", "IL23R"}},
+		{ID: "2", GeneticMarkers: []string{"ATG16L1", "NOD2"}},
+		{ID: "3", GeneticMarkers: []string{"NOD2", "IL23R", "NOD2"}},
+		{ID: "4", GeneticMarkers: []string{"TNFSF15"}},
+	}
+
+	got := MarkerCooccurrence(scrolls, 2)
+
+	want := []MarkerPair{
+		{A: "ATG16L1", B: "NOD2", Count: 2},
+		{A: "IL23R", B: "NOD2", Count: 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCooccurrenceHandler_NoPairsIsEmptyList(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	postSimulate(h, `{"id":"s1","trust_score":0.9,"genetic_markers":["g1","g2"]}`, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/analysis/cooccurrence?min_support=5", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var pairs []MarkerPair
+	if err := json.NewDecoder(rec.Body).Decode(&pairs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if pairs == nil || len(pairs) != 0 {
+		t.Fatalf("expected empty list, got %v", pairs)
+	}
+}
+
+func seedReliefPlans(t *testing.T, store ScrollStore) {
+	t.Helper()
+	plans := map[string]types.GeneInterventionPlan{
+		// NOD2 0.4, IL23R 0.2: split in proportion to relief.
+		"p1": {PredictedRelief: 0.6, TargetDetails: []types.MarkerContribution{
+			{Gene: "NOD2", Relief: 0.8}, {Gene: "IL23R", Relief: 0.4},
+		}},
+		// NOD2 0.5.
+		"p2": {PredictedRelief: 0.5, TargetDetails: []types.MarkerContribution{{Gene: "NOD2", Relief: 0.5}}},
+		// ATG16L1 0.15, IL23R 0.15: stored without details.
+		"p3": {PredictedRelief: 0.3, TargetedGenes: []string{"ATG16L1", "IL23R"}},
+		// ATG16L1 0.1, TNFSF15 0.1: no relief contributions to split by.
+		"p4": {PredictedRelief: 0.2, TargetDetails: []types.MarkerContribution{{Gene: "TNFSF15"}, {Gene: "ATG16L1"}}},
+		"p5": {PredictedRelief: 0.9},
+	}
+	for id, plan := range plans {
+		if err := store.SavePlan(testTenant, id, plan); err != nil {
+			t.Fatalf("save plan %s: %v", id, err)
+		}
+	}
+	_ = store.SavePlan("other", "p1", plans["p1"])
+}
+
+func TestTopReliefMarkers_Ranking(t *testing.T) {
+	want := []MarkerRelief{
+		{Gene: "NOD2", Relief: 0.9, Plans: 2},
+		{Gene: "IL23R", Relief: 0.35, Plans: 2},
+		{Gene: "ATG16L1", Relief: 0.25, Plans: 2},
+		{Gene: "TNFSF15", Relief: 0.1, Plans: 1},
+	}
+	for name, store := range map[string]ScrollStore{"memory": NewMemoryStore(), "sqlite": openTestSQLite(t)} {
+		t.Run(name, func(t *testing.T) {
+			seedReliefPlans(t, store)
+
+			got, err := TopReliefMarkers(store, testTenant, 20)
+			if err != nil {
+				t.Fatalf("top markers: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("expected %d markers, got %+v", len(want), got)
+			}
+			for i, w := range want {
+				if got[i].Gene != w.Gene || got[i].Plans != w.Plans || math.Abs(got[i].Relief-w.Relief) > 1e-9 {
+					t.Fatalf("rank %d: expected %+v, got %+v", i, w, got[i])
+				}
+			}
+
+			top, _ := TopReliefMarkers(store, testTenant, 2)
+			if len(top) != 2 || top[0].Gene != "NOD2" || top[1].Gene != "IL23R" {
+				t.Fatalf("expected k=2 to keep NOD2 and IL23R, got %+v", top)
+			}
+		})
+	}
+}
+
+func TestTopMarkersHandler(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+	get := func(path string) (int, []MarkerRelief) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newTenantRequest(http.MethodGet, path, nil))
+		var ranked []MarkerRelief
+		_ = json.NewDecoder(rec.Body).Decode(&ranked)
+		return rec.Code, ranked
+	}
+
+	if code, ranked := get("/analysis/top-markers"); code != http.StatusOK || ranked == nil || len(ranked) != 0 {
+		t.Fatalf("expected an empty list from an empty store, got %d %v", code, ranked)
+	}
+	if code, _ := get("/analysis/top-markers?k=0"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for k=0, got %d", code)
+	}
+
+	seedReliefPlans(t, srv.store)
+	if code, ranked := get("/analysis/top-markers?k=1"); code != http.StatusOK || len(ranked) != 1 || ranked[0].Gene != "NOD2" {
+		t.Fatalf("expected only NOD2 for k=1, got %d %+v", code, ranked)
+	}
+}
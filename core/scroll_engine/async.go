@@ -0,0 +1,173 @@
+package scroll_engine
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// scrollQueue is the async worker's backlog: a priority queue that hands out
+// flare scrolls before memory scrolls and, within each, higher trust first.
+// Scrolls of equal priority leave in arrival order. It is safe for
+// concurrent use.
+type scrollQueue struct {
+	mu     sync.Mutex
+	ready  *sync.Cond
+	items  scrollHeap
+	seq    uint64
+	closed bool
+}
+
+func newScrollQueue() *scrollQueue {
+	q := &scrollQueue{}
+	q.ready = sync.NewCond(&q.mu)
+	return q
+}
+
+// push enqueues req. It reports false once the queue is closed.
+func (q *scrollQueue) push(req simulateRequest) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	heap.Push(&q.items, queuedScroll{req: req, seq: q.seq})
+	q.seq++
+	q.ready.Signal()
+	return true
+}
+
+// pop blocks until a scroll is available and returns the most urgent one. It
+// reports false when the queue is closed and drained.
+func (q *scrollQueue) pop() (simulateRequest, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.ready.Wait()
+	}
+	if len(q.items) == 0 {
+		return simulateRequest{}, false
+	}
+	return heap.Pop(&q.items).(queuedScroll).req, true
+}
+
+// close stops the queue accepting scrolls and wakes idle workers; scrolls
+// already queued are still handed out.
+func (q *scrollQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.ready.Broadcast()
+}
+
+func (q *scrollQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+type queuedScroll struct {
+	req simulateRequest
+	seq uint64
+}
+
+// scrollHeap implements heap.Interface with the most urgent scroll at the
+// root.
+type scrollHeap []queuedScroll
+
+func (h scrollHeap) Len() int { return len(h) }
+
+func (h scrollHeap) Less(i, j int) bool {
+	a, b := h[i].req, h[j].req
+	if a.IsFlareEvent != b.IsFlareEvent {
+		return a.IsFlareEvent
+	}
+	if a.TrustScore != b.TrustScore {
+		return a.TrustScore > b.TrustScore
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h scrollHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *scrollHeap) Push(x any) { *h = append(*h, x.(queuedScroll)) }
+
+func (h *scrollHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// StartWorkers runs n workers simulating and persisting queued scrolls, and
+// one delivering deferred webhooks, until ctx is cancelled. Scrolls and
+// webhooks still queued at cancellation are dropped.
+func (s *Server) StartWorkers(ctx context.Context, n int) {
+	go func() {
+		<-ctx.Done()
+		s.queue.close()
+	}()
+	for range n {
+		go s.work(ctx)
+	}
+	go s.deliverDeferred(ctx)
+}
+
+func (s *Server) work(ctx context.Context) {
+	for {
+		req, ok := s.queue.pop()
+		if !ok || ctx.Err() != nil {
+			return
+		}
+		plan, err := s.simulate(ctx, req)
+		if err == nil {
+			err = s.persist(ctx, req.Tenant, req.Scroll, plan)
+		}
+		if err != nil {
+			s.metrics.Inc("async_failures_total")
+			log.Printf("async simulation of scroll %s failed: %v", req.ID, err)
+			continue
+		}
+		s.metrics.Inc("async_processed_total")
+		if s.config().WebhookURL != "" {
+			s.deferWebhook(WebhookEvent{Tenant: req.Tenant, ScrollID: req.ID, At: time.Now().UTC(), Plan: plan})
+		}
+	}
+}
+
+// asyncSimulateHandler queues a scroll for the async workers and returns 202
+// at once; the plan is persisted under the scroll's ID when it completes.
+func (s *Server) asyncSimulateHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	req, ok := s.decodeSimulateRequest(w, raw, tenant)
+	if !ok {
+		return
+	}
+	if !s.queue.push(req) {
+		writeError(w, http.StatusServiceUnavailable, CodeUnavailable, "async queue is shut down", "")
+		return
+	}
+	s.metrics.Inc("async_enqueued_total")
+	if req.assignedID {
+		w.Header().Set("Location", "/scrolls/"+url.PathEscape(req.ID))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(map[string]string{"scroll_id": req.ID, "status": "queued"})
+}
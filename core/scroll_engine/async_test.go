@@ -0,0 +1,133 @@
+package scroll_engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestScrollQueue_FlaresFirstThenTrust(t *testing.T) {
+	q := newScrollQueue()
+	for _, s := range []types.Scroll{
+		{ID: "mem-low", TrustScore: 0.2},
+		{ID: "flare-mid", TrustScore: 0.6, IsFlareEvent: true},
+		{ID: "mem-high", TrustScore: 0.9},
+		{ID: "flare-high", TrustScore: 0.95, IsFlareEvent: true},
+		{ID: "mem-low-2", TrustScore: 0.2},
+		{ID: "flare-low", TrustScore: 0.1, IsFlareEvent: true},
+	} {
+		q.push(simulateRequest{Scroll: s})
+	}
+	q.close()
+
+	var got []string
+	for {
+		s, ok := q.pop()
+		if !ok {
+			break
+		}
+		got = append(got, s.ID)
+	}
+	want := []string{"flare-high", "flare-mid", "flare-low", "mem-high", "mem-low", "mem-low-2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("dequeue order %v, want %v", got, want)
+	}
+}
+
+func TestScrollQueue_ConcurrentPushPop(t *testing.T) {
+	q := newScrollQueue()
+	const producers, perProducer = 8, 250
+
+	var popped sync.Map
+	var consumers sync.WaitGroup
+	for range 4 {
+		consumers.Add(1)
+		go func() {
+			defer consumers.Done()
+			for {
+				s, ok := q.pop()
+				if !ok {
+					return
+				}
+				if _, dup := popped.LoadOrStore(s.ID, true); dup {
+					t.Errorf("scroll %s dequeued twice", s.ID)
+				}
+			}
+		}()
+	}
+
+	var producersWG sync.WaitGroup
+	for p := range producers {
+		producersWG.Add(1)
+		go func() {
+			defer producersWG.Done()
+			for i := range perProducer {
+				q.push(simulateRequest{Scroll: types.Scroll{
+					ID:           fmt.Sprintf("p%d-%d", p, i),
+					TrustScore:   float64(i%10) / 10,
+					IsFlareEvent: i%3 == 0,
+				}})
+			}
+		}()
+	}
+	producersWG.Wait()
+	q.close()
+	consumers.Wait()
+
+	n := 0
+	popped.Range(func(_, _ any) bool { n++; return true })
+	if n != producers*perProducer {
+		t.Fatalf("dequeued %d scrolls, want %d", n, producers*perProducer)
+	}
+	if q.push(simulateRequest{Scroll: types.Scroll{ID: "late"}}) {
+		t.Fatalf("expected push to fail after close")
+	}
+}
+
+func TestAsyncSimulate_QueuesAndPersists(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+
+	req := newTenantRequest(http.MethodPost, "/simulate/async",
+		strings.NewReader(`{"id":"a1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rec.Code, rec.Body)
+	}
+
+	metrics := httptest.NewRecorder()
+	h.ServeHTTP(metrics, newTenantRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(metrics.Body.String(), "async_queue_depth 1") {
+		t.Fatalf("expected queue depth 1 before workers start:\n%s", metrics.Body)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv.StartWorkers(ctx, 1)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if plan, err := srv.store.GetPlan(testTenant, "a1"); err == nil {
+			if plan.Branch != BranchFlare {
+				t.Fatalf("expected flare plan, got %s", plan.Branch)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("async plan was never persisted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if srv.queue.len() != 0 {
+		t.Fatalf("expected drained queue, got depth %d", srv.queue.len())
+	}
+}
@@ -0,0 +1,104 @@
+package scroll_engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// AuditEntry is the immutable record of one simulation decision. Entries are
+// only ever appended to a store; Seq is assigned by the store in append
+// order.
+type AuditEntry struct {
+	Seq        int64            `json:"seq"`
+	ScrollID   string           `json:"scroll_id"`
+	At         time.Time        `json:"at"`
+	ConfigHash string           `json:"config_hash"`
+	Branch     string           `json:"branch"`
+	TrustScore float64          `json:"trust_score"`
+	Thresholds AuditThresholds  `json:"thresholds"`
+	Plan       AuditPlanSummary `json:"plan"`
+}
+
+// AuditThresholds are the decision thresholds in force for an entry.
+type AuditThresholds struct {
+	Trust         float64                 `json:"trust"`
+	FlareSeverity FlareSeverityThresholds `json:"flare_severity"`
+}
+
+// AuditPlanSummary is the outcome of the decision, without its explanation.
+type AuditPlanSummary struct {
+	MutationLoopID      string   `json:"mutation_loop_id"`
+	TargetedGenes       []string `json:"targeted_genes"`
+	RequiredRecalibrate bool     `json:"required_recalibrate"`
+	PredictedRelief     float64  `json:"predicted_relief"`
+	FlareSuppression    float64  `json:"flare_suppression"`
+	RebirthEligible     bool     `json:"rebirth_eligible"`
+	FlareSeverity       string   `json:"flare_severity,omitempty"`
+	// ContentHash is the plan's PlanContentHash.
+	ContentHash string `json:"content_hash,omitempty"`
+}
+
+// Hash returns a stable digest of the config's serializable settings, so an
+// audit entry can be matched to the config that produced it without
+// recording the config verbatim. Secrets are blanked before hashing: the
+// hash is shown to every tenant, and must not let them test guesses at a
+// secret offline.
+func (c SimulationConfig) Hash() string {
+	c.ScrollSigningKey, c.AdminToken, c.WebhookSecret = "", "", ""
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// newAuditEntry records the decision c produced for scroll at time at.
+func newAuditEntry(scroll types.Scroll, plan types.GeneInterventionPlan, c *compiledConfig, at time.Time) AuditEntry {
+	cfg := c.cfg
+	hash, _ := PlanContentHash(plan)
+	return AuditEntry{
+		ScrollID:   scroll.ID,
+		At:         at,
+		ConfigHash: c.hash,
+		Branch:     plan.Branch,
+		TrustScore: scroll.TrustScore,
+		Thresholds: AuditThresholds{Trust: cfg.TrustThreshold, FlareSeverity: cfg.FlareSeverity},
+		Plan: AuditPlanSummary{
+			MutationLoopID:      plan.MutationLoopID,
+			TargetedGenes:       slices.Clone(plan.TargetedGenes),
+			RequiredRecalibrate: plan.RequiredRecalibrate,
+			PredictedRelief:     plan.PredictedRelief,
+			FlareSuppression:    plan.FlareSuppression,
+			RebirthEligible:     plan.RebirthEligible,
+			FlareSeverity:       plan.FlareSeverity,
+			ContentHash:         hash,
+		},
+	}
+}
+
+func (s *Server) auditHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	id := r.URL.Query().Get("scroll_id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "scroll_id is required", "scroll_id")
+		return
+	}
+	entries, err := s.store.ListAudit(tenant, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "list audit: "+err.Error(), "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(entries)
+}
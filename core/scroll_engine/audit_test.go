@@ -0,0 +1,96 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func getAudit(t *testing.T, h http.Handler, query string) (int, []AuditEntry) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/audit?"+query, nil))
+	var entries []AuditEntry
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&entries); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return rec.Code, entries
+}
+
+func TestAudit_EachSimulationAppendsEntry(t *testing.T) {
+	cfg := DefaultConfig()
+	srv := NewServer(cfg)
+	h := srv.Handler()
+	body := `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`
+	postSimulate(h, body, "")
+	postSimulate(h, body, "")
+	postSimulate(h, `{"id":"other","trust_score":0.1}`, "")
+
+	code, entries := getAudit(t, h, "scroll_id=s1")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected two audit entries for s1, got %d", len(entries))
+	}
+	if entries[0].Seq == entries[1].Seq || entries[1].At.Before(entries[0].At) {
+		t.Fatalf("expected distinct, ordered entries: %+v", entries)
+	}
+	e := entries[0]
+	if e.ScrollID != "s1" || e.Branch != BranchFlare || e.TrustScore != 0.9 ||
+		e.Thresholds.Trust != cfg.TrustThreshold || e.ConfigHash != cfg.Hash() || e.ConfigHash == "" {
+		t.Fatalf("unexpected entry: %+v", e)
+	}
+	if len(e.Plan.TargetedGenes) != 1 || e.Plan.TargetedGenes[0] != "NOD2" || e.Plan.MutationLoopID == "" {
+		t.Fatalf("unexpected plan summary: %+v", e.Plan)
+	}
+}
+
+func TestAudit_RequiresScrollID(t *testing.T) {
+	if code, _ := getAudit(t, NewServer(DefaultConfig()).Handler(), ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without scroll_id, got %d", code)
+	}
+}
+
+func TestConfigHash_ChangesWithSettings(t *testing.T) {
+	a, b := DefaultConfig(), DefaultConfig()
+	if a.Hash() != b.Hash() {
+		t.Fatalf("expected equal configs to hash equally")
+	}
+	b.TrustThreshold = 0.8
+	if a.Hash() == b.Hash() {
+		t.Fatalf("expected a threshold change to change the hash")
+	}
+}
+
+func TestConfigHash_IgnoresSecrets(t *testing.T) {
+	base := DefaultConfig().Hash()
+	for name, set := range map[string]func(*SimulationConfig){
+		"scroll_signing_key": func(c *SimulationConfig) { c.ScrollSigningKey = "k" },
+		"admin_token":        func(c *SimulationConfig) { c.AdminToken = "t" },
+		"webhook_secret":     func(c *SimulationConfig) { c.WebhookSecret = "w" },
+	} {
+		cfg := DefaultConfig()
+		set(&cfg)
+		if cfg.Hash() != base {
+			t.Errorf("expected %s to leave the hash unchanged", name)
+		}
+	}
+}
+
+func TestMemoryStore_AuditEntriesImmutable(t *testing.T) {
+	store := NewMemoryStore()
+	genes := []string{"NOD2"}
+	_ = store.AppendAudit(testTenant, AuditEntry{ScrollID: "s", Plan: AuditPlanSummary{TargetedGenes: genes}})
+	genes[0] = "tampered"
+
+	entries, _ := store.ListAudit(testTenant, "s")
+	entries[0].Plan.TargetedGenes[0] = "tampered"
+	again, _ := store.ListAudit(testTenant, "s")
+	if again[0].Plan.TargetedGenes[0] != "NOD2" {
+		t.Fatalf("expected stored entry to be unaffected by caller mutation")
+	}
+}
@@ -0,0 +1,158 @@
+package scroll_engine
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerPolicy configures the circuit breaker around external scoring:
+// Failures consecutive failed calls, each within Window of the first, open
+// the circuit for Cooldown. Zero Failures disables the breaker.
+type BreakerPolicy struct {
+	Failures int      `json:"failures"`
+	Window   Duration `json:"window"`
+	Cooldown Duration `json:"cooldown"`
+}
+
+// DefaultBreakerPolicy is used for external scoring unless configured.
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{
+		Failures: 5,
+		Window:   Duration(30 * time.Second),
+		Cooldown: Duration(30 * time.Second),
+	}
+}
+
+func (p BreakerPolicy) validate(key string) error {
+	switch {
+	case p.Failures < 0:
+		return &ConfigError{Key: key + ".failures", Message: "must not be negative"}
+	case p.Failures == 0:
+	case p.Window <= 0:
+		return &ConfigError{Key: key + ".window", Message: "must be positive"}
+	case p.Cooldown <= 0:
+		return &ConfigError{Key: key + ".cooldown", Message: "must be positive"}
+	}
+	return nil
+}
+
+// newBreaker returns a breaker following p, or nil if p disables it.
+func (p BreakerPolicy) newBreaker() *CircuitBreaker {
+	if p.Failures == 0 {
+		return nil
+	}
+	return NewCircuitBreaker(p)
+}
+
+// BreakerState is a circuit breaker's state. Its numeric value is what
+// /metrics reports.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects every call until the cooldown has passed.
+	BreakerOpen
+	// BreakerHalfOpen lets a single probe call through: its success closes
+	// the circuit and its failure opens it again.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+// CircuitBreaker stops calls to a failing dependency so they fail fast
+// rather than piling more load onto it. It is safe for concurrent use.
+type CircuitBreaker struct {
+	policy BreakerPolicy
+	now    func() time.Time
+
+	mu           sync.Mutex
+	state        BreakerState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	probing      bool
+}
+
+// NewCircuitBreaker returns a closed breaker following policy.
+func NewCircuitBreaker(policy BreakerPolicy) *CircuitBreaker {
+	return &CircuitBreaker{policy: policy, now: time.Now}
+}
+
+// Allow reports whether a call may proceed. Once an open circuit's cooldown
+// has passed it half-opens and allows one probe; the caller must report the
+// outcome of every allowed call with Success, Failure, or Abandon.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= time.Duration(b.policy.Cooldown) {
+		b.state = BreakerHalfOpen
+	}
+	switch b.state {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// Success records a call that succeeded, closing the circuit.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state, b.failures, b.probing = BreakerClosed, 0, false
+}
+
+// Failure records a call that failed, opening the circuit if it was a
+// half-open probe or completes a run of failures within the window.
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	if b.state == BreakerHalfOpen {
+		b.open(now)
+		return
+	}
+	if b.failures == 0 || now.Sub(b.firstFailure) > time.Duration(b.policy.Window) {
+		b.failures, b.firstFailure = 0, now
+	}
+	b.failures++
+	if b.failures >= b.policy.Failures {
+		b.open(now)
+	}
+}
+
+// Abandon records a call that ended without an outcome, such as one its
+// caller cancelled, so a half-open circuit may probe again.
+func (b *CircuitBreaker) Abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *CircuitBreaker) open(now time.Time) {
+	b.state, b.openedAt, b.failures, b.probing = BreakerOpen, now, 0, false
+}
+
+// State returns the breaker's state, reporting an open circuit whose
+// cooldown has passed as half-open.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= time.Duration(b.policy.Cooldown) {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
@@ -0,0 +1,144 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var breakerNow = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// testBreaker returns a breaker opening after two failures within a minute
+// for ten seconds, and a function advancing its clock.
+func testBreaker() (*CircuitBreaker, func(time.Duration)) {
+	b := NewCircuitBreaker(BreakerPolicy{Failures: 2, Window: Duration(time.Minute), Cooldown: Duration(10 * time.Second)})
+	now := breakerNow
+	b.now = func() time.Time { return now }
+	return b, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCircuitBreaker_Transitions(t *testing.T) {
+	b, advance := testBreaker()
+
+	b.Failure()
+	if b.State() != BreakerClosed || !b.Allow() {
+		t.Fatalf("expected one failure to leave the circuit closed, got %s", b.State())
+	}
+	b.Failure()
+	if b.State() != BreakerOpen || b.Allow() {
+		t.Fatalf("expected two failures to open the circuit, got %s", b.State())
+	}
+
+	advance(10 * time.Second)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("expected the circuit half-open after the cooldown, got %s", b.State())
+	}
+	if !b.Allow() || b.Allow() {
+		t.Fatalf("expected a half-open circuit to allow exactly one probe")
+	}
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatalf("expected a failed probe to reopen the circuit, got %s", b.State())
+	}
+
+	advance(10 * time.Second)
+	if !b.Allow() {
+		t.Fatalf("expected a probe after the second cooldown")
+	}
+	b.Success()
+	if b.State() != BreakerClosed || !b.Allow() {
+		t.Fatalf("expected a successful probe to close the circuit, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_FailuresOutsideWindowDoNotOpen(t *testing.T) {
+	b, advance := testBreaker()
+
+	b.Failure()
+	advance(2 * time.Minute)
+	b.Failure()
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected failures a window apart to leave the circuit closed, got %s", b.State())
+	}
+}
+
+func TestCircuitBreaker_SuccessResetsRun(t *testing.T) {
+	b, _ := testBreaker()
+
+	b.Failure()
+	b.Success()
+	b.Failure()
+	if b.State() != BreakerClosed {
+		t.Fatalf("expected failures separated by a success to leave the circuit closed, got %s", b.State())
+	}
+}
+
+func TestHTTPScoringStrategy_BreakerSkipsServiceWhileOpen(t *testing.T) {
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(scoringResponse{PredictedRelief: 0.42, FlareSuppression: 0.55})
+	}))
+	t.Cleanup(srv.Close)
+	breaker, advance := testBreaker()
+	strategy := HTTPScoringStrategy{
+		URL:      srv.URL,
+		Retry:    RetryPolicy{MaxAttempts: 1},
+		Fallback: WeightedStrategy{Default: MarkerWeight{Relief: 0.1, Suppression: 0.2}},
+		Breaker:  breaker,
+	}
+	score := func() Score {
+		t.Helper()
+		s, err := strategy.Score(context.Background(), flareScroll, flareScroll.GeneticMarkers)
+		if err != nil {
+			t.Fatalf("score: %v", err)
+		}
+		return s
+	}
+
+	score()
+	score()
+	if breaker.State() != BreakerOpen || calls.Load() != 2 {
+		t.Fatalf("expected two failed calls to open the circuit, got %s after %d calls", breaker.State(), calls.Load())
+	}
+	if s := score(); calls.Load() != 2 || s.Fallback == "" {
+		t.Fatalf("expected an open circuit to fall back without calling, got %+v after %d calls", s, calls.Load())
+	}
+
+	healthy.Store(true)
+	advance(10 * time.Second)
+	if s := score(); s.PredictedRelief != 0.42 || calls.Load() != 3 {
+		t.Fatalf("expected the half-open probe to reach the service, got %+v after %d calls", s, calls.Load())
+	}
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expected a successful probe to close the circuit, got %s", breaker.State())
+	}
+}
+
+func TestMetrics_ReportsBreakerState(t *testing.T) {
+	srv, _ := flakyScoringServer(t, 1<<30)
+	cfg := DefaultConfig()
+	cfg.ScoringURL = srv.URL
+	cfg.ScoringRetry = RetryPolicy{MaxAttempts: 1}
+	cfg.ScoringBreaker = BreakerPolicy{Failures: 1, Window: Duration(time.Minute), Cooldown: Duration(time.Hour)}
+	h := NewServer(cfg).Handler()
+
+	if rec := postSimulate(h, budgetFlareBody, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "scoring_breaker_state 1") {
+		t.Fatalf("expected the breaker reported open:\n%s", rec.Body)
+	}
+}
@@ -0,0 +1,53 @@
+package scroll_engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// Stages of a /simulate request that share its budget, in the order they
+// run.
+const (
+	StageScoring = "scoring"
+	StageWebhook = "webhook"
+)
+
+// errBudgetExceeded is the cause of a budget context's deadline, which
+// tells a spent budget apart from the client's own deadline.
+var errBudgetExceeded = errors.New("request budget exceeded")
+
+// withBudget returns a child of ctx that expires budget from now, or ctx
+// itself when budget is zero.
+func withBudget(ctx context.Context, budget time.Duration) (context.Context, context.CancelFunc) {
+	if budget <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithDeadlineCause(ctx, time.Now().Add(budget), errBudgetExceeded)
+}
+
+// budgetSpent reports whether ctx ended because its request budget ran out.
+func budgetSpent(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), errBudgetExceeded)
+}
+
+// explainBudget records that scoring was cut off by the request budget.
+func explainBudget() types.ExplanationReason {
+	return types.ExplanationReason{
+		Kind:    ExplainBudget,
+		Message: "scoring exceeded the request budget; the plan is unscored",
+	}
+}
+
+// timedOutStage returns StageScoring if plan was cut short by the request
+// budget, or "".
+func timedOutStage(plan types.GeneInterventionPlan) string {
+	for _, r := range plan.Explanation {
+		if r.Kind == ExplainBudget {
+			return StageScoring
+		}
+	}
+	return ""
+}
@@ -0,0 +1,130 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// webhookRecorder is a webhook endpoint that records what it receives,
+// after sleeping delay.
+func webhookRecorder(t *testing.T, delay time.Duration) (*httptest.Server, chan WebhookEvent) {
+	t.Helper()
+	got := make(chan WebhookEvent, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		var e WebhookEvent
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			t.Errorf("decode webhook: %v", err)
+		}
+		got <- e
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func decodeSimulateResponse(t *testing.T, rec *httptest.ResponseRecorder) simulateResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp simulateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp
+}
+
+const budgetFlareBody = `{"id":"f","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`
+
+func TestSimulate_BudgetCutsSlowScoring(t *testing.T) {
+	hook, got := webhookRecorder(t, 0)
+	cfg := DefaultConfig()
+	cfg.Scoring = blockingStrategy{}
+	cfg.RequestBudget = Duration(20 * time.Millisecond)
+	cfg.WebhookURL = hook.URL
+	srv := NewServer(cfg)
+
+	start := time.Now()
+	resp := decodeSimulateResponse(t, postSimulate(srv.Handler(), budgetFlareBody, ""))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the budget to end scoring promptly, took %s", elapsed)
+	}
+	if resp.TimedOutStage != StageScoring {
+		t.Fatalf("expected timed_out_stage %q, got %q", StageScoring, resp.TimedOutStage)
+	}
+	if resp.Branch != BranchFlare || resp.PredictedRelief != 0 || len(resp.TargetedGenes) == 0 {
+		t.Fatalf("expected an unscored flare plan, got %+v", resp.GeneInterventionPlan)
+	}
+	if _, ok := findReason(resp.GeneInterventionPlan, ExplainBudget); !ok {
+		t.Fatalf("expected a budget explanation, got %+v", resp.Explanation)
+	}
+
+	// Scoring spent the budget, so the webhook waits for the worker.
+	if got := srv.metrics.Counter("webhooks_deferred_total"); got != 1 {
+		t.Fatalf("expected the webhook to be deferred, got %v", got)
+	}
+	srv.StartWorkers(t.Context(), 1)
+	select {
+	case e := <-got:
+		if e.ScrollID != "f" || e.Tenant != testTenant {
+			t.Fatalf("unexpected deferred webhook: %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("deferred webhook was never delivered")
+	}
+}
+
+func TestSimulate_BudgetDefersSlowWebhook(t *testing.T) {
+	hook, got := webhookRecorder(t, 100*time.Millisecond)
+	cfg := DefaultConfig()
+	cfg.RequestBudget = Duration(30 * time.Millisecond)
+	cfg.WebhookURL = hook.URL
+	srv := NewServer(cfg)
+
+	resp := decodeSimulateResponse(t, postSimulate(srv.Handler(), budgetFlareBody, ""))
+	if resp.TimedOutStage != StageWebhook {
+		t.Fatalf("expected timed_out_stage %q, got %q", StageWebhook, resp.TimedOutStage)
+	}
+	if resp.PredictedRelief == 0 {
+		t.Fatalf("expected the plan to be scored, got %+v", resp.GeneInterventionPlan)
+	}
+	if got := srv.metrics.Counter("webhooks_deferred_total"); got != 1 {
+		t.Fatalf("expected the webhook to be deferred, got %v", got)
+	}
+	// The cut-short attempt may still arrive; either way the worker
+	// delivers the deferred one.
+	srv.StartWorkers(t.Context(), 1)
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.metrics.Counter("webhooks_delivered_total") < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("deferred webhook was never delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(got) == 0 {
+		t.Fatal("expected the webhook endpoint to receive the deferred webhook")
+	}
+}
+
+func TestSimulate_WebhookWithoutBudgetIsSynchronous(t *testing.T) {
+	hook, got := webhookRecorder(t, 0)
+	cfg := DefaultConfig()
+	cfg.WebhookURL = hook.URL
+	srv := NewServer(cfg)
+
+	resp := decodeSimulateResponse(t, postSimulate(srv.Handler(), budgetFlareBody, ""))
+	if resp.TimedOutStage != "" {
+		t.Fatalf("expected no timed_out_stage without a budget, got %q", resp.TimedOutStage)
+	}
+	select {
+	case <-got:
+	default:
+		t.Fatal("expected the webhook to be delivered before the response")
+	}
+	if got := srv.metrics.Counter("webhooks_deferred_total"); got != 0 {
+		t.Fatalf("expected nothing deferred, got %v", got)
+	}
+}
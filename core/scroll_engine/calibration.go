@@ -0,0 +1,142 @@
+package scroll_engine
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// LabeledScroll is a historical scroll and whether intervening on it
+// actually helped.
+type LabeledScroll struct {
+	Scroll types.Scroll `json:"scroll"`
+	Helped bool         `json:"helped"`
+}
+
+// CalibrationMetric names what CalibrateThresholdBy maximizes.
+type CalibrationMetric string
+
+const (
+	CalibrationF1       CalibrationMetric = "f1"
+	CalibrationAccuracy CalibrationMetric = "accuracy"
+	// CalibrationYouden is Youden's J, recall plus specificity minus one,
+	// which unlike F1 rewards correctly withholding intervention.
+	CalibrationYouden CalibrationMetric = "youden"
+)
+
+// ThresholdMetrics is how a trust threshold would have classified the
+// labeled scrolls, counting a scroll at or above it as predicted to help.
+type ThresholdMetrics struct {
+	Threshold      float64 `json:"threshold"`
+	TruePositives  int     `json:"true_positives"`
+	FalsePositives int     `json:"false_positives"`
+	FalseNegatives int     `json:"false_negatives"`
+	TrueNegatives  int     `json:"true_negatives"`
+	Precision      float64 `json:"precision"`
+	Recall         float64 `json:"recall"`
+	F1             float64 `json:"f1"`
+	Accuracy       float64 `json:"accuracy"`
+	Youden         float64 `json:"youden"`
+}
+
+// CalibrationReport is every candidate threshold a calibration swept, in
+// ascending order, and the metric it chose among them by.
+type CalibrationReport struct {
+	Metric     CalibrationMetric  `json:"metric"`
+	Candidates []ThresholdMetrics `json:"candidates"`
+}
+
+// CalibrateThreshold returns the TrustThreshold that best separates the
+// labeled scrolls that helped from those that did not, by F1.
+func CalibrateThreshold(labeled []LabeledScroll) (float64, CalibrationReport) {
+	best, report, _ := CalibrateThresholdBy(labeled, CalibrationF1)
+	return best, report
+}
+
+// CalibrateThresholdBy is CalibrateThreshold maximizing metric instead. The
+// candidates are the distinct trust scores among labeled; of thresholds
+// scoring equally the highest wins, as the more conservative. With no
+// labeled scrolls it returns 0 and an empty report.
+func CalibrateThresholdBy(labeled []LabeledScroll, metric CalibrationMetric) (float64, CalibrationReport, error) {
+	score, err := metric.scorer()
+	if err != nil {
+		return 0, CalibrationReport{}, err
+	}
+	report := CalibrationReport{Metric: metric, Candidates: []ThresholdMetrics{}}
+
+	// Sweep thresholds from the highest trust down, so each candidate adds
+	// its scrolls to the predicted positives of the one before.
+	sorted := slices.SortedFunc(slices.Values(labeled), func(a, b LabeledScroll) int {
+		return cmp.Compare(b.Scroll.TrustScore, a.Scroll.TrustScore)
+	})
+	positives := 0
+	for _, l := range labeled {
+		if l.Helped {
+			positives++
+		}
+	}
+	negatives := len(labeled) - positives
+	tp, fp := 0, 0
+	for i := 0; i < len(sorted); {
+		t := sorted[i].Scroll.TrustScore
+		for ; i < len(sorted) && sorted[i].Scroll.TrustScore == t; i++ {
+			if sorted[i].Helped {
+				tp++
+			} else {
+				fp++
+			}
+		}
+		report.Candidates = append(report.Candidates, newThresholdMetrics(t, tp, fp, positives-tp, negatives-fp))
+	}
+	slices.Reverse(report.Candidates)
+
+	var best float64
+	bestScore := math.Inf(-1)
+	for _, c := range report.Candidates {
+		if s := score(c); s >= bestScore {
+			best, bestScore = c.Threshold, s
+		}
+	}
+	return best, report, nil
+}
+
+func newThresholdMetrics(threshold float64, tp, fp, fn, tn int) ThresholdMetrics {
+	m := ThresholdMetrics{
+		Threshold:      threshold,
+		TruePositives:  tp,
+		FalsePositives: fp,
+		FalseNegatives: fn,
+		TrueNegatives:  tn,
+		Precision:      ratio(tp, tp+fp),
+		Recall:         ratio(tp, tp+fn),
+		Accuracy:       ratio(tp+tn, tp+fp+fn+tn),
+	}
+	if m.Precision+m.Recall > 0 {
+		m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+	}
+	m.Youden = m.Recall + ratio(tn, tn+fp) - 1
+	return m
+}
+
+// ratio is n/d, or 0 when d is 0.
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+func (m CalibrationMetric) scorer() (func(ThresholdMetrics) float64, error) {
+	switch m {
+	case CalibrationF1:
+		return func(t ThresholdMetrics) float64 { return t.F1 }, nil
+	case CalibrationAccuracy:
+		return func(t ThresholdMetrics) float64 { return t.Accuracy }, nil
+	case CalibrationYouden:
+		return func(t ThresholdMetrics) float64 { return t.Youden }, nil
+	}
+	return nil, fmt.Errorf("unknown calibration metric %q", m)
+}
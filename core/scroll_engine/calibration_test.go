@@ -0,0 +1,59 @@
+package scroll_engine
+
+import (
+	"math"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func labeledAt(trust float64, helped bool) LabeledScroll {
+	return LabeledScroll{Scroll: types.Scroll{TrustScore: trust}, Helped: helped}
+}
+
+func TestCalibrateThreshold_FindsKnownOptimum(t *testing.T) {
+	// Scrolls at 0.6 and above helped, bar one noisy miss; below, only one
+	// did. Thresholding at 0.6 gets 5 of 6 positives with no false ones.
+	data := []LabeledScroll{
+		labeledAt(0.1, false), labeledAt(0.2, false), labeledAt(0.3, true),
+		labeledAt(0.4, false), labeledAt(0.5, false), labeledAt(0.6, true),
+		labeledAt(0.7, true), labeledAt(0.7, true), labeledAt(0.8, false),
+		labeledAt(0.9, true), labeledAt(0.95, true),
+	}
+
+	best, report := CalibrateThreshold(data)
+	if best != 0.6 {
+		t.Fatalf("expected threshold 0.6, got %v (%+v)", best, report.Candidates)
+	}
+	if report.Metric != CalibrationF1 || len(report.Candidates) != 10 {
+		t.Fatalf("expected 10 distinct candidates by f1, got %s with %d", report.Metric, len(report.Candidates))
+	}
+	for i := 1; i < len(report.Candidates); i++ {
+		if report.Candidates[i].Threshold <= report.Candidates[i-1].Threshold {
+			t.Fatalf("expected ascending candidates, got %+v", report.Candidates)
+		}
+	}
+	at := report.Candidates[5]
+	if at.Threshold != 0.6 || at.TruePositives != 5 || at.FalsePositives != 1 || at.FalseNegatives != 1 || at.TrueNegatives != 4 {
+		t.Fatalf("unexpected counts at 0.6: %+v", at)
+	}
+	if math.Abs(at.Precision-5.0/6) > 1e-9 || math.Abs(at.Recall-5.0/6) > 1e-9 {
+		t.Fatalf("unexpected precision/recall at 0.6: %+v", at)
+	}
+}
+
+func TestCalibrateThresholdBy_Metrics(t *testing.T) {
+	data := []LabeledScroll{labeledAt(0.2, false), labeledAt(0.5, true), labeledAt(0.8, true)}
+	for _, m := range []CalibrationMetric{CalibrationF1, CalibrationAccuracy, CalibrationYouden} {
+		best, _, err := CalibrateThresholdBy(data, m)
+		if err != nil || best != 0.5 {
+			t.Errorf("%s: expected 0.5, got %v (%v)", m, best, err)
+		}
+	}
+	if _, _, err := CalibrateThresholdBy(data, "auc"); err == nil {
+		t.Fatal("expected an unknown metric to be rejected")
+	}
+	if best, report := CalibrateThreshold(nil); best != 0 || len(report.Candidates) != 0 {
+		t.Fatalf("expected no candidates without data, got %v %+v", best, report)
+	}
+}
@@ -0,0 +1,164 @@
+package scroll_engine
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// canonicalPlan is GeneInterventionPlan's canonical wire shape: every field,
+// always present, in a fixed order.
+type canonicalPlan struct {
+	MutationLoopID      string                     `json:"mutation_loop_id"`
+	Branch              string                     `json:"branch"`
+	TargetedGenes       []string                   `json:"targeted_genes"`
+	TargetDetails       []types.MarkerContribution `json:"target_details"`
+	TrustAligned        bool                       `json:"trust_aligned"`
+	RequiredRecalibrate bool                       `json:"required_recalibrate"`
+	PredictedRelief     float64                    `json:"predicted_relief"`
+	ReliefCI            [2]float64                 `json:"relief_ci"`
+	FlareSuppression    float64                    `json:"flare_suppression"`
+	RebirthEligible     bool                       `json:"rebirth_eligible"`
+	FlareSeverity       string                     `json:"flare_severity"`
+	Explanation         []canonicalReason          `json:"explanation"`
+}
+
+type canonicalReason struct {
+	Kind       string                     `json:"kind"`
+	Passed     bool                       `json:"passed"`
+	Message    string                     `json:"message"`
+	Threshold  *float64                   `json:"threshold"`
+	Value      *float64                   `json:"value"`
+	Markers    []types.MarkerContribution `json:"markers"`
+	Provenance *types.TrustProvenance     `json:"provenance"`
+}
+
+// CanonicalJSON serializes plan deterministically, so logically identical
+// plans are byte-identical: every field is present in declaration order,
+// empty lists are [] rather than absent or null, and targeted genes, target
+// details, and each explanation's markers are sorted. Explanation order is
+// kept, as it records the order decisions were made in.
+func CanonicalJSON(plan types.GeneInterventionPlan) ([]byte, error) {
+	c := canonicalPlan{
+		MutationLoopID:      plan.MutationLoopID,
+		Branch:              plan.Branch,
+		TargetedGenes:       sortedStrings(plan.TargetedGenes),
+		TargetDetails:       sortedContributions(plan.TargetDetails),
+		TrustAligned:        plan.TrustAligned,
+		RequiredRecalibrate: plan.RequiredRecalibrate,
+		PredictedRelief:     plan.PredictedRelief,
+		ReliefCI:            plan.ReliefCI,
+		FlareSuppression:    plan.FlareSuppression,
+		RebirthEligible:     plan.RebirthEligible,
+		FlareSeverity:       plan.FlareSeverity,
+		Explanation:         make([]canonicalReason, 0, len(plan.Explanation)),
+	}
+	for _, r := range plan.Explanation {
+		markers := sortedContributions(r.Markers)
+		c.Explanation = append(c.Explanation, canonicalReason{
+			Kind:       r.Kind,
+			Passed:     r.Passed,
+			Message:    r.Message,
+			Threshold:  r.Threshold,
+			Value:      r.Value,
+			Markers:    markers,
+			Provenance: canonicalProvenance(r.Provenance),
+		})
+	}
+	return json.Marshal(c)
+}
+
+// canonicalProvenance returns p with its steps never nil.
+func canonicalProvenance(p *types.TrustProvenance) *types.TrustProvenance {
+	if p == nil {
+		return nil
+	}
+	out := *p
+	out.Steps = append([]types.TrustTransform{}, p.Steps...)
+	return &out
+}
+
+// PlanContentHash digests plan's canonical form without its mutation loop
+// ID, which is unique to each simulation, so plans deciding the same thing
+// hash alike.
+func PlanContentHash(plan types.GeneInterventionPlan) (string, error) {
+	plan.MutationLoopID = ""
+	raw, err := CanonicalJSON(plan)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalContent is the canonical shape of the scroll fields that
+// influence its plan. Identity and transport fields (ID, signature) are
+// left out, so identical content submitted under different IDs is
+// byte-identical.
+type canonicalContent struct {
+	TrustScore     float64  `json:"trust_score"`
+	IsFlareEvent   bool     `json:"is_flare_event"`
+	GeneticMarkers []string `json:"genetic_markers"`
+}
+
+// canonicalContentJSON serializes scroll's plan-relevant content the way
+// CanonicalJSON serializes a plan: fields in a fixed order, always present,
+// with markers sorted.
+func canonicalContentJSON(scroll types.Scroll) ([]byte, error) {
+	return json.Marshal(canonicalContent{
+		TrustScore:     scroll.TrustScore,
+		IsFlareEvent:   scroll.IsFlareEvent,
+		GeneticMarkers: sortedStrings(scroll.GeneticMarkers),
+	})
+}
+
+// canonicalBodyHash digests a request body's canonical form, so that bodies
+// differing only in whitespace, key order, number spelling, or the order of
+// their genetic markers hash alike. A body that is not a JSON object is
+// hashed as sent.
+func canonicalBodyHash(raw []byte) [sha256.Size]byte {
+	canon, err := canonicalBodyJSON(raw)
+	if err != nil {
+		return sha256.Sum256(raw)
+	}
+	return sha256.Sum256(canon)
+}
+
+// canonicalBodyJSON re-encodes a request body with its object keys sorted,
+// numbers in Go's shortest form, and genetic markers sorted, as
+// CanonicalJSON sorts a plan's genes.
+func canonicalBodyJSON(raw []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	if markers, ok := doc["genetic_markers"].([]any); ok {
+		slices.SortFunc(markers, func(a, b any) int {
+			as, _ := a.(string)
+			bs, _ := b.(string)
+			return cmp.Compare(as, bs)
+		})
+	}
+	// Marshal sorts object keys and drops insignificant whitespace.
+	return json.Marshal(doc)
+}
+
+// sortedContributions returns a copy of in sorted by gene, never nil.
+func sortedContributions(in []types.MarkerContribution) []types.MarkerContribution {
+	out := append([]types.MarkerContribution{}, in...)
+	slices.SortFunc(out, func(a, b types.MarkerContribution) int {
+		return cmp.Compare(a.Gene, b.Gene)
+	})
+	return out
+}
+
+// sortedStrings returns a sorted copy of in, never nil.
+func sortedStrings(in []string) []string {
+	out := append([]string{}, in...)
+	slices.Sort(out)
+	return out
+}
@@ -0,0 +1,100 @@
+package scroll_engine
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestCanonicalJSON_ShuffledMarkersAreByteIdentical(t *testing.T) {
+	cfg := DefaultConfig()
+	markers := slices.Clone(cfg.FlareMarkers)
+	scroll := types.Scroll{ID: "s", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: markers}
+	want, err := CanonicalJSON(canonicalTestPlan(t, scroll, cfg))
+	if err != nil {
+		t.Fatalf("canonical: %v", err)
+	}
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 10 {
+		shuffled := slices.Clone(markers)
+		rng.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		scroll.GeneticMarkers = shuffled
+		got, err := CanonicalJSON(canonicalTestPlan(t, scroll, cfg))
+		if err != nil {
+			t.Fatalf("canonical: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("markers %v: canonical output differs:\n%s\n%s", shuffled, got, want)
+		}
+	}
+}
+
+// canonicalTestPlan simulates scroll with the loop ID, unique to each
+// simulation, fixed.
+func canonicalTestPlan(t *testing.T, scroll types.Scroll, cfg SimulationConfig) types.GeneInterventionPlan {
+	t.Helper()
+	plan := mustSimulate(t, scroll, cfg)
+	plan.MutationLoopID = "loop"
+	return plan
+}
+
+func TestCanonicalJSON_EmptyFieldsPresent(t *testing.T) {
+	raw, err := CanonicalJSON(types.GeneInterventionPlan{Branch: BranchDiscovery})
+	if err != nil {
+		t.Fatalf("canonical: %v", err)
+	}
+	want := `{"mutation_loop_id":"","branch":"discovery","targeted_genes":[],"target_details":[],"trust_aligned":false,"required_recalibrate":false,"predicted_relief":0,"relief_ci":[0,0],"flare_suppression":0,"rebirth_eligible":false,"flare_severity":"","explanation":[]}`
+	if string(raw) != want {
+		t.Fatalf("unexpected canonical form:\n got %s\nwant %s", raw, want)
+	}
+}
+
+func TestPlanContentHash_IgnoresLoopID(t *testing.T) {
+	a := types.GeneInterventionPlan{MutationLoopID: "a", Branch: BranchFlare, TargetedGenes: []string{"X", "Y"}}
+	b := types.GeneInterventionPlan{MutationLoopID: "b", Branch: BranchFlare, TargetedGenes: []string{"Y", "X"}}
+	ha, _ := PlanContentHash(a)
+	hb, _ := PlanContentHash(b)
+	if ha == "" || ha != hb {
+		t.Fatalf("expected equal content hashes, got %q and %q", ha, hb)
+	}
+}
+
+func TestSimulate_IdempotencyIgnoresMarkerOrder(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	first := postSimulate(h, `{"id":"s","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2","IL23R"]}`, "k1")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", first.Code)
+	}
+	retry := postSimulate(h, `{"genetic_markers":["IL23R","NOD2"], "is_flare_event":true,"trust_score":0.9,"id":"s"}`, "k1")
+	if retry.Code != http.StatusOK || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected a reordered retry to replay, got %d", retry.Code)
+	}
+	if !bytes.Equal(retry.Body.Bytes(), first.Body.Bytes()) {
+		t.Fatalf("expected the replayed body to match the original")
+	}
+}
+
+func TestScrollContentHash_IgnoresIDAndMarkerOrder(t *testing.T) {
+	a, _ := scrollContentHash(types.Scroll{ID: "a", TrustScore: 0.9, GeneticMarkers: []string{"NOD2", "IL23R"}})
+	b, _ := scrollContentHash(types.Scroll{ID: "b", TrustScore: 0.9, GeneticMarkers: []string{"IL23R", "NOD2"}})
+	if a != b {
+		t.Fatalf("expected equal content hashes")
+	}
+	c, _ := scrollContentHash(types.Scroll{ID: "a", TrustScore: 0.8, GeneticMarkers: []string{"NOD2", "IL23R"}})
+	if a == c {
+		t.Fatalf("expected a trust change to change the content hash")
+	}
+}
+
+func TestCanonicalBodyHash_NormalizesNumbers(t *testing.T) {
+	a := canonicalBodyHash([]byte(`{"id":"s","trust_score":0.9}`))
+	b := canonicalBodyHash([]byte(`{"trust_score":0.90, "id":"s"}`))
+	if a != b {
+		t.Fatalf("expected number spelling and key order not to change the body hash")
+	}
+}
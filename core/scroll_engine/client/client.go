@@ -0,0 +1,174 @@
+// Package client is a typed Go client for the scroll engine HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// TenantHeader carries the tenant a request acts for.
+const TenantHeader = "X-Tenant-ID"
+
+// Client calls a scroll server. It is safe for concurrent use.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	tenant  string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithHTTPClient sends requests through hc instead of a default client.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithTimeout bounds each request, including reading the response body.
+// It applies to the client set by WithHTTPClient if that option comes
+// first.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		hc := *c.http
+		hc.Timeout = d
+		c.http = &hc
+	}
+}
+
+// WithTenant sends every request on behalf of tenant. The server rejects
+// store-backed requests without one.
+func WithTenant(tenant string) Option {
+	return func(c *Client) { c.tenant = tenant }
+}
+
+// New returns a Client for the server at baseURL, e.g.
+// "http://localhost:8282".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is an error response from the server.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Field   string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("scroll server: %d %s: %s", e.Status, e.Code, e.Message)
+	if e.Field != "" {
+		msg += " (field " + e.Field + ")"
+	}
+	return msg
+}
+
+// ScrollPage is one page of a scroll listing and the total across pages.
+type ScrollPage struct {
+	Scrolls []types.Scroll `json:"scrolls"`
+	Total   int            `json:"total"`
+}
+
+// Simulate runs a simulation for scroll and returns its plan.
+func (c *Client) Simulate(ctx context.Context, scroll types.Scroll) (types.GeneInterventionPlan, error) {
+	var plan types.GeneInterventionPlan
+	err := c.do(ctx, http.MethodPost, "/simulate", scroll, &plan)
+	return plan, err
+}
+
+// GetScroll returns the stored scroll with id.
+func (c *Client) GetScroll(ctx context.Context, id string) (types.Scroll, error) {
+	var scroll types.Scroll
+	err := c.do(ctx, http.MethodGet, "/scrolls/"+url.PathEscape(id), nil, &scroll)
+	return scroll, err
+}
+
+// ListScrolls returns a page of stored scrolls. A zero limit uses the
+// server's default page size.
+func (c *Client) ListScrolls(ctx context.Context, limit, offset int) (ScrollPage, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		q.Set("offset", strconv.Itoa(offset))
+	}
+	path := "/scrolls"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var page ScrollPage
+	err := c.do(ctx, http.MethodGet, path, nil, &page)
+	return page, err
+}
+
+// do sends a request with body encoded as JSON, if non-nil, and decodes a
+// successful response into out. Error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var rd io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, rd)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.tenant != "" {
+		req.Header.Set(TenantHeader, c.tenant)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// decodeError reads the server's {"error":{...}} envelope, falling back to
+// the status text for responses that don't carry one.
+func decodeError(resp *http.Response) error {
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Field   string `json:"field"`
+		} `json:"error"`
+	}
+	e := &Error{Status: resp.StatusCode}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err == nil && envelope.Error.Code != "" {
+		e.Code, e.Message, e.Field = envelope.Error.Code, envelope.Error.Message, envelope.Error.Field
+	} else {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	scrollengine "Maple-OS/modem_os/core/scroll_engine"
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func newTestClient(t *testing.T, opts ...Option) *Client {
+	t.Helper()
+	cfg := scrollengine.DefaultConfig()
+	cfg.AccessLog = false
+	ts := httptest.NewServer(scrollengine.NewServer(cfg).Handler())
+	t.Cleanup(ts.Close)
+	return New(ts.URL+"/", append([]Option{WithTenant("test")}, opts...)...)
+}
+
+func TestClient_SimulateAndFetch(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	plan, err := c.Simulate(ctx, types.Scroll{ID: "s1", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2"}})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if plan.Branch != scrollengine.BranchFlare || len(plan.TargetedGenes) != 1 {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	scroll, err := c.GetScroll(ctx, "s1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if scroll.ID != "s1" || scroll.TrustScore != 0.9 || scroll.Timestamp.IsZero() {
+		t.Fatalf("unexpected scroll: %+v", scroll)
+	}
+}
+
+func TestClient_ListScrolls(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := c.Simulate(ctx, types.Scroll{ID: id, TrustScore: 0.5}); err != nil {
+			t.Fatalf("simulate %s: %v", id, err)
+		}
+	}
+
+	page, err := c.ListScrolls(ctx, 1, 1)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if page.Total != 3 || len(page.Scrolls) != 1 || page.Scrolls[0].ID != "b" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	c := newTestClient(t)
+	ctx := context.Background()
+
+	_, err := c.GetScroll(ctx, "missing")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound || apiErr.Code != scrollengine.CodeNotFound {
+		t.Fatalf("expected a not_found *Error, got %v", err)
+	}
+
+	_, err = c.Simulate(ctx, types.Scroll{ID: "bad", TrustScore: 2})
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest || apiErr.Field != "trust_score" {
+		t.Fatalf("expected an invalid trust_score *Error, got %v", err)
+	}
+}
+
+func TestClient_TenantScoped(t *testing.T) {
+	cfg := scrollengine.DefaultConfig()
+	cfg.AccessLog = false
+	ts := httptest.NewServer(scrollengine.NewServer(cfg).Handler())
+	t.Cleanup(ts.Close)
+	ctx := context.Background()
+
+	acme := New(ts.URL, WithTenant("acme"))
+	if _, err := acme.Simulate(ctx, types.Scroll{ID: "s1", TrustScore: 0.5}); err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	var apiErr *Error
+	if _, err := New(ts.URL, WithTenant("globex")).GetScroll(ctx, "s1"); !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Fatalf("expected another tenant's scroll to be not found, got %v", err)
+	}
+	if _, err := New(ts.URL).GetScroll(ctx, "s1"); !errors.As(err, &apiErr) || apiErr.Code != scrollengine.CodeMissingTenant {
+		t.Fatalf("expected missing_tenant without a tenant, got %v", err)
+	}
+}
+
+func TestClient_TimeoutOption(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(slow.Close)
+
+	c := New(slow.URL, WithHTTPClient(&http.Client{}), WithTimeout(20*time.Millisecond))
+	if _, err := c.GetScroll(context.Background(), "s1"); err == nil {
+		t.Fatalf("expected the request to time out")
+	}
+}
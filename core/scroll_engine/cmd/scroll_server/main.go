@@ -1,13 +1,147 @@
 package main
 
 import (
-    "log"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
 
-    scrollengine "Maple-OS/modem_os/core/scroll_engine"
+	scrollengine "Maple-OS/modem_os/core/scroll_engine"
 )
 
 func main() {
-	if err := scrollengine.StartServer(":8282"); err != nil {
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	addr := flag.String("addr", ":8282", "listen address")
+	configPath := flag.String("config", "", "JSON config file (defaults apply when omitted)")
+	flag.Parse()
+
+	cfg := scrollengine.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if cfg, err = scrollengine.LoadConfig(*configPath); err != nil {
+			log.Fatalf("load config %s: %v", *configPath, err)
+		}
+	}
+
+	if err := scrollengine.StartServer(*addr, cfg, *configPath); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// runReplay re-simulates every scroll stored in the store the config
+// selects under that config, and reports which decisions flipped against
+// the stored plans. Replayed plans are saved next to the originals under
+// the replay namespace and written as NDJSON to stdout.
+func runReplay(args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	configPath := fs.String("config", "", "JSON config to replay under (required)")
+	tenant := fs.String("tenant", scrollengine.DefaultTenant, "tenant whose scrolls to replay")
+	namespace := fs.String("namespace", "replay-"+time.Now().UTC().Format("20060102T150405"), "namespace for replayed plans")
+	_ = fs.Parse(args)
+	if *configPath == "" {
+		fs.Usage()
+		return fmt.Errorf("replay: -config is required")
+	}
+
+	cfg, err := scrollengine.LoadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	store, err := scrollengine.OpenStore(cfg.Store)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	if c, ok := store.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	report, err := scrollengine.Replay(context.Background(), store, *tenant, cfg, nil)
+	if err != nil {
+		return err
+	}
+	if err := scrollengine.SaveReplay(store, *tenant, *namespace, report); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, res := range report.Results {
+		if err := enc.Encode(map[string]any{
+			"key":  scrollengine.ReplayPlanKey(*namespace, res.ScrollID),
+			"plan": res.Replayed,
+		}); err != nil {
+			return err
+		}
+	}
+	log.Printf("replayed %d scrolls into %s: %d decisions flipped %v",
+		report.Total, *namespace, report.Flipped, report.Flips)
+	return nil
+}
+
+// runImport simulates and persists the scrolls in a JSON or NDJSON file to
+// the store the config selects, reporting what it imported as JSON on
+// stdout. Scrolls already stored are skipped unless -overwrite is given.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "", "JSON array or NDJSON file of scrolls (required)")
+	configPath := fs.String("config", "", "JSON config file (defaults apply when omitted)")
+	tenant := fs.String("tenant", scrollengine.DefaultTenant, "tenant to import into")
+	overwrite := fs.Bool("overwrite", false, "re-import scrolls that are already stored")
+	_ = fs.Parse(args)
+	if *file == "" {
+		fs.Usage()
+		return fmt.Errorf("import: -file is required")
+	}
+
+	cfg := scrollengine.DefaultConfig()
+	if *configPath != "" {
+		var err error
+		if cfg, err = scrollengine.LoadConfig(*configPath); err != nil {
+			return fmt.Errorf("load config: %w", err)
+		}
+	}
+	store, err := scrollengine.OpenStore(cfg.Store)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	if c, ok := store.(io.Closer); ok {
+		defer c.Close()
+	}
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	srv := scrollengine.NewServerWithStore(cfg, store)
+	report, err := srv.Import(context.Background(), *tenant, f, *overwrite)
+	if err != nil {
+		return fmt.Errorf("import %s: %w", *file, err)
+	}
+	for _, fail := range report.Failed {
+		log.Printf("line %d: scroll %q: %s", fail.Line, fail.ID, fail.Error)
+	}
+	log.Printf("imported %d scrolls, skipped %d, failed %d", report.Imported, report.Skipped, len(report.Failed))
+	if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+		return err
+	}
+	if len(report.Failed) > 0 {
+		return fmt.Errorf("import: %d scrolls failed", len(report.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package scroll_engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// CompostReason says why a scroll was composted. Only the Reason constants
+// are valid; taking the type rather than a string keeps callers to them.
+type CompostReason string
+
+// Compost reasons.
+const (
+	// ReasonLowTrust: the scroll's trust fell at or below a cutoff.
+	ReasonLowTrust CompostReason = "low_trust"
+	// ReasonDrift: the scroll no longer agrees with the current panel or
+	// config.
+	ReasonDrift CompostReason = "drift"
+	// ReasonExpired: the scroll aged past a cutoff.
+	ReasonExpired CompostReason = "expired"
+	// ReasonManual: an operator composted the scroll by hand.
+	ReasonManual CompostReason = "manual"
+)
+
+// Valid reports whether r is one of the Reason constants. Reasons arriving
+// as request data must be checked with it.
+func (r CompostReason) Valid() bool {
+	switch r {
+	case ReasonLowTrust, ReasonDrift, ReasonExpired, ReasonManual:
+		return true
+	}
+	return false
+}
+
+// compostSeries is the scroll_compost_total series for reason.
+func compostSeries(reason CompostReason) string {
+	return fmt.Sprintf("scroll_compost_total{reason=%q}", reason)
+}
+
+// CompostFilter selects stored scrolls for bulk composting. A scroll
+// matches when it satisfies every criterion that is set.
+type CompostFilter struct {
+	// MaxTrust matches scrolls whose trust score is at or below it.
+	MaxTrust *float64 `json:"max_trust,omitempty"`
+	// OlderThan matches scrolls whose Timestamp is further in the past.
+	// Scrolls without a timestamp never match.
+	OlderThan Duration `json:"older_than,omitempty"`
+	// Reason is recorded for every matched scroll. When unset it is
+	// ReasonLowTrust if MaxTrust is set and ReasonExpired otherwise.
+	Reason CompostReason `json:"reason,omitempty"`
+}
+
+func (f CompostFilter) empty() bool {
+	return f.MaxTrust == nil && f.OlderThan == 0
+}
+
+func (f CompostFilter) reason() CompostReason {
+	switch {
+	case f.Reason != "":
+		return f.Reason
+	case f.MaxTrust != nil:
+		return ReasonLowTrust
+	}
+	return ReasonExpired
+}
+
+// match reports whether scroll satisfies the filter and, if so, describes
+// which criteria it met.
+func (f CompostFilter) match(scroll types.Scroll, now time.Time) (string, bool) {
+	var details []string
+	if f.MaxTrust != nil {
+		if scroll.TrustScore > *f.MaxTrust {
+			return "", false
+		}
+		details = append(details, fmt.Sprintf("trust %.2f <= max_trust %.2f", scroll.TrustScore, *f.MaxTrust))
+	}
+	if f.OlderThan > 0 {
+		if scroll.Timestamp.IsZero() {
+			return "", false
+		}
+		age := now.Sub(scroll.Timestamp)
+		if age <= time.Duration(f.OlderThan) {
+			return "", false
+		}
+		details = append(details, fmt.Sprintf("age %s > older_than %s", age.Round(time.Second), time.Duration(f.OlderThan)))
+	}
+	return strings.Join(details, "; "), true
+}
+
+// CompostOutcome is the result of composting one scroll in a batch.
+type CompostOutcome struct {
+	ID     string        `json:"id"`
+	Reason CompostReason `json:"reason,omitempty"`
+	// Detail describes the filter criteria the scroll met.
+	Detail string `json:"detail,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkCompostResult lists the scrolls a bulk compost moved to the compost
+// bin and those it matched but failed to move.
+type BulkCompostResult struct {
+	Composted []CompostOutcome `json:"composted"`
+	Failed    []CompostOutcome `json:"failed"`
+}
+
+// BulkCompost composts every scroll tenant has stored that matches filter. A failure on
+// one scroll is recorded and the batch continues.
+func BulkCompost(store ScrollStore, tenant string, filter CompostFilter, now time.Time) (BulkCompostResult, error) {
+	res := BulkCompostResult{Composted: []CompostOutcome{}, Failed: []CompostOutcome{}}
+	scrolls, err := store.ListScrolls(tenant, ScrollQuery{})
+	if err != nil {
+		return res, err
+	}
+	reason := filter.reason()
+	for _, scroll := range scrolls {
+		detail, ok := filter.match(scroll, now)
+		if !ok {
+			continue
+		}
+		if err := store.CompostScroll(tenant, scroll.ID, reason, now); err != nil {
+			res.Failed = append(res.Failed, CompostOutcome{ID: scroll.ID, Reason: reason, Detail: detail, Error: err.Error()})
+			continue
+		}
+		res.Composted = append(res.Composted, CompostOutcome{ID: scroll.ID, Reason: reason, Detail: detail})
+	}
+	return res, nil
+}
+
+// StartCompostDecay runs a worker that, every interval, permanently purges
+// compost entries composted more than retention ago, logging each one. It
+// stops when ctx is cancelled; the returned channel is closed once it has.
+func (s *Server) StartCompostDecay(ctx context.Context, interval, retention time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				s.decayCompost(now.UTC().Add(-retention))
+			}
+		}
+	}()
+	return done
+}
+
+func (s *Server) decayCompost(before time.Time) {
+	purged, err := s.store.PurgeCompost(before)
+	if err != nil {
+		log.Printf("compost decay failed: %v", err)
+		return
+	}
+	for _, c := range purged {
+		log.Printf("Scroll %s/%s decayed out of compost (composted %s: %s)",
+			c.Tenant, c.Scroll.ID, c.CompostedAt.Format(time.RFC3339), c.Reason)
+	}
+	s.metrics.Add("compost_purged_total", float64(len(purged)))
+}
+
+// StartCompostRebirth runs a worker that, every interval, re-evaluates
+// composted scrolls against the active config and restores each one that
+// would now be rebirth-eligible — for example because its markers were added
+// to the flare panel. A restored scroll gets a fresh plan and audit entry and
+// is announced to /events/flares subscribers as reborn. The worker stops
+// when ctx is cancelled; the returned channel is closed once it has.
+func (s *Server) StartCompostRebirth(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.rebirthCompost(ctx)
+			}
+		}
+	}()
+	return done
+}
+
+func (s *Server) rebirthCompost(ctx context.Context) {
+	bin, err := s.store.ListAllCompost()
+	if err != nil {
+		log.Printf("compost rebirth failed: %v", err)
+		return
+	}
+	active := s.active.Load()
+	for _, c := range bin {
+		if !active.engine().rebirthEligible(c.Scroll) {
+			continue
+		}
+		plan, err := s.rebirth(ctx, s.engineFor(active, c.Tenant, nil), c)
+		if err != nil {
+			log.Printf("compost rebirth of %s/%s failed: %v", c.Tenant, c.Scroll.ID, err)
+			continue
+		}
+		log.Printf("Scroll %s/%s reborn from compost (composted %s: %s)",
+			c.Tenant, c.Scroll.ID, c.CompostedAt.Format(time.RFC3339), c.Reason)
+		s.metrics.Inc("compost_reborn_total")
+		s.publishFlareEvent(FlareEvent{Tenant: c.Tenant, ScrollID: c.Scroll.ID, Plan: plan, Reborn: true})
+	}
+}
+
+// rebirth restores c with a fresh plan and audit entry. The plan's loop
+// settles as persist's do.
+func (s *Server) rebirth(ctx context.Context, engine *compiledConfig, c CompostedScroll) (plan types.GeneInterventionPlan, err error) {
+	plan, err = engine.simulate(ctx, c.Scroll)
+	if err != nil {
+		return plan, err
+	}
+	if err := s.store.RestoreScroll(c.Tenant, c.Scroll.ID); err != nil {
+		return plan, err
+	}
+	s.loops.StartFor(c.Tenant, c.Scroll.ID, plan.MutationLoopID)
+	_, _ = s.loops.Advance(plan.MutationLoopID)
+	defer func() { _, _ = s.loops.Settle(plan.MutationLoopID, err == nil) }()
+	if err := s.store.SavePlan(c.Tenant, c.Scroll.ID, plan); err != nil {
+		return plan, err
+	}
+	return plan, s.store.AppendAudit(c.Tenant, newAuditEntry(c.Scroll, plan, engine, time.Now().UTC()))
+}
@@ -0,0 +1,302 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+var compostNow = time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+func seedCompostStore() *MemoryStore {
+	store := NewMemoryStore()
+	old := compostNow.Add(-60 * 24 * time.Hour)
+	recent := compostNow.Add(-24 * time.Hour)
+	for _, s := range []types.Scroll{
+		{ID: "old-low", TrustScore: 0.1, Timestamp: old},
+		{ID: "old-high", TrustScore: 0.9, Timestamp: old},
+		{ID: "recent-low", TrustScore: 0.2, Timestamp: recent},
+		{ID: "undated-low", TrustScore: 0.1},
+	} {
+		_ = store.SaveScroll(testTenant, s)
+	}
+	return store
+}
+
+func compostedIDs(outcomes []CompostOutcome) []string {
+	ids := []string{}
+	for _, o := range outcomes {
+		ids = append(ids, o.ID)
+	}
+	return ids
+}
+
+func TestBulkCompost_MatchesEveryCriterion(t *testing.T) {
+	store := seedCompostStore()
+	maxTrust := 0.3
+
+	res, err := BulkCompost(store, testTenant, CompostFilter{MaxTrust: &maxTrust, OlderThan: Duration(720 * time.Hour)}, compostNow)
+	if err != nil {
+		t.Fatalf("compost: %v", err)
+	}
+	if got := strings.Join(compostedIDs(res.Composted), ","); got != "old-low" {
+		t.Fatalf("expected only old-low composted, got %s", got)
+	}
+	if res.Composted[0].Reason != ReasonLowTrust || res.Composted[0].Detail == "" {
+		t.Fatalf("expected a low_trust reason with detail, got %+v", res.Composted[0])
+	}
+
+	left, _ := store.ListScrolls(testTenant, ScrollQuery{})
+	bin, _ := store.ListCompost(testTenant)
+	if len(left) != 3 || len(bin) != 1 || bin[0].Scroll.ID != "old-low" {
+		t.Fatalf("expected old-low moved to the compost bin, got %d active / %v", len(left), bin)
+	}
+}
+
+// flakyCompostStore fails to compost one scroll.
+type flakyCompostStore struct {
+	*MemoryStore
+	failID string
+}
+
+func (f flakyCompostStore) CompostScroll(tenant, id string, reason CompostReason, at time.Time) error {
+	if id == f.failID {
+		return errors.New("disk full")
+	}
+	return f.MemoryStore.CompostScroll(tenant, id, reason, at)
+}
+
+func TestBulkCompost_ReportsPartialFailures(t *testing.T) {
+	store := flakyCompostStore{MemoryStore: seedCompostStore(), failID: "recent-low"}
+	maxTrust := 0.3
+
+	res, err := BulkCompost(store, testTenant, CompostFilter{MaxTrust: &maxTrust}, compostNow)
+	if err != nil {
+		t.Fatalf("compost: %v", err)
+	}
+	if got := strings.Join(compostedIDs(res.Composted), ","); got != "old-low,undated-low" {
+		t.Fatalf("expected batch to continue past the failure, composted %s", got)
+	}
+	if len(res.Failed) != 1 || res.Failed[0].ID != "recent-low" || res.Failed[0].Error != "disk full" {
+		t.Fatalf("expected recent-low reported as failed, got %+v", res.Failed)
+	}
+}
+
+func TestBulkCompostHandler_RequiresConfirm(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	srv.store = seedCompostStore()
+	h := srv.Handler()
+	body := `{"max_trust":0.3}`
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodPost, "/scrolls/compost", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without confirm, got %d", rec.Code)
+	}
+	if bin, _ := srv.store.ListCompost(testTenant); len(bin) != 0 {
+		t.Fatalf("expected nothing composted without confirm")
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodPost, "/scrolls/compost?confirm=true", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var res BulkCompostResult
+	_ = json.NewDecoder(rec.Body).Decode(&res)
+	if len(res.Composted) != 3 {
+		t.Fatalf("expected 3 low-trust scrolls composted, got %+v", res)
+	}
+}
+
+func TestCompostDecay_PurgesOnlyExpiredEntries(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	now := time.Now().UTC()
+	_ = srv.store.SaveScroll(testTenant, types.Scroll{ID: "stale", TrustScore: 0.1})
+	_ = srv.store.SaveScroll(testTenant, types.Scroll{ID: "fresh", TrustScore: 0.1})
+	_ = srv.store.CompostScroll(testTenant, "stale", ReasonDrift, now.Add(-time.Hour))
+	_ = srv.store.CompostScroll(testTenant, "fresh", ReasonDrift, now.Add(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := srv.StartCompostDecay(ctx, 5*time.Millisecond, time.Minute)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		bin, _ := srv.store.ListCompost(testTenant)
+		if len(bin) == 1 {
+			if bin[0].Scroll.ID != "fresh" {
+				t.Fatalf("expected fresh entry to survive, got %+v", bin)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stale compost entry was never purged: %+v", bin)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := srv.metrics.Counter("compost_purged_total"); got != 1 {
+		t.Fatalf("expected 1 purge counted, got %v", got)
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("decay worker did not stop after cancellation")
+	}
+}
+
+func TestPurgeCompost_DeletesPlans(t *testing.T) {
+	for name, store := range map[string]ScrollStore{"memory": NewMemoryStore(), "sqlite": openTestSQLite(t)} {
+		for _, id := range []string{"stale", "fresh"} {
+			_ = store.SaveScroll(testTenant, types.Scroll{ID: id, TrustScore: 0.1})
+			_ = store.SavePlan(testTenant, id, types.GeneInterventionPlan{Branch: BranchCompost})
+		}
+		_ = store.CompostScroll(testTenant, "stale", ReasonDrift, day(1))
+		_ = store.CompostScroll(testTenant, "fresh", ReasonDrift, day(9))
+
+		if _, err := store.PurgeCompost(day(5)); err != nil {
+			t.Fatalf("%s: purge: %v", name, err)
+		}
+		if _, err := store.GetPlan(testTenant, "stale"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("%s: expected the purged scroll's plan deleted, got %v", name, err)
+		}
+		if _, err := store.GetPlan(testTenant, "fresh"); err != nil {
+			t.Fatalf("%s: expected the composted scroll's plan kept, got %v", name, err)
+		}
+		var plans int
+		_ = store.EachPlan(testTenant, func(types.GeneInterventionPlan) error { plans++; return nil })
+		if plans != 1 {
+			t.Fatalf("%s: expected 1 plan left to aggregate, got %d", name, plans)
+		}
+	}
+}
+
+func TestBulkCompostHandler_RecordsReasonLabels(t *testing.T) {
+	cases := []struct {
+		name, body string
+		want       CompostReason
+	}{
+		{"trust cutoff", `{"max_trust":0.3}`, ReasonLowTrust},
+		{"age cutoff", `{"older_than":"1h"}`, ReasonExpired},
+		{"explicit drift", `{"max_trust":0.3,"reason":"drift"}`, ReasonDrift},
+		{"explicit manual", `{"older_than":"1h","reason":"manual"}`, ReasonManual},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := NewServer(DefaultConfig())
+			srv.store = seedCompostStore()
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, newTenantRequest(http.MethodPost, "/scrolls/compost?confirm=true", strings.NewReader(c.body)))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+			}
+			bin, _ := srv.store.ListCompost(testTenant)
+			if len(bin) == 0 {
+				t.Fatalf("expected scrolls composted")
+			}
+			for _, b := range bin {
+				if b.Reason != c.want {
+					t.Fatalf("scroll %s composted as %q, want %q", b.Scroll.ID, b.Reason, c.want)
+				}
+			}
+			if got := srv.metrics.Counter(compostSeries(c.want)); got != float64(len(bin)) {
+				t.Fatalf("expected %d counted under %s, got %v", len(bin), compostSeries(c.want), got)
+			}
+		})
+	}
+
+	srv := NewServer(DefaultConfig())
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, newTenantRequest(http.MethodPost, "/scrolls/compost?confirm=true", strings.NewReader(`{"max_trust":0.3,"reason":"bored"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown reason, got %d", rec.Code)
+	}
+}
+
+func TestCompostRebirth_RevivesScrollOnceOnPanel(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FlareMarkers = []string{"NOD2"}
+	srv := NewServer(cfg)
+	revivable := types.Scroll{ID: "il23r-flare", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"IL23R"}}
+	for _, s := range []types.Scroll{
+		revivable,
+		{ID: "quiet", TrustScore: 0.9, GeneticMarkers: []string{"IL23R"}},
+		{ID: "untrusted", TrustScore: 0.2, IsFlareEvent: true, GeneticMarkers: []string{"IL23R"}},
+	} {
+		_ = srv.store.SaveScroll(testTenant, s)
+		_ = srv.store.CompostScroll(testTenant, s.ID, ReasonDrift, compostNow)
+	}
+	events := srv.flares.subscribe(testTenant)
+	defer srv.flares.unsubscribe(events)
+
+	// Nothing is eligible until IL23R joins the panel.
+	srv.rebirthCompost(t.Context())
+	if bin, _ := srv.store.ListCompost(testTenant); len(bin) != 3 {
+		t.Fatalf("expected nothing reborn off-panel, got bin %+v", bin)
+	}
+
+	cfg.FlareMarkers = []string{"NOD2", "IL23R"}
+	if err := srv.Reload(cfg); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := srv.StartCompostRebirth(ctx, 5*time.Millisecond)
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	select {
+	case raw := <-events:
+		var e FlareEvent
+		if err := json.Unmarshal(raw, &e); err != nil || !e.Reborn || e.ScrollID != revivable.ID || e.Plan.Branch != BranchFlare {
+			t.Fatalf("expected a reborn flare event for %s, got %s (%v)", revivable.ID, raw, err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("scroll was never reborn")
+	}
+
+	if _, err := srv.store.GetScroll(testTenant, revivable.ID); err != nil {
+		t.Fatalf("expected the reborn scroll restored, got %v", err)
+	}
+	if plan, _ := srv.store.GetPlan(testTenant, revivable.ID); !plan.RebirthEligible {
+		t.Fatalf("expected a rebirth-eligible plan stored, got %+v", plan)
+	}
+	bin, _ := srv.store.ListCompost(testTenant)
+	if len(bin) != 2 {
+		t.Fatalf("expected ineligible scrolls left in compost, got %+v", bin)
+	}
+	if got := srv.metrics.Counter("compost_reborn_total"); got != 1 {
+		t.Fatalf("expected 1 rebirth counted, got %v", got)
+	}
+}
+
+func TestRestoreScroll_ConflictsWithResavedID(t *testing.T) {
+	for name, store := range map[string]ScrollStore{"memory": NewMemoryStore(), "sqlite": openTestSQLite(t)} {
+		_ = store.SaveScroll(testTenant, types.Scroll{ID: "s1", TrustScore: 0.2})
+		_ = store.CompostScroll(testTenant, "s1", ReasonLowTrust, compostNow)
+		resaved := types.Scroll{ID: "s1", TrustScore: 0.9}
+		if err := store.SaveScroll(testTenant, resaved); err != nil {
+			t.Fatalf("%s: re-save: %v", name, err)
+		}
+
+		if err := store.RestoreScroll(testTenant, "s1"); !errors.Is(err, ErrExists) {
+			t.Fatalf("%s: expected ErrExists restoring over a re-saved ID, got %v", name, err)
+		}
+		got, err := store.GetScroll(testTenant, "s1")
+		if err != nil || got.TrustScore != resaved.TrustScore {
+			t.Fatalf("%s: expected the re-saved scroll kept, got %+v (%v)", name, got, err)
+		}
+		if n, _ := store.CountScrolls(testTenant, ScrollQuery{}); n != 1 {
+			t.Fatalf("%s: expected s1 listed once, got %d scrolls", name, n)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package scroll_engine
+
+import (
+	"compress/gzip"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compress wraps next so responses of at least minBytes are gzipped for
+// clients that accept it. Smaller responses, and event streams, pass
+// through untouched; so does any response flushed before reaching minBytes,
+// since it is being streamed.
+func compress(minBytes int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipWriter{ResponseWriter: w, minBytes: minBytes}
+		defer gw.finish()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether r's Accept-Encoding allows gzip.
+func acceptsGzip(r *http.Request) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(v), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		f, err := strconv.ParseFloat(q, 64)
+		return err == nil && f > 0
+	}
+	return false
+}
+
+// gzipWriter holds back a response until it has seen minBytes of it, then
+// commits to gzipping it or, if the response ends or is flushed first, to
+// sending it as is.
+type gzipWriter struct {
+	http.ResponseWriter
+	minBytes int
+	status   int
+	buf      []byte
+	decided  bool
+	gz       *gzip.Writer
+}
+
+func (g *gzipWriter) WriteHeader(status int) {
+	if g.status == 0 {
+		g.status = status
+	}
+}
+
+func (g *gzipWriter) Write(b []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	if !g.decided {
+		g.buf = append(g.buf, b...)
+		if len(g.buf) < g.minBytes {
+			return len(b), nil
+		}
+		if err := g.decide(g.compressible()); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if g.gz != nil {
+		return g.gz.Write(b)
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+// compressible reports whether the response held back is worth gzipping.
+func (g *gzipWriter) compressible() bool {
+	h := g.Header()
+	if h.Get("Content-Encoding") != "" || g.status < 200 || g.status == http.StatusNoContent || g.status == http.StatusNotModified {
+		return false
+	}
+	mt, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return mt != "text/event-stream"
+}
+
+// decide writes the held-back status and body, gzipped or not.
+func (g *gzipWriter) decide(gzipped bool) error {
+	g.decided = true
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	if gzipped {
+		h := g.Header()
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if g.gz != nil {
+		_, err = g.gz.Write(buf)
+	} else {
+		_, err = g.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// FlushError sends what is held back uncompressed, as a streamed response
+// never reaches minBytes before it wants the client to see it.
+func (g *gzipWriter) FlushError() error {
+	if !g.decided {
+		if err := g.decide(false); err != nil {
+			return err
+		}
+	}
+	if g.gz != nil {
+		if err := g.gz.Flush(); err != nil {
+			return err
+		}
+	}
+	return http.NewResponseController(g.ResponseWriter).Flush()
+}
+
+// finish sends a response that ended below minBytes and closes the gzip
+// stream of one that did not.
+func (g *gzipWriter) finish() {
+	if !g.decided {
+		if g.status == 0 && len(g.buf) == 0 {
+			// Nothing was written; let net/http send its default.
+			return
+		}
+		_ = g.decide(false)
+		return
+	}
+	if g.gz != nil {
+		_ = g.gz.Close()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (g *gzipWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
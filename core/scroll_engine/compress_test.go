@@ -0,0 +1,84 @@
+package scroll_engine
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestCompress_LargeListingIsGzipped(t *testing.T) {
+	store := NewMemoryStore()
+	for i := range 200 {
+		_ = store.SaveScroll(testTenant, types.Scroll{ID: fmt.Sprintf("s%03d", i), TrustScore: 0.5, GeneticMarkers: []string{"NOD2", "IL23R"}})
+	}
+	h := NewServerWithStore(DefaultConfig(), store).Handler()
+
+	req := newTenantRequest(http.MethodGet, "/scrolls?limit=200", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected a gzipped 200, got %d with encoding %q", rec.Code, rec.Header().Get("Content-Encoding"))
+	}
+	if vary := rec.Header().Get("Vary"); vary != "Accept-Encoding" {
+		t.Fatalf("expected Vary: Accept-Encoding, got %q", vary)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	var page ScrollPage
+	if err := json.NewDecoder(zr).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if page.Total != 200 || len(page.Scrolls) != 200 || page.Scrolls[199].ID != "s199" {
+		t.Fatalf("expected all 200 scrolls to round-trip, got total %d with %d", page.Total, len(page.Scrolls))
+	}
+}
+
+func TestCompress_SkipsSmallAndUnrequested(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+
+	req := newTenantRequest(http.MethodGet, "/health", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "" || !strings.Contains(rec.Body.String(), "ok") {
+		t.Fatalf("expected a small response sent as is, got %q: %s", rec.Header().Get("Content-Encoding"), rec.Body)
+	}
+
+	big := strings.Repeat("x", 10000)
+	plain := compress(100, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(big))
+	}))
+	for _, ae := range []string{"", "identity", "gzip;q=0"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", ae)
+		rec := httptest.NewRecorder()
+		plain.ServeHTTP(rec, req)
+		if rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != len(big) {
+			t.Fatalf("Accept-Encoding %q: expected an uncompressed body", ae)
+		}
+	}
+}
+
+func TestCompress_SkipsEventStreams(t *testing.T) {
+	h := compress(100, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(strings.Repeat("data: x\n\n", 100)))
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "" || !strings.HasPrefix(rec.Body.String(), "data: x") {
+		t.Fatalf("expected an event stream sent as is, got encoding %q", rec.Header().Get("Content-Encoding"))
+	}
+}
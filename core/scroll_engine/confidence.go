@@ -0,0 +1,36 @@
+package scroll_engine
+
+import (
+	"math"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// reliefTrustPenalty is how far the relief interval widens on each side per
+// unit of missing trust.
+const reliefTrustPenalty = 0.5
+
+// ReliefInterval returns a [lower, upper] interval around relief, clamped to
+// [0,1]. The model is deliberately simple: the half-width is the 95%
+// normal-approximation margin of the mean of the targets' relief weights,
+// 1.96·σ/√n, plus reliefTrustPenalty·(1 − trust). Targets that agree narrow
+// the interval; low-trust scrolls widen it. Without per-target
+// contributions only the trust term applies.
+func ReliefInterval(relief, trust float64, contributions []types.MarkerContribution) [2]float64 {
+	half := reliefTrustPenalty * (1 - trust)
+	if n := float64(len(contributions)); n > 1 {
+		var sum, sumSq float64
+		for _, c := range contributions {
+			sum += c.Relief
+			sumSq += c.Relief * c.Relief
+		}
+		mean := sum / n
+		variance := math.Max(sumSq/n-mean*mean, 0)
+		half += 1.96 * math.Sqrt(variance/n)
+	}
+	return [2]float64{clampUnit(relief - half), clampUnit(relief + half)}
+}
+
+func clampUnit(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
@@ -0,0 +1,41 @@
+package scroll_engine
+
+import (
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestReliefCI_LowTrustIsWider(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MarkerWeights = map[string]MarkerWeight{"NOD2": {Relief: 0.6, Suppression: 0.7}}
+	markers := []string{"NOD2", "IL23R"}
+	low := mustSimulate(t, types.Scroll{ID: "low", TrustScore: 0.72, IsFlareEvent: true, GeneticMarkers: markers}, cfg)
+	high := mustSimulate(t, types.Scroll{ID: "high", TrustScore: 0.98, IsFlareEvent: true, GeneticMarkers: markers}, cfg)
+
+	width := func(ci [2]float64) float64 { return ci[1] - ci[0] }
+	if width(low.ReliefCI) <= width(high.ReliefCI) {
+		t.Fatalf("expected low-trust interval %v to be wider than high-trust %v", low.ReliefCI, high.ReliefCI)
+	}
+	for _, p := range []types.GeneInterventionPlan{low, high} {
+		if p.ReliefCI[0] > p.PredictedRelief || p.ReliefCI[1] < p.PredictedRelief {
+			t.Fatalf("interval %v does not contain relief %v", p.ReliefCI, p.PredictedRelief)
+		}
+	}
+}
+
+func TestReliefInterval_Clamped(t *testing.T) {
+	contributions := []types.MarkerContribution{{Relief: 0}, {Relief: 1}}
+	ci := ReliefInterval(0.5, 0, contributions)
+	if ci != [2]float64{0, 1} {
+		t.Fatalf("expected interval clamped to [0,1], got %v", ci)
+	}
+}
+
+func TestReliefInterval_AgreeingMarkersNarrower(t *testing.T) {
+	agree := ReliefInterval(0.5, 0.9, []types.MarkerContribution{{Relief: 0.5}, {Relief: 0.5}})
+	spread := ReliefInterval(0.5, 0.9, []types.MarkerContribution{{Relief: 0.2}, {Relief: 0.8}})
+	if agree[1]-agree[0] >= spread[1]-spread[0] {
+		t.Fatalf("expected agreeing weights %v to give a narrower interval than spread ones %v", agree, spread)
+	}
+}
@@ -0,0 +1,525 @@
+package scroll_engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SimulationConfig holds the tunables for the scroll engine and its server.
+type SimulationConfig struct {
+	// TrustThreshold is the minimum trust score for a scroll to be
+	// considered trust-aligned.
+	TrustThreshold float64 `json:"trust_threshold"`
+
+	// IdempotencyTTL is how long a plan is replayed for a repeated
+	// Idempotency-Key before the key may be reused.
+	IdempotencyTTL Duration `json:"idempotency_ttl"`
+
+	// LoopRetention is how long a mutation loop stays listed after its
+	// last transition.
+	LoopRetention Duration `json:"loop_retention"`
+
+	// ScrollSigningKey is the shared secret scroll signatures are verified
+	// against. When empty, signatures are not checked.
+	ScrollSigningKey string `json:"scroll_signing_key"`
+
+	// AdminToken is the bearer token POST /admin/maintenance, POST
+	// /admin/reload, POST /admin/replay and POST /scrolls/{id}/unfreeze
+	// require. When empty, none of them can be used over HTTP.
+	AdminToken string `json:"admin_token"`
+
+	// ScrollIDs is how a scroll submitted without an ID gets one:
+	// ScrollIDContent (the default) or ScrollIDUUID.
+	ScrollIDs string `json:"scroll_ids"`
+
+	// PlanCacheSize is the number of plans kept in the content-hash LRU
+	// cache. Zero disables caching.
+	PlanCacheSize int `json:"plan_cache_size"`
+
+	// FlareMarkers is the flare panel: the markers a flare intervention may
+	// target. An empty panel places no restriction on flare targets.
+	FlareMarkers []string `json:"flare_markers"`
+
+	// MinFlareMarkers is how many of a flare's markers must be on the panel
+	// for it to be intervened on; a flare matching fewer is held in memory.
+	// Values below 1 mean 1.
+	MinFlareMarkers int `json:"min_flare_markers"`
+
+	// MaxMarkers is the most distinct markers, after canonicalization and
+	// dedup, a scroll may carry. Zero means no limit.
+	MaxMarkers int `json:"max_markers"`
+
+	// MarkerAliases maps alternative gene symbols onto canonical ones, e.g.
+	// {"TL1A": "TNFSF15"}. Chains resolve to their final symbol.
+	MarkerAliases map[string]string `json:"marker_aliases"`
+
+	// FlareSeverity sets the bounds used to label flare plans.
+	FlareSeverity FlareSeverityThresholds `json:"flare_severity"`
+
+	// LoopIDs generates plan MutationLoopIDs. Nil uses
+	// TimestampLoopIDGenerator.
+	LoopIDs LoopIDGenerator `json:"-"`
+
+	// MarkerWeights and DefaultMarkerWeight drive the default
+	// WeightedStrategy; DefaultMarkerWeight applies to unlisted markers.
+	MarkerWeights       map[string]MarkerWeight `json:"marker_weights"`
+	DefaultMarkerWeight MarkerWeight            `json:"default_marker_weight"`
+
+	// ScoringURL, when set, scores flares with an external model service
+	// (see HTTPScoringStrategy), retried under ScoringRetry and falling back
+	// to the weighted strategy. ScoringBreaker stops calling the service
+	// while it keeps failing; its state restarts closed on reload.
+	ScoringURL     string        `json:"scoring_url"`
+	ScoringRetry   RetryPolicy   `json:"scoring_retry"`
+	ScoringBreaker BreakerPolicy `json:"scoring_breaker"`
+
+	// ScoringKind selects how the weighted strategy averages marker
+	// weights: ScoringWeighted evenly, or ScoringIDF by each marker's
+	// inverse document frequency across the tenant's stored scrolls,
+	// recounted every IDFRefreshInterval. IDF scoring cannot be combined
+	// with ScoringURL.
+	ScoringKind        string   `json:"scoring"`
+	IDFRefreshInterval Duration `json:"idf_refresh_interval"`
+
+	// WeightOverrides are merged over MarkerWeights for a single
+	// simulation; see WithWeightOverrides.
+	WeightOverrides map[string]MarkerWeight `json:"-"`
+
+	// Scoring overrides the strategy built from the settings above.
+	Scoring ScoringStrategy `json:"-"`
+
+	// AccessLog logs every request, with its X-Request-ID, status, size,
+	// and duration, via slog.
+	AccessLog bool `json:"access_log"`
+
+	// MaxBodyBytes caps a request body, and MaxStreamBodyBytes an NDJSON
+	// one carrying many scrolls; larger bodies get a 413. Zero is no limit.
+	MaxBodyBytes       int64 `json:"max_body_bytes"`
+	MaxStreamBodyBytes int64 `json:"max_stream_body_bytes"`
+
+	// GzipMinBytes is the response size from which responses are gzipped
+	// for clients sending Accept-Encoding: gzip. Zero disables compression.
+	GzipMinBytes int `json:"gzip_min_bytes"`
+
+	// StatsCacheTTL is how long GET /stats serves a computed summary before
+	// rescanning the store. Zero recomputes on every request.
+	StatsCacheTTL Duration `json:"stats_cache_ttl"`
+
+	// CompostDecayInterval is how often StartServer's decay worker scans
+	// the compost bin; entries composted more than CompostRetention ago are
+	// purged for good.
+	CompostDecayInterval Duration `json:"compost_decay_interval"`
+	CompostRetention     Duration `json:"compost_retention"`
+
+	// CompostRebirth opts in to StartServer's rebirth worker, which every
+	// CompostRebirthInterval restores composted scrolls the current config
+	// would now find rebirth-eligible.
+	CompostRebirth         bool     `json:"compost_rebirth"`
+	CompostRebirthInterval Duration `json:"compost_rebirth_interval"`
+
+	// EventKeepalive is how long an idle /events/flares stream waits
+	// before sending a keepalive comment.
+	EventKeepalive Duration `json:"event_keepalive"`
+
+	// AsyncWorkers is the number of workers StartServer runs to drain
+	// POST /simulate/async.
+	AsyncWorkers int `json:"async_workers"`
+
+	// RequestBudget bounds a /simulate request end to end. Scoring and then
+	// webhook delivery each get whatever of it remains: scoring that runs
+	// out yields an unscored plan, and a webhook that runs out is deferred
+	// to the webhook worker. Zero means no budget.
+	RequestBudget Duration `json:"request_budget"`
+
+	// WebhookURL, when set, receives a WebhookEvent for every plan.
+	WebhookURL string `json:"webhook_url"`
+
+	// WebhookSecret, when set, is the key every webhook body is signed
+	// with; receivers check it with VerifyWebhook. When empty, webhooks are
+	// sent unsigned.
+	WebhookSecret string `json:"webhook_secret"`
+
+	// Store selects the persistence backend StartServer opens.
+	Store StoreConfig `json:"store"`
+
+	// StoreFailure is what /simulate does when the store cannot save its
+	// scroll or plan: StoreFailClosed (the default) fails the request, and
+	// StoreFailOpen returns the plan with persisted set to false.
+	StoreFailure string `json:"store_failure"`
+
+	// FlarePrecedence settles a scroll whose trigger contradicts its
+	// is_flare_event: FlareReject (the default) rejects it with a 400,
+	// FlareIsFlareEvent keeps is_flare_event, and FlareTrigger keeps the
+	// trigger.
+	FlarePrecedence string `json:"flare_precedence"`
+
+	// TrustRecency weights scrolls by age in AggregateTrust.
+	TrustRecency RecencyKernel `json:"trust_recency"`
+
+	// TrustDecay, when its kind is set, scales a scroll's trust by the
+	// kernel's weight for the scroll's age before it is compared against
+	// TrustThreshold. Undated scrolls are not decayed. Plans decided under
+	// decay depend on when they were decided, so they are not cached.
+	TrustDecay RecencyKernel `json:"trust_decay"`
+}
+
+// StoreConfig selects a ScrollStore backend. Driver is "memory" (the
+// default) or "sqlite", for which DSN names the database file.
+type StoreConfig struct {
+	Driver string `json:"driver"`
+	DSN    string `json:"dsn"`
+}
+
+// DefaultConfig returns the configuration the server runs with when no
+// overrides are supplied.
+func DefaultConfig() SimulationConfig {
+	return SimulationConfig{
+		TrustThreshold:         0.7,
+		IdempotencyTTL:         Duration(24 * time.Hour),
+		LoopRetention:          Duration(time.Hour),
+		PlanCacheSize:          1024,
+		MaxMarkers:             256,
+		MinFlareMarkers:        1,
+		DefaultMarkerWeight:    MarkerWeight{Relief: 0.87, Suppression: 0.91},
+		ScoringRetry:           DefaultRetryPolicy(),
+		ScoringBreaker:         DefaultBreakerPolicy(),
+		ScoringKind:            ScoringWeighted,
+		IDFRefreshInterval:     Duration(5 * time.Minute),
+		AccessLog:              true,
+		GzipMinBytes:           4096,
+		ScrollIDs:              ScrollIDContent,
+		MaxBodyBytes:           1 << 20,
+		MaxStreamBodyBytes:     64 << 20,
+		StatsCacheTTL:          Duration(5 * time.Second),
+		CompostDecayInterval:   Duration(time.Hour),
+		CompostRetention:       Duration(30 * 24 * time.Hour),
+		CompostRebirthInterval: Duration(time.Hour),
+		EventKeepalive:         Duration(15 * time.Second),
+		AsyncWorkers:           2,
+		Store:                  StoreConfig{Driver: "memory"},
+		StoreFailure:           StoreFailClosed,
+		FlarePrecedence:        FlareReject,
+		TrustRecency: RecencyKernel{
+			Kind:     RecencyExponential,
+			HalfLife: Duration(7 * 24 * time.Hour),
+			Window:   Duration(30 * 24 * time.Hour),
+		},
+		FlareSeverity: FlareSeverityThresholds{
+			Severe:   SeverityBound{MinMarkers: 3, MinTrust: 0.85, MaxSuppression: 0.95},
+			Moderate: SeverityBound{MinMarkers: 2, MinTrust: 0.75, MaxSuppression: 1},
+		},
+	}
+}
+
+// Duration is a time.Duration written in JSON as a Go duration string such
+// as "24h" or "90s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON reports a value that is not a duration string as a
+// *json.UnmarshalTypeError, so the decoder fills in the key it was under.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return &json.UnmarshalTypeError{Value: string(b), Type: durationType}
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: string(b), Type: durationType}
+	}
+	*d = Duration(v)
+	return nil
+}
+
+var durationType = reflect.TypeFor[Duration]()
+
+// ConfigError reports a config key that could not be loaded.
+type ConfigError struct {
+	Key     string
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	if e.Key == "" {
+		return "config: " + e.Message
+	}
+	return fmt.Sprintf("config key %q: %s", e.Key, e.Message)
+}
+
+// LoadConfig reads a JSON config file. Keys missing from the file keep
+// their DefaultConfig values; unknown keys, malformed JSON, and out-of-range
+// values are rejected with a *ConfigError naming the key.
+func LoadConfig(path string) (SimulationConfig, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return SimulationConfig{}, err
+	}
+	return ParseConfig(raw)
+}
+
+// ParseConfig is LoadConfig for an in-memory document.
+func ParseConfig(raw []byte) (SimulationConfig, error) {
+	return parseConfigOver(DefaultConfig(), raw)
+}
+
+// parseConfigOver is ParseConfig with keys missing from raw keeping their
+// values in base rather than the defaults. Maps in raw are merged into
+// copies of base's, so base is never modified.
+func parseConfigOver(base SimulationConfig, raw []byte) (SimulationConfig, error) {
+	cfg := base
+	cfg.FlareMarkers = slices.Clone(base.FlareMarkers)
+	cfg.MarkerAliases = maps.Clone(base.MarkerAliases)
+	cfg.MarkerWeights = maps.Clone(base.MarkerWeights)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return SimulationConfig{}, configDecodeError(err, raw)
+	}
+	if err := cfg.Validate(); err != nil {
+		return SimulationConfig{}, err
+	}
+	return cfg, nil
+}
+
+func configDecodeError(err error, raw []byte) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Type == durationType:
+		key := typeErr.Field
+		if key == "" {
+			key = badDurationKey(raw)
+		}
+		return &ConfigError{Key: key,
+			Message: fmt.Sprintf("expected a duration string like \"24h\", got %s", typeErr.Value)}
+	case errors.As(err, &typeErr):
+		return &ConfigError{Key: typeErr.Field, Message: fmt.Sprintf("expected %s", typeErr.Type)}
+	case errors.As(err, &syntaxErr):
+		return &ConfigError{Message: fmt.Sprintf("malformed JSON at offset %d: %v", syntaxErr.Offset, err)}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		key := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return &ConfigError{Key: key, Message: "unknown key"}
+	}
+	return &ConfigError{Message: err.Error()}
+}
+
+// badDurationKey returns the dotted key of the first value in raw that
+// should decode as a Duration but does not. Not every encoding/json
+// implementation names the key for an error from UnmarshalJSON, so the
+// document is walked against SimulationConfig to find it.
+func badDurationKey(raw []byte) string {
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return ""
+	}
+	return badDurationPath(doc, reflect.TypeFor[SimulationConfig](), "")
+}
+
+func badDurationPath(v any, t reflect.Type, key string) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == durationType {
+		b, _ := json.Marshal(v)
+		if new(Duration).UnmarshalJSON(b) != nil {
+			return key
+		}
+		return ""
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return ""
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if !f.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if sub, ok := obj[name]; ok {
+				if p := badDurationPath(sub, f.Type, joinKey(key, name)); p != "" {
+					return p
+				}
+			}
+		}
+	case reflect.Map:
+		for _, name := range slices.Sorted(maps.Keys(obj)) {
+			if p := badDurationPath(obj[name], t.Elem(), joinKey(key, name)); p != "" {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// Validate checks every tunable is within its allowed range.
+func (c SimulationConfig) Validate() error {
+	if err := unitRange("trust_threshold", c.TrustThreshold); err != nil {
+		return err
+	}
+	if c.IdempotencyTTL <= 0 {
+		return &ConfigError{Key: "idempotency_ttl", Message: "must be positive"}
+	}
+	if c.LoopRetention <= 0 {
+		return &ConfigError{Key: "loop_retention", Message: "must be positive"}
+	}
+	if c.MaxMarkers < 0 {
+		return &ConfigError{Key: "max_markers", Message: "must not be negative"}
+	}
+	if c.MinFlareMarkers < 0 {
+		return &ConfigError{Key: "min_flare_markers", Message: "must not be negative"}
+	}
+	if c.PlanCacheSize < 0 {
+		return &ConfigError{Key: "plan_cache_size", Message: "must not be negative"}
+	}
+	if c.StatsCacheTTL < 0 {
+		return &ConfigError{Key: "stats_cache_ttl", Message: "must not be negative"}
+	}
+	if c.CompostDecayInterval <= 0 {
+		return &ConfigError{Key: "compost_decay_interval", Message: "must be positive"}
+	}
+	if c.MaxBodyBytes < 0 {
+		return &ConfigError{Key: "max_body_bytes", Message: "must not be negative"}
+	}
+	if c.MaxStreamBodyBytes < 0 {
+		return &ConfigError{Key: "max_stream_body_bytes", Message: "must not be negative"}
+	}
+	if c.GzipMinBytes < 0 {
+		return &ConfigError{Key: "gzip_min_bytes", Message: "must not be negative"}
+	}
+	if c.RequestBudget < 0 {
+		return &ConfigError{Key: "request_budget", Message: "must not be negative"}
+	}
+	if c.CompostRetention < 0 {
+		return &ConfigError{Key: "compost_retention", Message: "must not be negative"}
+	}
+	if c.CompostRebirth && c.CompostRebirthInterval <= 0 {
+		return &ConfigError{Key: "compost_rebirth_interval", Message: "must be positive when compost_rebirth is set"}
+	}
+	if c.EventKeepalive <= 0 {
+		return &ConfigError{Key: "event_keepalive", Message: "must be positive"}
+	}
+	if c.AsyncWorkers < 1 {
+		return &ConfigError{Key: "async_workers", Message: "must be at least 1"}
+	}
+	if chain := aliasCycle(c.MarkerAliases); chain != nil {
+		return &ConfigError{Key: "marker_aliases." + chain[0], Message: "alias cycle " + strings.Join(chain, " -> ")}
+	}
+	if err := validateWeight("default_marker_weight", c.DefaultMarkerWeight); err != nil {
+		return err
+	}
+	for marker, w := range c.MarkerWeights {
+		if err := validateWeight("marker_weights."+marker, w); err != nil {
+			return err
+		}
+	}
+	if err := c.validateScoringKind(); err != nil {
+		return err
+	}
+	if c.ScoringRetry.MaxAttempts < 1 {
+		return &ConfigError{Key: "scoring_retry.max_attempts", Message: "must be at least 1"}
+	}
+	if c.ScoringRetry.BaseDelay < 0 || c.ScoringRetry.MaxDelay < c.ScoringRetry.BaseDelay {
+		return &ConfigError{Key: "scoring_retry.max_delay", Message: "must be at least base_delay, which must not be negative"}
+	}
+	if err := unitRange("scoring_retry.jitter", c.ScoringRetry.Jitter); err != nil {
+		return err
+	}
+	if err := c.ScoringBreaker.validate("scoring_breaker"); err != nil {
+		return err
+	}
+	if c.ScrollIDs != ScrollIDContent && c.ScrollIDs != ScrollIDUUID {
+		return &ConfigError{Key: "scroll_ids", Message: fmt.Sprintf("must be %q or %q", ScrollIDContent, ScrollIDUUID)}
+	}
+	if err := validateStoreFailure(c.StoreFailure); err != nil {
+		return err
+	}
+	if err := validateFlarePrecedence(c.FlarePrecedence); err != nil {
+		return err
+	}
+	switch c.Store.Driver {
+	case "memory":
+	case "sqlite":
+		if c.Store.DSN == "" {
+			return &ConfigError{Key: "store.dsn", Message: "required for the sqlite driver"}
+		}
+	default:
+		return &ConfigError{Key: "store.driver", Message: fmt.Sprintf("unknown driver %q", c.Store.Driver)}
+	}
+	if err := c.TrustRecency.validate("trust_recency"); err != nil {
+		return err
+	}
+	if c.TrustDecay.Kind != "" {
+		if err := c.TrustDecay.validate("trust_decay"); err != nil {
+			return err
+		}
+	}
+	for _, sb := range []struct {
+		name string
+		b    SeverityBound
+	}{
+		{"flare_severity.severe", c.FlareSeverity.Severe},
+		{"flare_severity.moderate", c.FlareSeverity.Moderate},
+	} {
+		name, b := sb.name, sb.b
+		if b.MinMarkers < 0 {
+			return &ConfigError{Key: name + ".min_markers", Message: "must not be negative"}
+		}
+		if err := unitRange(name+".min_trust", b.MinTrust); err != nil {
+			return err
+		}
+		if err := unitRange(name+".max_suppression", b.MaxSuppression); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateWeight(key string, w MarkerWeight) error {
+	if err := unitRange(key+".relief", w.Relief); err != nil {
+		return err
+	}
+	return unitRange(key+".suppression", w.Suppression)
+}
+
+func unitRange(key string, v float64) error {
+	if v < 0 || v > 1 {
+		return &ConfigError{Key: key, Message: fmt.Sprintf("%v is outside [0,1]", v)}
+	}
+	return nil
+}
+
+// markerRegistry returns a registry resolving the configured aliases.
+func (c SimulationConfig) markerRegistry() *MarkerRegistry {
+	return NewMarkerRegistry(c.MarkerAliases)
+}
+
+// WithWeightOverrides returns a copy of c whose weighted scoring uses
+// overrides in place of the configured weight for each listed marker.
+func (c SimulationConfig) WithWeightOverrides(overrides map[string]MarkerWeight) SimulationConfig {
+	c.WeightOverrides = overrides
+	return c
+}
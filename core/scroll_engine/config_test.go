@@ -0,0 +1,102 @@
+package scroll_engine
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_Full(t *testing.T) {
+	path := writeConfig(t, `{
+		"trust_threshold": 0.8,
+		"idempotency_ttl": "1h",
+		"scroll_signing_key": "secret",
+		"plan_cache_size": 16,
+		"flare_markers": ["NOD2", "IL23R"],
+		"flare_severity": {
+			"severe":   {"min_markers": 4, "min_trust": 0.9, "max_suppression": 0.8},
+			"moderate": {"min_markers": 2, "min_trust": 0.8, "max_suppression": 1}
+		},
+		"marker_weights": {"NOD2": {"relief": 0.6, "suppression": 0.7}},
+		"default_marker_weight": {"relief": 0.5, "suppression": 0.5}
+	}`)
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.TrustThreshold != 0.8 || time.Duration(cfg.IdempotencyTTL) != time.Hour || cfg.PlanCacheSize != 16 {
+		t.Fatalf("unexpected scalars: %+v", cfg)
+	}
+	if len(cfg.FlareMarkers) != 2 || cfg.FlareSeverity.Severe.MinMarkers != 4 {
+		t.Fatalf("unexpected flare settings: %+v", cfg)
+	}
+	if cfg.MarkerWeights["NOD2"].Relief != 0.6 || cfg.DefaultMarkerWeight.Relief != 0.5 {
+		t.Fatalf("unexpected weights: %+v", cfg)
+	}
+}
+
+func TestLoadConfig_PartialKeepsDefaults(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, `{"trust_threshold": 0.5}`))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	def := DefaultConfig()
+	if cfg.TrustThreshold != 0.5 {
+		t.Fatalf("expected override 0.5, got %v", cfg.TrustThreshold)
+	}
+	if cfg.IdempotencyTTL != def.IdempotencyTTL || cfg.PlanCacheSize != def.PlanCacheSize ||
+		cfg.DefaultMarkerWeight != def.DefaultMarkerWeight || cfg.FlareSeverity != def.FlareSeverity {
+		t.Fatalf("expected unspecified keys to keep defaults, got %+v", cfg)
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	cases := []struct {
+		name, body, key string
+	}{
+		{"out of range", `{"trust_threshold": 1.5}`, "trust_threshold"},
+		{"nested out of range", `{"marker_weights": {"NOD2": {"relief": -0.1}}}`, "marker_weights.NOD2.relief"},
+		{"wrong type", `{"plan_cache_size": "big"}`, "plan_cache_size"},
+		{"bad duration", `{"idempotency_ttl": "soon"}`, "idempotency_ttl"},
+		{"scoring kind", `{"scoring": "bm25"}`, "scoring"},
+		{"idf with scoring url", `{"scoring": "idf", "scoring_url": "http://model"}`, "scoring"},
+		{"idf refresh interval", `{"scoring": "idf", "idf_refresh_interval": "0s"}`, "idf_refresh_interval"},
+		{"loop retention", `{"loop_retention": "0s"}`, "loop_retention"},
+		{"numeric duration", `{"idempotency_ttl": 5}`, "idempotency_ttl"},
+		{"nested bad duration", `{"trust_recency": {"kind": "linear", "window": "later"}}`, "trust_recency.window"},
+		{"unknown key", `{"trust_treshold": 0.5}`, "trust_treshold"},
+		{"unknown kernel", `{"trust_recency": {"kind": "cubic"}}`, "trust_recency.kind"},
+		{"kernel parameter", `{"trust_recency": {"kind": "linear", "window": "0s"}}`, "trust_recency.window"},
+		{"decay kernel", `{"trust_decay": {"kind": "exponential"}}`, "trust_decay.half_life"},
+		{"scroll ids", `{"scroll_ids": "sequential"}`, "scroll_ids"},
+		{"min flare markers", `{"min_flare_markers": -1}`, "min_flare_markers"},
+		{"store failure", `{"store_failure": "sometimes"}`, "store_failure"},
+		{"flare precedence", `{"flare_precedence": "majority"}`, "flare_precedence"},
+		{"breaker window", `{"scoring_breaker": {"failures": 3, "window": "0s"}}`, "scoring_breaker.window"},
+		{"malformed", `{"trust_threshold": 0.5`, ""},
+	}
+	for _, c := range cases {
+		_, err := LoadConfig(writeConfig(t, c.body))
+		var cfgErr *ConfigError
+		if !errors.As(err, &cfgErr) {
+			t.Errorf("%s: expected *ConfigError, got %v", c.name, err)
+			continue
+		}
+		if cfgErr.Key != c.key {
+			t.Errorf("%s: expected key %q, got %q (%v)", c.name, c.key, cfgErr.Key, err)
+		}
+	}
+}
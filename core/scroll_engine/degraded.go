@@ -0,0 +1,49 @@
+package scroll_engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// What /simulate does when the store fails to save its scroll or plan; see
+// SimulationConfig.StoreFailure.
+const (
+	// StoreFailClosed fails the request with a 500, so a client never sees
+	// a plan that was not recorded.
+	StoreFailClosed = "closed"
+	// StoreFailOpen returns the plan anyway, marked as not persisted, since
+	// the decision matters more than its record.
+	StoreFailOpen = "open"
+)
+
+// persistOrDegrade persists a simulated scroll and its plan as persist does,
+// reporting whether they were stored. A failure is counted and, when the
+// store fails open, logged and swallowed; otherwise it is returned. A
+// refusal to overwrite a frozen scroll is not a failure and is always
+// returned.
+func (s *Server) persistOrDegrade(ctx context.Context, tenant string, scroll types.Scroll, plan types.GeneInterventionPlan) (bool, error) {
+	err := s.persist(ctx, tenant, scroll, plan)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, ErrFrozen) {
+		return false, err
+	}
+	s.metrics.Inc("store_write_failures_total")
+	if s.config().StoreFailure != StoreFailOpen {
+		return false, err
+	}
+	log.Printf("store unavailable, returning plan for scroll %s unpersisted: %v", scroll.ID, err)
+	return false, nil
+}
+
+func validateStoreFailure(mode string) error {
+	if mode != StoreFailClosed && mode != StoreFailOpen {
+		return &ConfigError{Key: "store_failure", Message: fmt.Sprintf("must be %q or %q", StoreFailClosed, StoreFailOpen)}
+	}
+	return nil
+}
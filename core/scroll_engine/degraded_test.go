@@ -0,0 +1,55 @@
+package scroll_engine
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// downStore fails every write.
+type downStore struct {
+	*MemoryStore
+}
+
+func (downStore) SaveScroll(string, types.Scroll) error {
+	return errors.New("store unavailable")
+}
+
+func TestSimulate_StoreFailOpenReturnsPlan(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.StoreFailure = StoreFailOpen
+	srv := NewServerWithStore(cfg, downStore{NewMemoryStore()})
+
+	resp := decodeSimulateResponse(t, postSimulate(srv.Handler(), budgetFlareBody, ""))
+	if resp.Persisted {
+		t.Fatalf("expected the plan marked as not persisted")
+	}
+	if resp.Branch != BranchFlare || resp.MutationLoopID == "" {
+		t.Fatalf("expected the computed flare plan, got %+v", resp.GeneInterventionPlan)
+	}
+	if got := srv.metrics.Counter("store_write_failures_total"); got != 1 {
+		t.Fatalf("expected one store write failure counted, got %v", got)
+	}
+}
+
+func TestSimulate_StoreFailClosedFailsRequest(t *testing.T) {
+	srv := NewServerWithStore(DefaultConfig(), downStore{NewMemoryStore()})
+
+	rec := postSimulate(srv.Handler(), budgetFlareBody, "")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when failing closed, got %d: %s", rec.Code, rec.Body)
+	}
+	if got := srv.metrics.Counter("store_write_failures_total"); got != 1 {
+		t.Fatalf("expected one store write failure counted, got %v", got)
+	}
+}
+
+func TestSimulate_ReportsPersisted(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+
+	if resp := decodeSimulateResponse(t, postSimulate(srv.Handler(), budgetFlareBody, "")); !resp.Persisted {
+		t.Fatalf("expected a stored plan reported as persisted")
+	}
+}
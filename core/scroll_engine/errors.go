@@ -0,0 +1,185 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// Error codes returned in the "code" field of an error response.
+const (
+	CodeInvalidInput        = "invalid_input"
+	CodeEmptyBody           = "empty_body"
+	CodeBodyTooLarge        = "body_too_large"
+	CodeMalformedJSON       = "malformed_json"
+	CodeInvalidScroll       = "invalid_scroll"
+	CodeInvalidSignature    = "invalid_signature"
+	CodeIdempotencyConflict = "idempotency_conflict"
+	CodeIdempotencyBusy     = "idempotency_in_progress"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodeNotFound            = "not_found"
+	CodeClientClosed        = "client_closed_request"
+	CodeDeadlineExceeded    = "deadline_exceeded"
+	CodeUnavailable         = "unavailable"
+	CodeMaintenance         = "maintenance"
+	CodeUnauthorized        = "unauthorized"
+	CodeScrollFrozen        = "scroll_frozen"
+	CodeLineageCycle        = "lineage_cycle"
+	CodeMissingTenant       = "missing_tenant"
+	CodeInvalidConfig       = "invalid_config"
+	CodeNotAcceptable       = "not_acceptable"
+	CodeInternal            = "internal_error"
+)
+
+// StatusClientClosedRequest is the non-standard status (popularized by
+// nginx) recorded when the client goes away before the response is written.
+const StatusClientClosedRequest = 499
+
+// ErrorBody is the payload of an error response.
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+	// Violations lists every way a scroll failed its JSON Schema; Field and
+	// Message describe the first.
+	Violations []SchemaViolation `json:"violations,omitempty"`
+}
+
+// ErrorResponse is the JSON envelope every handler uses to report errors:
+//
+//	{"error":{"code":"invalid_scroll","message":"...","field":"trust_score"}}
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg, field string) {
+	writeErrorBody(w, status, ErrorBody{Code: code, Message: msg, Field: field})
+}
+
+func writeErrorBody(w http.ResponseWriter, status int, body ErrorBody) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Error: body})
+}
+
+// errEmptyBody and errTrailingData are returned by decodeBody for a body
+// with no JSON value and for data following the value, respectively.
+var (
+	errEmptyBody    = errors.New("request body is empty")
+	errTrailingData = errors.New("unexpected data after JSON value")
+)
+
+// decodeBody decodes exactly one JSON value from r into v.
+func decodeBody(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errEmptyBody
+		}
+		return err
+	}
+	// dec.More alone misses a stray closing delimiter, so look for EOF.
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errTrailingData
+	}
+	return nil
+}
+
+// requestError is an error response not yet written: its status and body.
+type requestError struct {
+	status int
+	body   ErrorBody
+}
+
+func newRequestError(status int, code, msg, field string) *requestError {
+	return &requestError{status: status, body: ErrorBody{Code: code, Message: msg, Field: field}}
+}
+
+func (e *requestError) write(w http.ResponseWriter) {
+	writeErrorBody(w, e.status, e.body)
+}
+
+// decodeError classifies a request body that failed to decode: empty,
+// malformed, or holding a value of the wrong type, naming the offending
+// field when the decoder knows it.
+func decodeError(err error) *requestError {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		return bodyTooLarge(maxErr.Limit)
+	case errors.Is(err, errEmptyBody):
+		return newRequestError(http.StatusBadRequest, CodeEmptyBody, err.Error(), "")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, errTrailingData):
+		return newRequestError(http.StatusBadRequest, CodeMalformedJSON, err.Error(), "")
+	case errors.As(err, &typeErr) && isFloatOverflow(typeErr):
+		return newRequestError(http.StatusBadRequest, CodeInvalidInput,
+			typeErr.Value+" is out of range: must be a finite number", typeErr.Field)
+	case errors.As(err, &typeErr):
+		return newRequestError(http.StatusBadRequest, CodeInvalidInput, err.Error(), typeErr.Field)
+	default:
+		return newRequestError(http.StatusBadRequest, CodeInvalidInput, err.Error(), "")
+	}
+}
+
+// bodyTooLarge is the error for a request body over its limit bytes.
+func bodyTooLarge(limit int64) *requestError {
+	return newRequestError(http.StatusRequestEntityTooLarge, CodeBodyTooLarge,
+		fmt.Sprintf("request body exceeds the %d-byte limit", limit), "")
+}
+
+// isFloatOverflow reports whether err is a JSON number too large for the
+// float field it was decoded into, such as 1e400.
+func isFloatOverflow(err *json.UnmarshalTypeError) bool {
+	if err.Type == nil || !strings.HasPrefix(err.Value, "number") {
+		return false
+	}
+	k := err.Type.Kind()
+	return k == reflect.Float32 || k == reflect.Float64
+}
+
+// validationError classifies a scroll that decoded but failed Validate.
+func validationError(err error) *requestError {
+	var vErr *types.ValidationError
+	if errors.As(err, &vErr) {
+		return newRequestError(http.StatusBadRequest, CodeInvalidScroll, vErr.Message, vErr.Field)
+	}
+	return newRequestError(http.StatusBadRequest, CodeInvalidScroll, err.Error(), "")
+}
+
+// simulationError classifies a simulation that did not complete. A scroll
+// the engine rejected gets 400; a client that disconnected gets 499; a
+// deadline that expired gets 503.
+func simulationError(err error) *requestError {
+	var vErr *types.ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return validationError(err)
+	case errors.Is(err, context.Canceled):
+		return newRequestError(StatusClientClosedRequest, CodeClientClosed, err.Error(), "")
+	case errors.Is(err, context.DeadlineExceeded):
+		return newRequestError(http.StatusServiceUnavailable, CodeDeadlineExceeded, err.Error(), "")
+	default:
+		return newRequestError(http.StatusInternalServerError, CodeInternal, err.Error(), "")
+	}
+}
+
+func writeDecodeError(w http.ResponseWriter, err error) {
+	decodeError(err).write(w)
+}
+
+func writeValidationError(w http.ResponseWriter, err error) {
+	validationError(err).write(w)
+}
+
+func writeSimulationError(w http.ResponseWriter, err error) {
+	simulationError(err).write(w)
+}
@@ -0,0 +1,152 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func decodeErrorResponse(t *testing.T, rec *httptest.ResponseRecorder) ErrorBody {
+	t.Helper()
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("expected JSON error, got Content-Type %q", ct)
+	}
+	var raw map[string]map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&raw); err != nil {
+		t.Fatalf("decode error envelope: %v", err)
+	}
+	e, ok := raw["error"]
+	if !ok {
+		t.Fatalf("expected top-level \"error\" object, got %v", raw)
+	}
+	body := ErrorBody{}
+	body.Code, _ = e["code"].(string)
+	body.Message, _ = e["message"].(string)
+	body.Field, _ = e["field"].(string)
+	return body
+}
+
+func TestSimulate_InvalidScrollErrorShape(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+
+	rec := postSimulate(h, `{"id":"s1","trust_score":1.5}`, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	e := decodeErrorResponse(t, rec)
+	if e.Code != CodeInvalidScroll || e.Field != "trust_score" || e.Message == "" {
+		t.Fatalf("unexpected error body: %+v", e)
+	}
+}
+
+func TestSimulate_BadInputErrorShape(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+
+	rec := postSimulate(h, `{"trust_score":"high"}`, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	e := decodeErrorResponse(t, rec)
+	if e.Code != CodeInvalidInput || e.Field != "trust_score" {
+		t.Fatalf("unexpected error body: %+v", e)
+	}
+}
+
+func TestSimulate_MethodNotAllowedErrorShape(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(DefaultConfig()).Handler().ServeHTTP(rec, newTenantRequest(http.MethodGet, "/simulate", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d", rec.Code)
+	}
+	if e := decodeErrorResponse(t, rec); e.Code != CodeMethodNotAllowed {
+		t.Fatalf("unexpected error body: %+v", e)
+	}
+}
+
+func TestSimulate_BodyDecodeErrors(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	for _, tc := range []struct {
+		name, body, code string
+	}{
+		{"empty", "", CodeEmptyBody},
+		{"whitespace", "  \n", CodeEmptyBody},
+		{"malformed", `{"id":"s1",`, CodeMalformedJSON},
+		{"bad syntax", `{"id" "s1"}`, CodeMalformedJSON},
+		{"trailing junk", `{"id":"s1","trust_score":0.5} garbage`, CodeMalformedJSON},
+		{"second object", `{"id":"s1","trust_score":0.5}{"id":"s2"}`, CodeMalformedJSON},
+		{"stray brace", `{"id":"s1","trust_score":0.5}}`, CodeMalformedJSON},
+	} {
+		rec := postSimulate(h, tc.body, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", tc.name, rec.Code)
+		}
+		if e := decodeErrorResponse(t, rec); e.Code != tc.code {
+			t.Fatalf("%s: expected code %s, got %+v", tc.name, tc.code, e)
+		}
+	}
+
+	if rec := postSimulate(h, `{"id":"s1","trust_score":0.5}`+"\n", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a valid body with trailing newline, got %d", rec.Code)
+	}
+}
+
+func TestSimulate_RejectsNonFiniteTrust(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	for _, tc := range []struct {
+		name, body, code string
+	}{
+		{"overflow", `{"id":"s1","trust_score":1e400}`, CodeInvalidInput},
+		{"negative overflow", `{"id":"s1","trust_score":-1e400}`, CodeInvalidInput},
+		{"above range", `{"id":"s1","trust_score":1.0001}`, CodeInvalidScroll},
+		{"below range", `{"id":"s1","trust_score":-0.1}`, CodeInvalidScroll},
+	} {
+		rec := postSimulate(h, tc.body, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", tc.name, rec.Code)
+		}
+		if e := decodeErrorResponse(t, rec); e.Code != tc.code || e.Field != "trust_score" {
+			t.Fatalf("%s: expected %s on trust_score, got %+v", tc.name, tc.code, e)
+		}
+	}
+}
+
+func TestValidate_RejectsNonFiniteScores(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		err   error
+		field string
+	}{
+		{"NaN trust", types.Scroll{TrustScore: math.NaN()}.Validate(), "trust_score"},
+		{"+Inf trust", types.Scroll{TrustScore: math.Inf(1)}.Validate(), "trust_score"},
+		{"NaN relief", types.GeneInterventionPlan{PredictedRelief: math.NaN()}.Validate(), "predicted_relief"},
+		{"-Inf suppression", types.GeneInterventionPlan{FlareSuppression: math.Inf(-1)}.Validate(), "flare_suppression"},
+		{"relief out of range", types.GeneInterventionPlan{PredictedRelief: 1.2}.Validate(), "predicted_relief"},
+	} {
+		var vErr *types.ValidationError
+		if !errors.As(tc.err, &vErr) || vErr.Field != tc.field {
+			t.Errorf("%s: expected a ValidationError on %s, got %v", tc.name, tc.field, tc.err)
+		}
+	}
+}
+
+// nanStrategy scores NaN, as a broken model might.
+type nanStrategy struct{}
+
+func (nanStrategy) Score(context.Context, types.Scroll, []string) (Score, error) {
+	return Score{PredictedRelief: math.NaN(), FlareSuppression: 0.5}, nil
+}
+
+func TestSimulate_RejectsNonFiniteScore(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Scoring = nanStrategy{}
+	rec := postSimulate(NewServer(cfg).Handler(), `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`, "")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for a strategy scoring NaN, got %d: %s", rec.Code, rec.Body)
+	}
+}
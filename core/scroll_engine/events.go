@@ -0,0 +1,140 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// eventBuffer is how many events a subscriber may fall behind by before
+// further events are dropped for it.
+const eventBuffer = 16
+
+// FlareEvent is streamed to a tenant's /events/flares subscribers whenever a
+// flare simulation for that tenant produces a plan.
+type FlareEvent struct {
+	Tenant   string                     `json:"tenant"`
+	ScrollID string                     `json:"scroll_id"`
+	At       time.Time                  `json:"at"`
+	Plan     types.GeneInterventionPlan `json:"plan"`
+	// Reborn marks a plan from a scroll the rebirth worker restored from
+	// compost.
+	Reborn bool `json:"reborn,omitempty"`
+}
+
+// eventHub fans encoded events out to every subscriber of the tenant they
+// belong to. Publishing never blocks: a subscriber whose buffer is full
+// misses the event.
+type eventHub struct {
+	mu      sync.Mutex
+	subs    map[chan []byte]string
+	dropped func()
+}
+
+func newEventHub(dropped func()) *eventHub {
+	return &eventHub{subs: make(map[chan []byte]string), dropped: dropped}
+}
+
+func (h *eventHub) subscribe(tenant string) chan []byte {
+	ch := make(chan []byte, eventBuffer)
+	h.mu.Lock()
+	h.subs[ch] = tenant
+	h.mu.Unlock()
+	return ch
+}
+
+func (h *eventHub) unsubscribe(ch chan []byte) {
+	h.mu.Lock()
+	delete(h.subs, ch)
+	h.mu.Unlock()
+}
+
+func (h *eventHub) publish(tenant string, event []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch, t := range h.subs {
+		if t != tenant {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+			h.dropped()
+		}
+	}
+}
+
+func (h *eventHub) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// publishFlare broadcasts plan to tenant's subscribers if it came from the
+// flare branch.
+func (s *Server) publishFlare(tenant string, scroll types.Scroll, plan types.GeneInterventionPlan) {
+	if plan.Branch != BranchFlare {
+		return
+	}
+	s.publishFlareEvent(FlareEvent{Tenant: tenant, ScrollID: scroll.ID, Plan: plan})
+}
+
+// publishFlareEvent stamps e with the current time and broadcasts it to
+// e.Tenant's subscribers.
+func (s *Server) publishFlareEvent(e FlareEvent) {
+	e.At = time.Now().UTC()
+	event, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	s.flares.publish(e.Tenant, event)
+}
+
+// flareEventsHandler streams the tenant's flare plans as Server-Sent Events,
+// with a keepalive comment whenever the stream has been idle for
+// cfg.EventKeepalive.
+func (s *Server) flareEventsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	rc := http.NewResponseController(w)
+	ch := s.flares.subscribe(tenant)
+	defer s.flares.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	interval := time.Duration(s.config().EventKeepalive)
+	if interval <= 0 {
+		interval = time.Duration(DefaultConfig().EventKeepalive)
+	}
+	keepalive := time.NewTicker(interval)
+	defer keepalive.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			_, err = fmt.Fprintf(w, "event: flare\ndata: %s\n\n", event)
+			keepalive.Reset(interval)
+		case <-keepalive.C:
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
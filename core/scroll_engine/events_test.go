@@ -0,0 +1,121 @@
+package scroll_engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readSSELine returns the next non-empty line of an event stream.
+func readSSELine(t *testing.T, lines chan string) string {
+	t.Helper()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("event stream closed")
+			}
+			if line != "" {
+				return line
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event stream")
+		}
+	}
+}
+
+// openFlareStream subscribes to testTenant's flare stream on ts. Register
+// ts.Close with t.Cleanup first so the stream is closed before the server.
+func openFlareStream(t *testing.T, ts *httptest.Server) chan string {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, ts.URL+"/events/flares", nil)
+	req.Header.Set(TenantHeader, testTenant)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(resp.Body)
+		for sc.Scan() {
+			select {
+			case lines <- sc.Text():
+			case <-t.Context().Done():
+				return
+			}
+		}
+	}()
+	return lines
+}
+
+func TestFlareEvents_StreamsFlarePlans(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	lines := openFlareStream(t, ts)
+
+	post := func(tenant, body string) {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/simulate", strings.NewReader(body))
+		req.Header.Set(TenantHeader, tenant)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("simulate: %v", err)
+		}
+		resp.Body.Close()
+	}
+	post(testTenant, `{"id":"calm","trust_score":0.2}`)
+	post("other", `{"id":"elsewhere","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`)
+	post(testTenant, `{"id":"f1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`)
+
+	if line := readSSELine(t, lines); line != "event: flare" {
+		t.Fatalf("expected flare event, got %q", line)
+	}
+	data, ok := strings.CutPrefix(readSSELine(t, lines), "data: ")
+	if !ok {
+		t.Fatalf("expected data line")
+	}
+	var event FlareEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if event.ScrollID != "f1" || event.Tenant != testTenant || event.Plan.Branch != BranchFlare {
+		t.Fatalf("expected only this tenant's flare plan to stream, got %+v", event)
+	}
+}
+
+func TestFlareEvents_Keepalive(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EventKeepalive = Duration(10 * time.Millisecond)
+	ts := httptest.NewServer(NewServer(cfg).Handler())
+	t.Cleanup(ts.Close)
+
+	if line := readSSELine(t, openFlareStream(t, ts)); line != ": keepalive" {
+		t.Fatalf("expected keepalive comment, got %q", line)
+	}
+}
+
+func TestEventHub_DropsForSlowSubscriber(t *testing.T) {
+	dropped := 0
+	hub := newEventHub(func() { dropped++ })
+	ch := hub.subscribe("a")
+	for range eventBuffer + 3 {
+		hub.publish("a", []byte("{}"))
+	}
+	hub.publish("b", []byte("{}"))
+	if len(ch) != eventBuffer || dropped != 3 {
+		t.Fatalf("expected %d buffered and 3 dropped, got %d and %d", eventBuffer, len(ch), dropped)
+	}
+	hub.unsubscribe(ch)
+	if hub.len() != 0 {
+		t.Fatalf("expected subscriber removed")
+	}
+}
@@ -0,0 +1,186 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// Explanation reason kinds.
+const (
+	ExplainTrustThreshold  = "trust_threshold"
+	ExplainFlareEvent      = "flare_event"
+	ExplainMarkers         = "markers"
+	ExplainRebirth         = "rebirth_eligibility"
+	ExplainScoring         = "scoring"
+	ExplainWeightOverrides = "weight_overrides"
+	ExplainFlarePanel      = "flare_panel"
+	ExplainBudget          = "budget"
+)
+
+func explainTrust(prov types.TrustProvenance, threshold float64, aligned bool) types.ExplanationReason {
+	verdict := "below"
+	if aligned {
+		verdict = "meets"
+	}
+	trust := prov.Effective
+	return types.ExplanationReason{
+		Kind:       ExplainTrustThreshold,
+		Passed:     aligned,
+		Message:    fmt.Sprintf("trust %.2f %s threshold %.2f", trust, verdict, threshold),
+		Threshold:  &threshold,
+		Value:      &trust,
+		Provenance: &prov,
+	}
+}
+
+func explainFlareEvent(flare bool) types.ExplanationReason {
+	msg := "scroll is not a flare event"
+	if flare {
+		msg = "scroll is a flare event"
+	}
+	return types.ExplanationReason{Kind: ExplainFlareEvent, Passed: flare, Message: msg}
+}
+
+// explainFlarePanel records that too few of a flare's markers, matched of
+// the required, were on the panel.
+func explainFlarePanel(panel []string, matched, required int) types.ExplanationReason {
+	msg := fmt.Sprintf("no markers on the flare panel %v; holding the scroll in memory", panel)
+	if matched > 0 {
+		msg = fmt.Sprintf("%d of %d required markers on the flare panel %v; holding the scroll in memory", matched, required, panel)
+	}
+	threshold, value := float64(required), float64(matched)
+	return types.ExplanationReason{
+		Kind:      ExplainFlarePanel,
+		Message:   msg,
+		Threshold: &threshold,
+		Value:     &value,
+	}
+}
+
+// explainMarkers lists the scroll's markers, with their weights when the
+// scoring strategy attributed them.
+func explainMarkers(markers []string, contributions []types.MarkerContribution) types.ExplanationReason {
+	if len(markers) == 0 {
+		return types.ExplanationReason{Kind: ExplainMarkers, Message: "scroll carries no genetic markers"}
+	}
+	if contributions == nil {
+		contributions = make([]types.MarkerContribution, len(markers))
+		for i, m := range markers {
+			contributions[i] = types.MarkerContribution{Gene: m}
+		}
+	}
+	return types.ExplanationReason{
+		Kind:    ExplainMarkers,
+		Passed:  true,
+		Message: fmt.Sprintf("%d genetic markers matched", len(markers)),
+		Markers: contributions,
+	}
+}
+
+// explainRebirth states why the scroll was or wasn't rebirth-eligible: only
+// a trust-aligned flare carrying markers qualifies.
+func explainRebirth(aligned, flare, hasMarkers bool) types.ExplanationReason {
+	r := types.ExplanationReason{Kind: ExplainRebirth}
+	switch {
+	case !aligned:
+		r.Message = "not rebirth-eligible: trust below threshold"
+	case !flare:
+		r.Message = "not rebirth-eligible: scroll is not a flare event"
+	case !hasMarkers:
+		r.Message = "not rebirth-eligible: no genetic markers to target"
+	default:
+		r.Passed = true
+		r.Message = "rebirth-eligible: trust-aligned flare with targetable markers"
+	}
+	return r
+}
+
+// explainScoringFallback records that the scoring strategy substituted
+// another, or returns nil when it scored as intended.
+func explainScoringFallback(score Score) []types.ExplanationReason {
+	if score.Fallback == "" {
+		return nil
+	}
+	return []types.ExplanationReason{{Kind: ExplainScoring, Message: score.Fallback}}
+}
+
+// explainWeightOverrides lists the marker weights a request overrode, sorted
+// by marker, or returns nil when none were.
+func explainWeightOverrides(overrides map[string]MarkerWeight, reg *MarkerRegistry) []types.ExplanationReason {
+	if len(overrides) == 0 {
+		return nil
+	}
+	markers := make([]types.MarkerContribution, 0, len(overrides))
+	for m, w := range overrides {
+		markers = append(markers, types.MarkerContribution{Gene: reg.Canonicalize(m), Relief: w.Relief, Suppression: w.Suppression})
+	}
+	sort.Slice(markers, func(i, j int) bool { return markers[i].Gene < markers[j].Gene })
+	return []types.ExplanationReason{{
+		Kind:    ExplainWeightOverrides,
+		Passed:  true,
+		Message: fmt.Sprintf("%d marker weights overridden for this request", len(markers)),
+		Markers: markers,
+	}}
+}
+
+// skipScoring stands in for a scoring strategy when only the decision is
+// wanted; every intervention scores zero.
+type skipScoring struct{}
+
+func (skipScoring) Score(context.Context, types.Scroll, []string) (Score, error) {
+	return Score{}, nil
+}
+
+// noLoopIDs leaves plans without a loop ID, so explaining a scroll does not
+// use one up.
+type noLoopIDs struct{}
+
+func (noLoopIDs) NextLoopID(string) string { return "" }
+
+// explain runs simulate's decision logic without scoring, so its plan's
+// branch and explanation match simulate's but its scores and loop ID are
+// zero.
+func (c *compiledConfig) explain(ctx context.Context, scroll types.Scroll) (types.GeneInterventionPlan, error) {
+	out := *c
+	out.scoring = skipScoring{}
+	out.cfg.LoopIDs = noLoopIDs{}
+	return out.simulate(ctx, scroll)
+}
+
+// ExplainResponse is the decision trace POST /simulate/explain returns.
+type ExplainResponse struct {
+	Branch      string                    `json:"branch"`
+	Explanation []types.ExplanationReason `json:"explanation"`
+}
+
+// explainHandler decides a scroll as /simulate would, weight overrides
+// included, without scoring, persisting, or starting a loop, and returns
+// only how it decided.
+func (s *Server) explainHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	req, ok := s.decodeSimulateRequest(w, raw, tenant)
+	if !ok {
+		return
+	}
+	plan, err := s.active.Load().engineFor(req.WeightOverrides).explain(r.Context(), req.Scroll)
+	if err != nil {
+		writeSimulationError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ExplainResponse{Branch: plan.Branch, Explanation: plan.Explanation})
+}
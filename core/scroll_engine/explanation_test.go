@@ -0,0 +1,173 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func findReason(plan types.GeneInterventionPlan, kind string) (types.ExplanationReason, bool) {
+	for _, r := range plan.Explanation {
+		if r.Kind == kind {
+			return r, true
+		}
+	}
+	return types.ExplanationReason{}, false
+}
+
+func TestExplanation_FlareListsMatchedMarkers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MarkerWeights = map[string]MarkerWeight{"NOD2": {Relief: 0.6, Suppression: 0.7}}
+	plan := mustSimulate(t, types.Scroll{
+		ID: "f", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2", "IL23R"},
+	}, cfg)
+
+	markers, ok := findReason(plan, ExplainMarkers)
+	if !ok {
+		t.Fatalf("expected a markers reason, got %+v", plan.Explanation)
+	}
+	want := []types.MarkerContribution{
+		{Gene: "IL23R", Relief: 0.87, Suppression: 0.91},
+		{Gene: "NOD2", Relief: 0.6, Suppression: 0.7},
+	}
+	if len(markers.Markers) != len(want) {
+		t.Fatalf("expected %d marker contributions, got %+v", len(want), markers.Markers)
+	}
+	for i, w := range want {
+		if markers.Markers[i] != w {
+			t.Fatalf("marker %d: expected %+v, got %+v", i, w, markers.Markers[i])
+		}
+	}
+
+	trust, _ := findReason(plan, ExplainTrustThreshold)
+	if !trust.Passed || *trust.Threshold != 0.7 {
+		t.Fatalf("expected passed trust reason at 0.7, got %+v", trust)
+	}
+	if rebirth, _ := findReason(plan, ExplainRebirth); !rebirth.Passed {
+		t.Fatalf("expected rebirth-eligible reason, got %+v", rebirth)
+	}
+}
+
+func TestExplanation_CompostSaysWhyNotRebirthEligible(t *testing.T) {
+	plan := mustSimulate(t, types.Scroll{ID: "m", TrustScore: 0.9, GeneticMarkers: []string{"NOD2"}}, DefaultConfig())
+
+	rebirth, _ := findReason(plan, ExplainRebirth)
+	if rebirth.Passed || !strings.Contains(rebirth.Message, "not a flare event") {
+		t.Fatalf("expected non-flare rebirth rejection, got %+v", rebirth)
+	}
+}
+
+func TestExplanation_OmittedWhenEmpty(t *testing.T) {
+	raw, _ := json.Marshal(types.GeneInterventionPlan{})
+	if strings.Contains(string(raw), "explanation") {
+		t.Fatalf("expected explanation to be omitted for legacy clients, got %s", raw)
+	}
+}
+
+// slowStrategy scores like a WeightedStrategy after a fixed delay, as an
+// expensive model would.
+type slowStrategy struct{ delay time.Duration }
+
+func (s slowStrategy) Score(ctx context.Context, scroll types.Scroll, targets []string) (Score, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return Score{}, ctx.Err()
+	}
+	return WeightedStrategy{Default: MarkerWeight{Relief: 0.5, Suppression: 0.5}}.Score(ctx, scroll, targets)
+}
+
+func TestExplain_SkipsScoringAndMatchesSimulate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Scoring = slowStrategy{delay: 50 * time.Millisecond}
+	h := NewServer(cfg).Handler()
+
+	for _, body := range []string{
+		`{"id":"f","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`,
+		`{"id":"c","trust_score":0.9,"is_flare_event":false,"genetic_markers":["NOD2"]}`,
+		`{"id":"d","trust_score":0.1}`,
+	} {
+		start := time.Now()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newTenantRequest(http.MethodPost, "/simulate/explain", strings.NewReader(body)))
+		explainTook := time.Since(start)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("explain: expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+		var got ExplainResponse
+		if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+
+		start = time.Now()
+		plan := decodePlan(t, postSimulate(h, body, ""))
+		simulateTook := time.Since(start)
+
+		if got.Branch != plan.Branch || len(got.Explanation) == 0 {
+			t.Fatalf("%s: expected branch %s with an explanation, got %+v", body, plan.Branch, got)
+		}
+		if plan.Branch == BranchFlare && explainTook >= simulateTook {
+			t.Fatalf("expected explain (%s) to skip the scoring simulate (%s) waits for", explainTook, simulateTook)
+		}
+	}
+}
+
+func TestExplain_AppliesOverridesAndPrecedenceLikeSimulate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FlarePrecedence = FlareTrigger
+	h := NewServer(cfg).Handler()
+	body := `{"id":"f","trust_score":0.9,"is_flare_event":false,"trigger":"flare","genetic_markers":["NOD2"],` +
+		`"weight_overrides":{"NOD2":{"relief":0.1,"suppression":0.2}}}`
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodPost, "/simulate/explain", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("explain: expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var got ExplainResponse
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	plan := decodePlan(t, postSimulate(h, body, ""))
+
+	if got.Branch != BranchFlare || got.Branch != plan.Branch {
+		t.Fatalf("expected explain and simulate both to take the flare branch, got %s and %s", got.Branch, plan.Branch)
+	}
+	// Explain leaves marker contributions unscored; every decision matches.
+	decisions := func(reasons []types.ExplanationReason) []string {
+		out := make([]string, len(reasons))
+		for i, r := range reasons {
+			out[i] = fmt.Sprintf("%s %t %s", r.Kind, r.Passed, r.Message)
+		}
+		return out
+	}
+	if want, got := decisions(plan.Explanation), decisions(got.Explanation); !slices.Equal(got, want) {
+		t.Fatalf("expected explain's trace to match simulate's:\n got %q\nwant %q", got, want)
+	}
+	gotOverrides, _ := findReason(types.GeneInterventionPlan{Explanation: got.Explanation}, ExplainWeightOverrides)
+	wantOverrides, ok := findReason(plan, ExplainWeightOverrides)
+	if !ok || !reflect.DeepEqual(gotOverrides, wantOverrides) {
+		t.Fatalf("expected explain to report simulate's weight overrides %+v, got %+v", wantOverrides, gotOverrides)
+	}
+}
+
+func TestExplain_RejectsInvalidScroll(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewServer(DefaultConfig()).Handler().ServeHTTP(rec,
+		newTenantRequest(http.MethodPost, "/simulate/explain", strings.NewReader(`{"id":"s","trust_score":2}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if e := decodeErrorResponse(t, rec); e.Field != "trust_score" {
+		t.Fatalf("unexpected error: %+v", e)
+	}
+}
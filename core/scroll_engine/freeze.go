@@ -0,0 +1,52 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// persistError classifies a failure to store a scroll or its plan: a 409 if
+// the scroll is frozen, a 500 otherwise.
+func persistError(err error) *requestError {
+	if errors.Is(err, ErrFrozen) {
+		return newRequestError(http.StatusConflict, CodeScrollFrozen, "scroll is frozen; unfreeze it to change it", "id")
+	}
+	return newRequestError(http.StatusInternalServerError, CodeInternal, "store plan: "+err.Error(), "")
+}
+
+// freezeHandler freezes a stored scroll, making it and its plan immutable:
+// once a decision has been acted on, the record of it must not change.
+func (s *Server) freezeHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	s.setFrozen(w, tenant, r.PathValue("id"), true)
+}
+
+// unfreezeHandler lifts a freeze. Since a frozen scroll records a decision
+// already acted on, only an admin may.
+func (s *Server) unfreezeHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok || !s.authorizeAdmin(w, r) {
+		return
+	}
+	s.setFrozen(w, tenant, r.PathValue("id"), false)
+}
+
+// setFrozen applies a freeze or unfreeze and writes the updated scroll.
+func (s *Server) setFrozen(w http.ResponseWriter, tenant, id string, frozen bool) {
+	scroll, err := s.store.SetFrozen(tenant, id, frozen)
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "scroll not found", "id")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(scroll)
+}
@@ -0,0 +1,171 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func mustHash(plan types.GeneInterventionPlan) string {
+	hash, _ := PlanContentHash(plan)
+	return hash
+}
+
+func postScroll(h http.Handler, path, token, body string) *httptest.ResponseRecorder {
+	req := newTenantRequest(http.MethodPost, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// mutateFrozen runs every operation that changes a stored scroll or its plan
+// against scroll f, returning each one's status.
+func mutateFrozen(t *testing.T, h http.Handler) map[string]int {
+	t.Helper()
+	codes := map[string]int{
+		"patch":      patchScroll(h, "f", `{"trust_score":0.95}`).Code,
+		"resimulate": postSimulate(h, budgetFlareBody, "").Code,
+		"add tag":    postScroll(h, "/scrolls/f/tags", "", `{"tags":["acted"]}`).Code,
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodDelete, "/scrolls/f/tags/acted", nil))
+	codes["remove tag"] = rec.Code
+
+	rec = postScroll(h, "/scrolls/compost?confirm=true", "", `{"max_trust":1}`)
+	var res BulkCompostResult
+	_ = json.NewDecoder(rec.Body).Decode(&res)
+	codes["compost"] = http.StatusOK
+	if len(res.Failed) == 1 && strings.Contains(res.Failed[0].Error, "frozen") {
+		codes["compost"] = http.StatusConflict
+	}
+	return codes
+}
+
+func TestFreeze_BlocksMutationUntilUnfrozen(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = testAdminToken
+	srv := NewServer(cfg)
+	h := srv.Handler()
+	if rec := postSimulate(h, budgetFlareBody, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rec := postScroll(h, "/scrolls/f/freeze", "", "")
+	var frozen types.Scroll
+	_ = json.NewDecoder(rec.Body).Decode(&frozen)
+	if rec.Code != http.StatusOK || !frozen.Frozen {
+		t.Fatalf("expected the frozen scroll back, got %d %+v", rec.Code, frozen)
+	}
+	plan, _ := srv.store.GetPlan(testTenant, "f")
+
+	for op, code := range mutateFrozen(t, h) {
+		if code != http.StatusConflict {
+			t.Errorf("%s: expected 409 while frozen, got %d", op, code)
+		}
+	}
+	rec = patchScroll(h, "f", `{"trust_score":0.95}`)
+	if code := decodeErrorResponse(t, rec).Code; code != CodeScrollFrozen {
+		t.Fatalf("expected code %s, got %s", CodeScrollFrozen, code)
+	}
+	stored, err := srv.store.GetScroll(testTenant, "f")
+	if err != nil || stored.Version != 1 || len(stored.Tags) != 0 {
+		t.Fatalf("expected the frozen scroll unchanged, got %+v (%v)", stored, err)
+	}
+	if after, _ := srv.store.GetPlan(testTenant, "f"); mustHash(after) != mustHash(plan) {
+		t.Fatalf("expected the frozen plan unchanged")
+	}
+
+	if rec := postScroll(h, "/scrolls/f/unfreeze", testAdminToken, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected unfreeze to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+	for op, code := range mutateFrozen(t, h) {
+		if code != http.StatusOK {
+			t.Errorf("%s: expected 200 after unfreeze, got %d", op, code)
+		}
+	}
+}
+
+func TestUnfreeze_RequiresAdminToken(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = testAdminToken
+	srv := NewServer(cfg)
+	h := srv.Handler()
+	_ = srv.store.SaveScroll(testTenant, types.Scroll{ID: "f", TrustScore: 0.9})
+	postScroll(h, "/scrolls/f/freeze", "", "")
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := postScroll(h, "/scrolls/f/unfreeze", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected 401, got %d", token, rec.Code)
+		}
+	}
+	if stored, _ := srv.store.GetScroll(testTenant, "f"); !stored.Frozen {
+		t.Fatalf("expected the scroll to stay frozen")
+	}
+	if rec := postScroll(h, "/scrolls/missing/freeze", "", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 freezing an unknown scroll, got %d", rec.Code)
+	}
+}
+
+func TestSimulate_IgnoresSubmittedFrozen(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	body := `{"id":"f","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"],"frozen":true}`
+	if rec := postSimulate(srv.Handler(), body, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	if stored, _ := srv.store.GetScroll(testTenant, "f"); stored.Frozen {
+		t.Fatalf("expected a client unable to freeze a scroll by submitting it")
+	}
+}
+
+func TestStores_EnforceFreeze(t *testing.T) {
+	for name, store := range map[string]ScrollStore{"memory": NewMemoryStore(), "sqlite": openTestSQLite(t)} {
+		t.Run(name, func(t *testing.T) {
+			_ = store.SaveScroll(testTenant, types.Scroll{ID: "s1", TrustScore: 0.9})
+			_ = store.SavePlan(testTenant, "s1", types.GeneInterventionPlan{Branch: BranchFlare})
+			if _, err := store.SetFrozen(testTenant, "s1", true); err != nil {
+				t.Fatalf("freeze: %v", err)
+			}
+			if _, err := store.SetFrozen(testTenant, "missing", true); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expected ErrNotFound freezing an unknown scroll, got %v", err)
+			}
+
+			writes := map[string]func() error{
+				"save scroll": func() error { return store.SaveScroll(testTenant, types.Scroll{ID: "s1", TrustScore: 0.1}) },
+				"save plan":   func() error { return store.SavePlan(testTenant, "s1", types.GeneInterventionPlan{}) },
+				"tags": func() error {
+					_, err := store.UpdateTags(testTenant, "s1", []string{"x"}, nil)
+					return err
+				},
+				"compost": func() error { return store.CompostScroll(testTenant, "s1", ReasonManual, day(1)) },
+			}
+			for op, write := range writes {
+				if err := write(); !errors.Is(err, ErrFrozen) {
+					t.Errorf("%s: expected ErrFrozen, got %v", op, err)
+				}
+			}
+			if got, _ := store.GetScroll(testTenant, "s1"); got.TrustScore != 0.9 || !got.Frozen {
+				t.Fatalf("expected the frozen scroll unchanged, got %+v", got)
+			}
+			if plan, _ := store.GetPlan(testTenant, "s1"); plan.Branch != BranchFlare {
+				t.Fatalf("expected the frozen plan unchanged, got %+v", plan)
+			}
+
+			if _, err := store.SetFrozen(testTenant, "s1", false); err != nil {
+				t.Fatalf("unfreeze: %v", err)
+			}
+			for op, write := range writes {
+				if err := write(); err != nil {
+					t.Errorf("%s: expected success after unfreeze, got %v", op, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package scroll_engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// RetryPolicy bounds how an HTTP-backed call is retried. Attempt n waits
+// BaseDelay*2^(n-1), capped at MaxDelay, reduced by up to Jitter (a fraction
+// in [0,1]) at random so concurrent callers spread out.
+type RetryPolicy struct {
+	MaxAttempts int      `json:"max_attempts"`
+	BaseDelay   Duration `json:"base_delay"`
+	MaxDelay    Duration `json:"max_delay"`
+	Jitter      float64  `json:"jitter"`
+}
+
+// DefaultRetryPolicy is used for external scoring unless configured.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   Duration(100 * time.Millisecond),
+		MaxDelay:    Duration(2 * time.Second),
+		Jitter:      0.2,
+	}
+}
+
+// delay returns the wait before retry attempt (1-based) attempt+1.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := time.Duration(p.BaseDelay) << (attempt - 1)
+	if d > time.Duration(p.MaxDelay) || d <= 0 {
+		d = time.Duration(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d -= time.Duration(float64(d) * p.Jitter * rand.Float64())
+	}
+	return d
+}
+
+// errPermanent marks a failure that retrying cannot fix.
+var errPermanent = errors.New("permanent scoring failure")
+
+// scoringRequest is the body posted to an external scoring service.
+type scoringRequest struct {
+	Scroll  types.Scroll `json:"scroll"`
+	Targets []string     `json:"targets"`
+}
+
+// scoringResponse is the body an external scoring service replies with.
+type scoringResponse struct {
+	PredictedRelief  float64                    `json:"predicted_relief"`
+	FlareSuppression float64                    `json:"flare_suppression"`
+	Contributions    []types.MarkerContribution `json:"contributions,omitempty"`
+}
+
+// HTTPScoringStrategy scores by POSTing to an external model service at
+// URL. Transient failures (network errors and 5xx) are retried under Retry;
+// once retries are exhausted, or on a 4xx, it scores with Fallback and notes
+// that in Score.Fallback. Cancellation of ctx is returned, not fallen back
+// from.
+//
+// With a Breaker, each Score that ends up falling back counts as one
+// failure, and while the circuit is open Score skips the service and goes
+// straight to Fallback.
+type HTTPScoringStrategy struct {
+	URL      string
+	Client   *http.Client
+	Retry    RetryPolicy
+	Fallback ScoringStrategy
+	Breaker  *CircuitBreaker
+}
+
+func (h HTTPScoringStrategy) Score(ctx context.Context, scroll types.Scroll, targets []string) (Score, error) {
+	if h.Breaker == nil {
+		return h.score(ctx, scroll, targets)
+	}
+	if !h.Breaker.Allow() {
+		return h.fallback(ctx, scroll, targets, errCircuitOpen)
+	}
+	score, err := h.score(ctx, scroll, targets)
+	switch {
+	case err != nil:
+		h.Breaker.Abandon()
+	case score.Fallback != "":
+		h.Breaker.Failure()
+	default:
+		h.Breaker.Success()
+	}
+	return score, err
+}
+
+// errCircuitOpen is why Score fell back without calling the service.
+var errCircuitOpen = errors.New("circuit open")
+
+// score is Score without the breaker.
+func (h HTTPScoringStrategy) score(ctx context.Context, scroll types.Scroll, targets []string) (Score, error) {
+	attempts := max(h.Retry.MaxAttempts, 1)
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		score, err := h.call(ctx, scroll, targets)
+		if err == nil {
+			return score, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Score{}, ctxErr
+		}
+		lastErr = err
+		if errors.Is(err, errPermanent) || attempt == attempts {
+			break
+		}
+
+		timer := time.NewTimer(h.Retry.delay(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Score{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	return h.fallback(ctx, scroll, targets, lastErr)
+}
+
+// fallback scores with Fallback because external scoring failed with cause.
+func (h HTTPScoringStrategy) fallback(ctx context.Context, scroll types.Scroll, targets []string, cause error) (Score, error) {
+	score, err := h.Fallback.Score(ctx, scroll, targets)
+	if err != nil {
+		return Score{}, err
+	}
+	score.Fallback = fmt.Sprintf("external scoring failed (%v); used local weighted strategy", cause)
+	return score, nil
+}
+
+func (h HTTPScoringStrategy) call(ctx context.Context, scroll types.Scroll, targets []string) (Score, error) {
+	body, err := json.Marshal(scoringRequest{Scroll: scroll, Targets: targets})
+	if err != nil {
+		return Score{}, fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return Score{}, fmt.Errorf("%w: %v", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Score{}, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return Score{}, fmt.Errorf("scoring service returned %d", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return Score{}, fmt.Errorf("%w: scoring service returned %d", errPermanent, resp.StatusCode)
+	}
+
+	var out scoringResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return Score{}, fmt.Errorf("decode scoring response: %w", err)
+	}
+	score := Score{
+		PredictedRelief:  out.PredictedRelief,
+		FlareSuppression: out.FlareSuppression,
+		Contributions:    out.Contributions,
+	}
+	if err := score.validate(); err != nil {
+		return Score{}, fmt.Errorf("%w: scoring service returned %v", errPermanent, err)
+	}
+	return score, nil
+}
@@ -0,0 +1,94 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+var fastRetry = RetryPolicy{MaxAttempts: 3, BaseDelay: Duration(time.Millisecond), MaxDelay: Duration(5 * time.Millisecond)}
+
+// flakyScoringServer fails its first failures calls with 503, then scores.
+func flakyScoringServer(t *testing.T, failures int32) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(scoringResponse{PredictedRelief: 0.42, FlareSuppression: 0.55})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestHTTPScoringStrategy_RetriesThenSucceeds(t *testing.T) {
+	srv, calls := flakyScoringServer(t, 2)
+	strategy := HTTPScoringStrategy{URL: srv.URL, Retry: fastRetry, Fallback: WeightedStrategy{}}
+
+	score, err := strategy.Score(context.Background(), flareScroll, flareScroll.GeneticMarkers)
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+	if score.PredictedRelief != 0.42 || score.Fallback != "" {
+		t.Fatalf("expected remote score without fallback, got %+v", score)
+	}
+}
+
+func TestHTTPScoringStrategy_FallsBackAfterExhaustingRetries(t *testing.T) {
+	srv, calls := flakyScoringServer(t, 1<<30)
+	cfg := DefaultConfig()
+	cfg.ScoringURL = srv.URL
+	cfg.ScoringRetry = fastRetry
+
+	plan := mustSimulate(t, flareScroll, cfg)
+
+	if calls.Load() != int32(fastRetry.MaxAttempts) {
+		t.Fatalf("expected %d attempts, got %d", fastRetry.MaxAttempts, calls.Load())
+	}
+	if plan.PredictedRelief != cfg.DefaultMarkerWeight.Relief {
+		t.Fatalf("expected local weighted relief %v, got %v", cfg.DefaultMarkerWeight.Relief, plan.PredictedRelief)
+	}
+	if r, ok := findReason(plan, ExplainScoring); !ok || r.Message == "" {
+		t.Fatalf("expected explanation to record the fallback, got %+v", plan.Explanation)
+	}
+}
+
+func TestHTTPScoringStrategy_CancelledDuringBackoff(t *testing.T) {
+	srv, _ := flakyScoringServer(t, 1<<30)
+	slow := RetryPolicy{MaxAttempts: 5, BaseDelay: Duration(time.Hour), MaxDelay: Duration(time.Hour)}
+	strategy := HTTPScoringStrategy{URL: srv.URL, Retry: slow, Fallback: WeightedStrategy{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := strategy.Score(ctx, types.Scroll{}, nil)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline to end the backoff, got %v", err)
+	}
+}
+
+func TestHTTPScoringStrategy_FallsBackOnOutOfRangeScore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"predicted_relief":7,"flare_suppression":0.5}`))
+	}))
+	t.Cleanup(srv.Close)
+	strategy := HTTPScoringStrategy{URL: srv.URL, Retry: fastRetry, Fallback: WeightedStrategy{Default: MarkerWeight{Relief: 0.3}}}
+
+	score, err := strategy.Score(context.Background(), flareScroll, flareScroll.GeneticMarkers)
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if score.PredictedRelief != 0.3 || score.Fallback == "" {
+		t.Fatalf("expected an out-of-range remote score to fall back, got %+v", score)
+	}
+}
@@ -0,0 +1,132 @@
+package scroll_engine
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// idempotencyEntry is the response recorded for an Idempotency-Key along
+// with a digest of the request body that produced it.
+type idempotencyEntry struct {
+	bodyHash [sha256.Size]byte
+	status   int
+	body     []byte
+	expires  time.Time
+}
+
+// idempotencyCache remembers responses by Idempotency-Key for a TTL so that
+// retried requests replay the original plan instead of re-simulating. A key
+// is held in flight from claim until store or release, so a retry racing
+// the original is turned away rather than simulated twice. Expired entries
+// are swept by store, at most once per TTL.
+type idempotencyCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	now       func() time.Time
+	entries   map[string]idempotencyEntry
+	inFlight  map[string]bool
+	nextSweep time.Time
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[string]idempotencyEntry),
+		inFlight: make(map[string]bool),
+	}
+}
+
+// claimResult is what claim found for a key.
+type claimResult int
+
+const (
+	// claimed: the key is now in flight and the caller must store or
+	// release it.
+	claimed claimResult = iota
+	// claimReplay: a response is recorded for the key.
+	claimReplay
+	// claimBusy: another request holds the key in flight.
+	claimBusy
+)
+
+// claim returns the unexpired entry recorded for key, if any. Otherwise it
+// marks key in flight, unless another request already has.
+func (c *idempotencyCache) claim(key string) (idempotencyEntry, claimResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.lookupLocked(key); ok {
+		return entry, claimReplay
+	}
+	if c.inFlight[key] {
+		return idempotencyEntry{}, claimBusy
+	}
+	c.inFlight[key] = true
+	return idempotencyEntry{}, claimed
+}
+
+// release gives up a claim on key that recorded no response. It is a no-op
+// once store has recorded one.
+func (c *idempotencyCache) release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.inFlight, key)
+}
+
+// lookup returns the unexpired entry for key, pruning it if it has expired.
+func (c *idempotencyCache) lookup(key string) (idempotencyEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookupLocked(key)
+}
+
+func (c *idempotencyCache) lookupLocked(key string) (idempotencyEntry, bool) {
+	entry, ok := c.entries[key]
+	if !ok {
+		return idempotencyEntry{}, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return idempotencyEntry{}, false
+	}
+	return entry, true
+}
+
+// store records the response for key, computed from a request whose body
+// hashed to bodyHash, and ends its claim.
+func (c *idempotencyCache) store(key string, bodyHash [sha256.Size]byte, status int, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.sweep(now)
+	delete(c.inFlight, key)
+	c.entries[key] = idempotencyEntry{
+		bodyHash: bodyHash,
+		status:   status,
+		body:     body,
+		expires:  now.Add(c.ttl),
+	}
+}
+
+// len returns how many entries are recorded, expired or not.
+func (c *idempotencyCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// sweep drops every expired entry, unless it last ran within a TTL of now.
+// Clients usually send a fresh key per request, so without it keys that are
+// never retried would keep their responses forever.
+func (c *idempotencyCache) sweep(now time.Time) {
+	if now.Before(c.nextSweep) {
+		return
+	}
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+	c.nextSweep = now.Add(c.ttl)
+}
@@ -0,0 +1,128 @@
+package scroll_engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func postSimulate(h http.Handler, body, key string) *httptest.ResponseRecorder {
+	req := newTenantRequest(http.MethodPost, "/simulate", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSimulate_IdempotencyKeyReplaysCachedPlan(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LoopIDs = &CounterLoopIDGenerator{}
+	srv := NewServer(cfg)
+	h := srv.Handler()
+	body := `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["g1"]}`
+
+	first := postSimulate(h, body, "key-1")
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", first.Code)
+	}
+
+	// A replay that re-ran the simulation would mint a new loop ID.
+	loopID := "flare-1"
+
+	second := postSimulate(h, body, "key-1")
+	if second.Code != first.Code {
+		t.Fatalf("expected replayed status %d, got %d", first.Code, second.Code)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Fatalf("expected identical replayed body\nfirst:  %s\nsecond: %s", first.Body, second.Body)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replay to be flagged")
+	}
+	if loop, _ := srv.loops.Get(loopID); loop.State != LoopConverged {
+		t.Fatalf("expected cached replay to leave loop converged, got %s", loop.State)
+	}
+	if _, ok := srv.loops.Get("flare-2"); ok {
+		t.Fatalf("expected no second loop from a replayed request")
+	}
+}
+
+func TestSimulate_IdempotencyKeyDifferentBody(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+
+	postSimulate(h, `{"id":"s1","trust_score":0.9}`, "key-1")
+	rec := postSimulate(h, `{"id":"s2","trust_score":0.1}`, "key-1")
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 for reused key with different body, got %d", rec.Code)
+	}
+}
+
+func TestIdempotencyCache_Expires(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.store("k", [32]byte{}, http.StatusOK, []byte("{}"))
+	if _, ok := c.lookup("k"); !ok {
+		t.Fatalf("expected entry within TTL")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.lookup("k"); ok {
+		t.Fatalf("expected entry to expire after TTL")
+	}
+}
+
+func TestIdempotencyCache_SweepsExpiredKeys(t *testing.T) {
+	c := newIdempotencyCache(time.Minute)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	c.store("a", [32]byte{}, http.StatusOK, []byte("{}"))
+	c.store("b", [32]byte{}, http.StatusOK, []byte("{}"))
+	now = now.Add(time.Minute)
+	c.store("c", [32]byte{}, http.StatusOK, []byte("{}"))
+	if n := c.len(); n != 1 {
+		t.Fatalf("expected never-retried expired keys swept, got %d entries", n)
+	}
+}
+
+func TestSimulate_IdempotencyKeyInFlightRejected(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+	body := `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["g1"]}`
+
+	// Stand in for the original request, still simulating.
+	if _, res := srv.idem.claim(testTenant + "\x00key-1"); res != claimed {
+		t.Fatalf("expected to claim a fresh key, got %v", res)
+	}
+	rec := postSimulate(h, body, "key-1")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while the key is in flight, got %d", rec.Code)
+	}
+	if e := decodeErrorResponse(t, rec); e.Code != CodeIdempotencyBusy {
+		t.Fatalf("unexpected error: %+v", e)
+	}
+	if n, _ := srv.store.CountScrolls(testTenant, ScrollQuery{}); n != 0 {
+		t.Fatalf("expected the rejected retry not to simulate, got %d scrolls", n)
+	}
+
+	srv.idem.release(testTenant + "\x00key-1")
+	if rec := postSimulate(h, body, "key-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 once the key is released, got %d", rec.Code)
+	}
+}
+
+func TestSimulate_IdempotencyKeyFreedAfterFailure(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	if rec := postSimulate(h, `{"id":"s1","trust_score":2}`, "key-1"); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	if rec := postSimulate(h, `{"id":"s1","trust_score":0.9}`, "key-1"); rec.Code != http.StatusOK {
+		t.Fatalf("expected a failed request to free its key, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,236 @@
+package scroll_engine
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// How the weighted strategy averages marker weights; see
+// SimulationConfig.ScoringKind.
+const (
+	// ScoringWeighted gives every target marker an even share.
+	ScoringWeighted = "weighted"
+	// ScoringIDF scores with an IDFWeightedStrategy over the tenant's
+	// marker IDF.
+	ScoringIDF = "idf"
+)
+
+// MarkerIDF is the inverse document frequency of each marker across one
+// tenant's stored scrolls: a marker carried by every scroll says little
+// about any one of them, and one carried by few says a lot. It is computed
+// by Refresh and is safe for concurrent use. It holds a single tenant's
+// frequencies; refreshing it for another tenant replaces them.
+//
+// Weights are smoothed, ln((1+n)/(1+df)) + 1 for n scrolls of which df
+// carry the marker, so every weight is at least 1 and a marker never seen
+// weighs the most. Before the first Refresh, or over an empty store, every
+// marker weighs 1.
+type MarkerIDF struct {
+	// Registry canonicalizes stored markers to match scoring targets; nil
+	// counts them as stored.
+	Registry *MarkerRegistry
+
+	table atomic.Pointer[idfTable]
+}
+
+type idfTable struct {
+	scrolls int
+	df      map[string]int
+}
+
+// Weight returns marker's IDF weight. marker must be canonical.
+func (m *MarkerIDF) Weight(marker string) float64 {
+	t := m.table.Load()
+	if t == nil {
+		return 1
+	}
+	return math.Log(float64(1+t.scrolls)/float64(1+t.df[marker])) + 1
+}
+
+// Refresh recounts marker frequencies over tenant's stored scrolls.
+func (m *MarkerIDF) Refresh(store ScrollStore, tenant string) error {
+	scrolls, err := store.ListScrolls(tenant, ScrollQuery{})
+	if err != nil {
+		return err
+	}
+	t := &idfTable{scrolls: len(scrolls), df: make(map[string]int)}
+	for _, s := range scrolls {
+		markers := s.GeneticMarkers
+		if m.Registry != nil {
+			markers = m.Registry.CanonicalizeAll(markers)
+		}
+		for _, marker := range dedupMarkers(markers) {
+			t.df[marker]++
+		}
+	}
+	m.table.Store(t)
+	return nil
+}
+
+// StartRefresh refreshes m now and then every interval until ctx is
+// cancelled; the returned channel is closed once it has stopped. A failed
+// refresh is logged and the previous weights kept.
+func (m *MarkerIDF) StartRefresh(ctx context.Context, store ScrollStore, tenant string, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	refresh := func() {
+		if err := m.Refresh(store, tenant); err != nil {
+			log.Printf("marker IDF refresh failed: %v", err)
+		}
+	}
+	refresh()
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+	return done
+}
+
+// IDFWeightedStrategy scores like Base, but averages its targets' weights
+// weighted by their IDF, so rare markers count for more than ubiquitous
+// ones. Each contribution is scaled by its marker's share of that weight,
+// so contributions still average to the plan's scores. With a nil IDF, or
+// before it is first refreshed, it scores exactly as Base does.
+//
+// As its MarkerIDF describes one tenant, it should only score that tenant's
+// scrolls. The server, configured with ScoringIDF, keeps an IDF per tenant
+// and scores each tenant's scrolls with its own.
+type IDFWeightedStrategy struct {
+	Base WeightedStrategy
+	IDF  *MarkerIDF
+}
+
+func (w IDFWeightedStrategy) Score(ctx context.Context, _ types.Scroll, targets []string) (Score, error) {
+	if err := ctx.Err(); err != nil {
+		return Score{}, err
+	}
+	if len(targets) == 0 {
+		return Score{}, nil
+	}
+
+	var s Score
+	var total float64
+	idfs := make([]float64, len(targets))
+	for i, g := range targets {
+		mw, ok := w.Base.Weights[g]
+		if !ok {
+			mw = w.Base.Default
+		}
+		idfs[i] = 1.0
+		if w.IDF != nil {
+			idfs[i] = w.IDF.Weight(g)
+		}
+		s.PredictedRelief += idfs[i] * mw.Relief
+		s.FlareSuppression += idfs[i] * mw.Suppression
+		total += idfs[i]
+		s.Contributions = append(s.Contributions, types.MarkerContribution{
+			Gene: g, Relief: mw.Relief, Suppression: mw.Suppression,
+		})
+	}
+	s.PredictedRelief /= total
+	s.FlareSuppression /= total
+	// Scale each contribution by its share of the IDF weight, relative to
+	// the even share Base gives it.
+	n := float64(len(targets))
+	for i := range s.Contributions {
+		share := idfs[i] * n / total
+		s.Contributions[i].Relief *= share
+		s.Contributions[i].Suppression *= share
+	}
+	return s, nil
+}
+
+func (c SimulationConfig) validateScoringKind() error {
+	switch c.ScoringKind {
+	case ScoringWeighted:
+	case ScoringIDF:
+		if c.ScoringURL != "" {
+			return &ConfigError{Key: "scoring", Message: fmt.Sprintf("%q scoring weights local marker weights and cannot be combined with scoring_url", ScoringIDF)}
+		}
+	default:
+		return &ConfigError{Key: "scoring", Message: fmt.Sprintf("must be %q or %q", ScoringWeighted, ScoringIDF)}
+	}
+	if c.IDFRefreshInterval <= 0 {
+		return &ConfigError{Key: "idf_refresh_interval", Message: "must be positive"}
+	}
+	return nil
+}
+
+// tenantIDF returns tenant's marker IDF under a, counting it over store on
+// first use. A reload starts every tenant's count afresh, canonicalized
+// through the new config's registry.
+func (a *activeConfig) tenantIDF(store ScrollStore, tenant string) *MarkerIDF {
+	a.idfMu.Lock()
+	defer a.idfMu.Unlock()
+	if idf, ok := a.idfs[tenant]; ok {
+		return idf
+	}
+	idf := &MarkerIDF{Registry: a.engine().reg}
+	if err := idf.Refresh(store, tenant); err != nil {
+		log.Printf("marker IDF refresh for tenant %s failed: %v", tenant, err)
+	}
+	if a.idfs == nil {
+		a.idfs = make(map[string]*MarkerIDF)
+	}
+	a.idfs[tenant] = idf
+	return idf
+}
+
+// engineFor returns the compiled config tenant's simulations run under:
+// the active one with overrides, if any, applied and, under IDF scoring,
+// scoring with tenant's marker IDF.
+func (s *Server) engineFor(active *activeConfig, tenant string, overrides map[string]MarkerWeight) *compiledConfig {
+	engine := active.engineFor(overrides)
+	if active.cfg.ScoringKind != ScoringIDF {
+		return engine
+	}
+	return engine.withIDF(active.tenantIDF(s.store, tenant))
+}
+
+// StartIDFRefresh runs a worker that, every interval, recounts the marker
+// IDF of every tenant the active config has scored under IDF scoring. It
+// stops when ctx is cancelled; the returned channel is closed once it has.
+func (s *Server) StartIDFRefresh(ctx context.Context, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.refreshIDFs()
+			}
+		}
+	}()
+	return done
+}
+
+func (s *Server) refreshIDFs() {
+	active := s.active.Load()
+	active.idfMu.Lock()
+	idfs := maps.Clone(active.idfs)
+	active.idfMu.Unlock()
+	for tenant, idf := range idfs {
+		if err := idf.Refresh(s.store, tenant); err != nil {
+			log.Printf("marker IDF refresh for tenant %s failed: %v", tenant, err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package scroll_engine
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestIDFWeightedStrategy_RareMarkerOutweighsUbiquitous(t *testing.T) {
+	store := NewMemoryStore()
+	seedIDFScrolls(store, testTenant)
+	idf := &MarkerIDF{}
+	if err := idf.Refresh(store, testTenant); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if idf.Weight("RARE") <= idf.Weight("COMMON") || idf.Weight("COMMON") != 1 {
+		t.Fatalf("expected RARE to outweigh COMMON (weight 1), got %v vs %v", idf.Weight("RARE"), idf.Weight("COMMON"))
+	}
+
+	base := WeightedStrategy{Weights: map[string]MarkerWeight{
+		"COMMON": {Relief: 0.2, Suppression: 0.2},
+		"RARE":   {Relief: 0.8, Suppression: 0.8},
+	}}
+	targets := []string{"COMMON", "RARE"}
+	plain, _ := base.Score(t.Context(), types.Scroll{}, targets)
+	weighted, err := IDFWeightedStrategy{Base: base, IDF: idf}.Score(t.Context(), types.Scroll{}, targets)
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if weighted.PredictedRelief <= plain.PredictedRelief || weighted.PredictedRelief > 0.8 {
+		t.Fatalf("expected relief pulled toward RARE's 0.8 from the plain mean %v, got %v", plain.PredictedRelief, weighted.PredictedRelief)
+	}
+
+	var sum float64
+	for _, c := range weighted.Contributions {
+		sum += c.Relief
+	}
+	if mean := sum / float64(len(weighted.Contributions)); math.Abs(mean-weighted.PredictedRelief) > 1e-9 {
+		t.Fatalf("expected contributions to average to the weighted relief %v, got %v", weighted.PredictedRelief, mean)
+	}
+	if weighted.Contributions[1].Relief <= 0.8 {
+		t.Fatalf("expected RARE's contribution scaled up by its IDF, got %+v", weighted.Contributions[1])
+	}
+}
+
+func TestIDFWeightedStrategy_ColdStartMatchesBase(t *testing.T) {
+	base := WeightedStrategy{Default: MarkerWeight{Relief: 0.3, Suppression: 0.6}, Weights: map[string]MarkerWeight{"A": {Relief: 0.9, Suppression: 0.1}}}
+	idf := &MarkerIDF{}
+	targets := []string{"A", "B"}
+	want, _ := base.Score(t.Context(), types.Scroll{}, targets)
+
+	for name, strategy := range map[string]IDFWeightedStrategy{
+		"never refreshed": {Base: base, IDF: idf},
+		"nil IDF":         {Base: base},
+	} {
+		got, _ := strategy.Score(t.Context(), types.Scroll{}, targets)
+		if got.PredictedRelief != want.PredictedRelief || got.FlareSuppression != want.FlareSuppression ||
+			!slices.Equal(got.Contributions, want.Contributions) {
+			t.Fatalf("%s: expected base scores %+v, got %+v", name, want, got)
+		}
+	}
+
+	if err := idf.Refresh(NewMemoryStore(), testTenant); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if w := idf.Weight("A"); w != 1 {
+		t.Fatalf("expected weight 1 over an empty store, got %v", w)
+	}
+}
+
+func postSimulateAs(h http.Handler, tenant, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(body))
+	req.Header.Set(TenantHeader, tenant)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSimulate_IDFScoringPerTenant(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MarkerWeights = map[string]MarkerWeight{
+		"COMMON": {Relief: 0.2, Suppression: 0.2},
+		"RARE":   {Relief: 0.8, Suppression: 0.8},
+	}
+	weighted := decodePlan(t, postSimulate(NewServer(cfg).Handler(), idfFlareBody, ""))
+
+	cfg.ScoringKind = ScoringIDF
+	srv := NewServer(cfg)
+	h := srv.Handler()
+	seedIDFScrolls(srv.store, testTenant)
+
+	plan := decodePlan(t, postSimulate(h, idfFlareBody, ""))
+	if plan.PredictedRelief <= weighted.PredictedRelief {
+		t.Fatalf("expected RARE to pull relief above the even mean %v, got %v", weighted.PredictedRelief, plan.PredictedRelief)
+	}
+
+	// Another tenant's scrolls do not count toward this one's IDF.
+	other := decodePlan(t, postSimulateAs(h, "other", idfFlareBody))
+	if other.PredictedRelief != weighted.PredictedRelief {
+		t.Fatalf("expected a tenant with no history scored evenly (%v), got %v", weighted.PredictedRelief, other.PredictedRelief)
+	}
+
+	seedIDFScrolls(srv.store, "other")
+	srv.refreshIDFs()
+	if refreshed := decodePlan(t, postSimulateAs(h, "other", idfFlareBody)); refreshed.PredictedRelief <= weighted.PredictedRelief {
+		t.Fatalf("expected the refresh to pick up the tenant's new scrolls, got relief %v", refreshed.PredictedRelief)
+	}
+}
+
+const idfFlareBody = `{"id":"new","trust_score":0.9,"is_flare_event":true,"genetic_markers":["COMMON","RARE"]}`
+
+// seedIDFScrolls stores 20 scrolls for tenant, all carrying COMMON and one
+// carrying RARE.
+func seedIDFScrolls(store ScrollStore, tenant string) {
+	for i := range 20 {
+		markers := []string{"COMMON"}
+		if i == 0 {
+			markers = append(markers, "RARE")
+		}
+		_ = store.SaveScroll(tenant, types.Scroll{ID: fmt.Sprintf("s%d", i), GeneticMarkers: markers})
+	}
+}
@@ -0,0 +1,185 @@
+package scroll_engine
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ImportFailure is a scroll Import could not import. Line is the 1-based
+// line the scroll starts on.
+type ImportFailure struct {
+	Line  int    `json:"line"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportReport counts what Import did with each scroll it read. Skipped
+// scrolls were already stored.
+type ImportReport struct {
+	Imported int             `json:"imported"`
+	Skipped  int             `json:"skipped"`
+	Failed   []ImportFailure `json:"failed,omitempty"`
+}
+
+// Import reads scrolls from r, either NDJSON or a JSON array, and simulates
+// and persists each for tenant exactly as /simulate would. Scrolls already
+// stored are skipped unless overwrite is set, so an interrupted import can
+// be rerun. A scroll that fails is recorded in the report and the import
+// continues; the error is only for input Import cannot read at all. r is
+// streamed, never read whole.
+func (s *Server) Import(ctx context.Context, tenant string, r io.Reader, overwrite bool) (ImportReport, error) {
+	var report ImportReport
+	one := func(line int, raw []byte) {
+		id, err := s.importScroll(ctx, tenant, raw, overwrite)
+		switch {
+		case errors.Is(err, errAlreadyStored):
+			report.Skipped++
+		case err != nil:
+			report.Failed = append(report.Failed, ImportFailure{Line: line, ID: id, Error: err.Error()})
+		default:
+			report.Imported++
+		}
+	}
+
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err == io.EOF {
+		return report, nil
+	}
+	if err != nil {
+		return report, err
+	}
+	if first == '[' {
+		return report, importArray(ctx, br, one)
+	}
+	return report, importNDJSON(ctx, br, one)
+}
+
+// errAlreadyStored marks a scroll Import skips.
+var errAlreadyStored = errors.New("scroll already stored")
+
+// importScroll imports one raw scroll, returning its ID when it could be
+// read.
+func (s *Server) importScroll(ctx context.Context, tenant string, raw []byte, overwrite bool) (string, error) {
+	req, rerr := s.parseSimulateRequest(raw, tenant)
+	if rerr != nil {
+		if rerr.body.Field != "" {
+			return req.ID, fmt.Errorf("%s: %s", rerr.body.Field, rerr.body.Message)
+		}
+		return req.ID, errors.New(rerr.body.Message)
+	}
+	if !overwrite {
+		_, err := s.store.GetScroll(tenant, req.ID)
+		if err == nil {
+			return req.ID, errAlreadyStored
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return req.ID, fmt.Errorf("look up scroll: %w", err)
+		}
+	}
+	plan, err := s.simulate(ctx, req)
+	if err != nil {
+		return req.ID, err
+	}
+	if err := s.persist(ctx, tenant, req.Scroll, plan); err != nil {
+		return req.ID, fmt.Errorf("store plan: %w", err)
+	}
+	return req.ID, nil
+}
+
+// importNDJSON calls one for each non-blank line of r.
+func importNDJSON(ctx context.Context, r io.Reader, one func(line int, raw []byte)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		one(line, sc.Bytes())
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("line %d: %w", line+1, err)
+	}
+	return nil
+}
+
+// importArray calls one for each element of the JSON array r holds.
+func importArray(ctx context.Context, r io.Reader, one func(line int, raw []byte)) error {
+	lr := &lineReader{r: r}
+	dec := json.NewDecoder(lr)
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	for dec.More() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := dec.InputOffset()
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
+		line := lr.lineOf(start)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		one(line, raw)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// peekNonSpace skips leading whitespace in br and returns the next byte
+// without consuming it.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if !isJSONSpace(b) {
+			return b, br.UnreadByte()
+		}
+	}
+}
+
+func isJSONSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n'
+}
+
+// lineReader tracks line numbers in what a json.Decoder reads, keeping only
+// the bytes read since the last offset it was asked about.
+type lineReader struct {
+	r       io.Reader
+	pending []byte
+	base    int64
+	lines   int
+}
+
+func (l *lineReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.pending = append(l.pending, p[:n]...)
+	return n, err
+}
+
+// lineOf returns the 1-based line of the first value byte at or after off,
+// skipping the whitespace and comma a decoder offset may sit before. Offsets
+// must not decrease between calls, and the value must have been read.
+func (l *lineReader) lineOf(off int64) int {
+	k := int(off - l.base)
+	for k < len(l.pending) && (isJSONSpace(l.pending[k]) || l.pending[k] == ',') {
+		k++
+	}
+	l.lines += bytes.Count(l.pending[:k], []byte{'\n'})
+	l.pending = append(l.pending[:0], l.pending[k:]...)
+	l.base += int64(k)
+	return l.lines + 1
+}
@@ -0,0 +1,66 @@
+package scroll_engine
+
+import (
+	"strings"
+	"testing"
+)
+
+const importNDJSONFile = `{"id":"a","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}
+{"id":"b","trust_score":0.4}
+
+{"id":"c","trust_score":1.5}
+{"id":"d","trust_score":0.7}
+`
+
+func TestImport_NDJSONReportsBadLine(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+
+	report, err := srv.Import(t.Context(), testTenant, strings.NewReader(importNDJSONFile), false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Imported != 3 || report.Skipped != 0 || len(report.Failed) != 1 {
+		t.Fatalf("expected 3 imported and 1 failed, got %+v", report)
+	}
+	if f := report.Failed[0]; f.Line != 4 || f.ID != "c" || !strings.Contains(f.Error, "trust_score") {
+		t.Fatalf("expected scroll c to fail on line 4, got %+v", f)
+	}
+	for _, id := range []string{"a", "b", "d"} {
+		if _, err := srv.store.GetScroll(testTenant, id); err != nil {
+			t.Fatalf("expected scroll %s stored: %v", id, err)
+		}
+		if _, err := srv.store.GetPlan(testTenant, id); err != nil {
+			t.Fatalf("expected plan for %s stored: %v", id, err)
+		}
+	}
+
+	// Rerunning skips what is already stored; -overwrite re-imports it.
+	report, _ = srv.Import(t.Context(), testTenant, strings.NewReader(importNDJSONFile), false)
+	if report.Imported != 0 || report.Skipped != 3 || len(report.Failed) != 1 {
+		t.Fatalf("expected rerun to skip stored scrolls, got %+v", report)
+	}
+	report, _ = srv.Import(t.Context(), testTenant, strings.NewReader(importNDJSONFile), true)
+	if report.Imported != 3 || report.Skipped != 0 {
+		t.Fatalf("expected overwrite to re-import, got %+v", report)
+	}
+}
+
+func TestImport_JSONArrayLineNumbers(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	file := `[
+  {"id": "a", "trust_score": 0.9},
+  {
+    "id": "b",
+    "trust_score": -1
+  },
+  {"id": "c", "trust_score": 0.2}
+]`
+
+	report, err := srv.Import(t.Context(), testTenant, strings.NewReader(file), false)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if report.Imported != 2 || len(report.Failed) != 1 || report.Failed[0].Line != 3 {
+		t.Fatalf("expected b to fail starting on line 3, got %+v", report)
+	}
+}
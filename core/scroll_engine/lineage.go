@@ -0,0 +1,70 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// ErrLineageCycle is returned when a scroll's parent links loop back on
+// themselves.
+var ErrLineageCycle = errors.New("lineage cycle")
+
+// Lineage walks parent links from tenant's scroll with id and returns its
+// ancestry oldest-first, ending with the scroll itself. The walk stops at a
+// scroll with no parent or whose parent is not in the store. It returns
+// ErrNotFound if id itself is not stored and an error wrapping
+// ErrLineageCycle if a scroll is reached twice.
+func Lineage(store ScrollStore, tenant, id string) ([]types.Scroll, error) {
+	scroll, err := store.GetScroll(tenant, id)
+	if err != nil {
+		return nil, err
+	}
+	chain := []types.Scroll{scroll}
+	seen := map[string]bool{scroll.ID: true}
+	for scroll.ParentID != "" {
+		if seen[scroll.ParentID] {
+			return nil, fmt.Errorf("%w: %s is its own ancestor", ErrLineageCycle, scroll.ParentID)
+		}
+		parent, err := store.GetScroll(tenant, scroll.ParentID)
+		if errors.Is(err, ErrNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		seen[parent.ID] = true
+		chain = append(chain, parent)
+		scroll = parent
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain, nil
+}
+
+func (s *Server) lineageHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	chain, err := Lineage(s.store, tenant, r.PathValue("id"))
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, CodeNotFound, "scroll not found", "id")
+		return
+	case errors.Is(err, ErrLineageCycle):
+		writeError(w, http.StatusConflict, CodeLineageCycle, err.Error(), "parent_id")
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(chain)
+}
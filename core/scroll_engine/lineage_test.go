@@ -0,0 +1,83 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func getLineage(t *testing.T, h http.Handler, id string) (int, []types.Scroll) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/scrolls/"+id+"/lineage", nil))
+	var chain []types.Scroll
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&chain); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return rec.Code, chain
+}
+
+func lineageIDs(chain []types.Scroll) []string {
+	ids := make([]string, len(chain))
+	for i, s := range chain {
+		ids[i] = s.ID
+	}
+	return ids
+}
+
+func TestLineage_MultiGeneration(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	for _, s := range []types.Scroll{
+		{ID: "root", TrustScore: 0.3},
+		{ID: "recal", TrustScore: 0.6, ParentID: "root"},
+		{ID: "reborn", TrustScore: 0.9, ParentID: "recal"},
+	} {
+		_ = srv.store.SaveScroll(testTenant, s)
+	}
+	h := srv.Handler()
+
+	code, chain := getLineage(t, h, "reborn")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if got, want := lineageIDs(chain), []string{"root", "recal", "reborn"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("lineage %v, want %v", got, want)
+	}
+
+	if _, chain := getLineage(t, h, "root"); !reflect.DeepEqual(lineageIDs(chain), []string{"root"}) {
+		t.Fatalf("expected parentless scroll alone, got %v", lineageIDs(chain))
+	}
+	if code, _ := getLineage(t, h, "missing"); code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", code)
+	}
+}
+
+func TestLineage_StopsAtMissingParent(t *testing.T) {
+	store := NewMemoryStore()
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "orphan", ParentID: "pruned"})
+
+	chain, err := Lineage(store, testTenant, "orphan")
+	if err != nil || !reflect.DeepEqual(lineageIDs(chain), []string{"orphan"}) {
+		t.Fatalf("expected orphan alone, got %v, %v", lineageIDs(chain), err)
+	}
+}
+
+func TestLineage_CycleRejected(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	_ = srv.store.SaveScroll(testTenant, types.Scroll{ID: "a", ParentID: "b"})
+	_ = srv.store.SaveScroll(testTenant, types.Scroll{ID: "b", ParentID: "a"})
+
+	if _, err := Lineage(srv.store, testTenant, "a"); !errors.Is(err, ErrLineageCycle) {
+		t.Fatalf("expected ErrLineageCycle, got %v", err)
+	}
+	if code, _ := getLineage(t, srv.Handler(), "b"); code != http.StatusConflict {
+		t.Fatalf("expected 409 for cyclic lineage, got %d", code)
+	}
+}
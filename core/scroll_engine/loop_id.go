@@ -0,0 +1,43 @@
+package scroll_engine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// LoopIDGenerator produces the MutationLoopID assigned to a plan. branch is
+// the simulation branch the plan came from and is used as the ID prefix.
+type LoopIDGenerator interface {
+	NextLoopID(branch string) string
+}
+
+// TimestampLoopIDGenerator produces IDs of the form
+// <branch>-<utc timestamp>-<random hex>, e.g. flare-20260112T143113-9f2c41ab.
+type TimestampLoopIDGenerator struct{}
+
+func (TimestampLoopIDGenerator) NextLoopID(branch string) string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return fmt.Sprintf("%s-%s-%s", branch, time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(b[:]))
+}
+
+// CounterLoopIDGenerator produces deterministic IDs of the form
+// <branch>-<n>, counting from 1. It is safe for concurrent use.
+type CounterLoopIDGenerator struct {
+	n atomic.Uint64
+}
+
+func (g *CounterLoopIDGenerator) NextLoopID(branch string) string {
+	return fmt.Sprintf("%s-%d", branch, g.n.Add(1))
+}
+
+// loopIDs returns the configured generator, falling back to timestamps.
+func (c SimulationConfig) loopIDs() LoopIDGenerator {
+	if c.LoopIDs == nil {
+		return TimestampLoopIDGenerator{}
+	}
+	return c.LoopIDs
+}
@@ -0,0 +1,75 @@
+package scroll_engine
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maintenanceRetryAfter is the Retry-After, in seconds, sent with the 503s
+// refusing writes during maintenance.
+const maintenanceRetryAfter = 30
+
+// maintenanceRequest is the body of POST /admin/maintenance.
+type maintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// SetMaintenance turns maintenance mode on or off. In maintenance, new
+// simulations and other writes are refused with 503 and /readyz reports 503
+// so load balancers drain the server; requests already running finish, and
+// reads and /health keep working.
+func (s *Server) SetMaintenance(on bool) {
+	s.maintenance.Store(on)
+}
+
+// drained wraps a handler that writes so it is refused during maintenance.
+func (s *Server) drained(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.maintenance.Load() {
+			s.metrics.Inc("maintenance_rejections_total")
+			w.Header().Set("Retry-After", strconv.Itoa(maintenanceRetryAfter))
+			writeError(w, http.StatusServiceUnavailable, CodeMaintenance, "server is in maintenance; retry later", "")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// authorizeAdmin checks r carries the configured admin bearer token,
+// writing a 403 if none is configured or a 401 if r's does not match.
+func (s *Server) authorizeAdmin(w http.ResponseWriter, r *http.Request) bool {
+	want := s.config().AdminToken
+	if want == "" {
+		writeError(w, http.StatusForbidden, CodeUnauthorized, "no admin token is configured", "")
+		return false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, CodeUnauthorized, "missing or invalid admin token", "")
+		return false
+	}
+	return true
+}
+
+func (s *Server) maintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	var req maintenanceRequest
+	if err := decodeBody(r.Body, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if req.Enabled == nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "enabled is required", "enabled")
+		return
+	}
+	s.SetMaintenance(*req.Enabled)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]bool{"maintenance": *req.Enabled})
+}
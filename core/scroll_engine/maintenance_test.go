@@ -0,0 +1,90 @@
+package scroll_engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testAdminToken = "s3cret"
+
+func setMaintenance(t *testing.T, h http.Handler, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := newTenantRequest(http.MethodPost, "/admin/maintenance", strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func getStatus(h http.Handler, path string) int {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, path, nil))
+	return rec.Code
+}
+
+func TestMaintenance_DrainsWritesButNotReads(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = testAdminToken
+	srv := NewServer(cfg)
+	srv.Warmup()
+	h := srv.Handler()
+	mustPost := func(body string) {
+		t.Helper()
+		if rec := postSimulate(h, body, ""); rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+		}
+	}
+	mustPost(budgetFlareBody)
+
+	if rec := setMaintenance(t, h, testAdminToken, `{"enabled":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected maintenance enabled, got %d: %s", rec.Code, rec.Body)
+	}
+	rec := postSimulate(h, budgetFlareBody, "")
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 503 with Retry-After in maintenance, got %d %v", rec.Code, rec.Header())
+	}
+	if code := decodeErrorResponse(t, rec).Code; code != CodeMaintenance {
+		t.Fatalf("expected code %s, got %s", CodeMaintenance, code)
+	}
+	if code := getStatus(h, "/health"); code != http.StatusOK {
+		t.Fatalf("expected /health to stay 200, got %d", code)
+	}
+	if code := getStatus(h, "/scrolls/f"); code != http.StatusOK {
+		t.Fatalf("expected reads to keep working, got %d", code)
+	}
+	if code := getStatus(h, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected /readyz to report 503 so traffic drains, got %d", code)
+	}
+
+	if rec := setMaintenance(t, h, testAdminToken, `{"enabled":false}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected maintenance disabled, got %d: %s", rec.Code, rec.Body)
+	}
+	mustPost(budgetFlareBody)
+	if code := getStatus(h, "/readyz"); code != http.StatusOK {
+		t.Fatalf("expected /readyz back to 200, got %d", code)
+	}
+}
+
+func TestMaintenance_RequiresAdminToken(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = testAdminToken
+	h := NewServer(cfg).Handler()
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := setMaintenance(t, h, token, `{"enabled":true}`); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected 401, got %d", token, rec.Code)
+		}
+	}
+	if rec := postSimulate(h, budgetFlareBody, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected an unauthorized toggle to leave simulate up, got %d", rec.Code)
+	}
+
+	unconfigured := NewServer(DefaultConfig()).Handler()
+	if rec := setMaintenance(t, unconfigured, "anything", `{"enabled":true}`); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without a configured admin token, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package scroll_engine
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Metrics is a minimal registry of counters and gauges rendered in the
+// Prometheus text exposition format. Series names may carry labels, e.g.
+// `scroll_compost_total{reason="drift"}`.
+type Metrics struct {
+	mu       sync.Mutex
+	counters map[string]float64
+	gauges   map[string]func() float64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		counters: make(map[string]float64),
+		gauges:   make(map[string]func() float64),
+	}
+}
+
+// Inc adds one to the counter series.
+func (m *Metrics) Inc(series string) {
+	m.Add(series, 1)
+}
+
+// Add adds v to the counter series.
+func (m *Metrics) Add(series string, v float64) {
+	m.mu.Lock()
+	m.counters[series] += v
+	m.mu.Unlock()
+}
+
+// Counter returns the current value of a counter series.
+func (m *Metrics) Counter(series string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[series]
+}
+
+// Gauge registers a gauge series whose value is read from f at scrape time.
+func (m *Metrics) Gauge(series string, f func() float64) {
+	m.mu.Lock()
+	m.gauges[series] = f
+	m.mu.Unlock()
+}
+
+// WritePrometheus writes every series, sorted by name, to w.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	values := make(map[string]float64, len(m.counters)+len(m.gauges))
+	kinds := make(map[string]string)
+	for series, v := range m.counters {
+		values[series] = v
+		kinds[metricName(series)] = "counter"
+	}
+	gauges := make(map[string]func() float64, len(m.gauges))
+	for series, f := range m.gauges {
+		gauges[series] = f
+		kinds[metricName(series)] = "gauge"
+	}
+	m.mu.Unlock()
+
+	// Gauges are read outside the lock so they may consult other state.
+	for series, f := range gauges {
+		values[series] = f()
+	}
+
+	names := make([]string, 0, len(values))
+	for series := range values {
+		names = append(names, series)
+	}
+	sort.Strings(names)
+
+	typed := make(map[string]bool)
+	for _, series := range names {
+		name := metricName(series)
+		if !typed[name] {
+			typed[name] = true
+			if _, err := fmt.Fprintf(w, "# TYPE %s %s\n", name, kinds[name]); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "%s %g\n", series, values[series]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metricName strips any label set from a series.
+func metricName(series string) string {
+	name, _, _ := strings.Cut(series, "{")
+	return name
+}
@@ -0,0 +1,134 @@
+package scroll_engine
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// RequestIDHeader carries the ID that ties a response to its access log
+// entry. An inbound value is honored so IDs can follow a request across
+// services.
+const RequestIDHeader = "X-Request-ID"
+
+// statusRecorder captures the status code and body size a handler writes.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.size += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// accessLog wraps next so every request is assigned a request ID and logged
+// to logger with its method, path, status, response size, and duration once
+// it completes.
+func accessLog(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		rec := &statusRecorder{ResponseWriter: w}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		logger.LogAttrs(r.Context(), slog.LevelInfo, "request",
+			slog.String("request_id", id),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Int("size", rec.size),
+			slog.Duration("duration", time.Since(start)),
+		)
+	})
+}
+
+// limitBodies caps request bodies at maxBytes, or at maxStreamBytes for
+// NDJSON bodies, which carry many scrolls; a limit of zero or less is no
+// limit. A body declaring a length over its limit is refused with a 413
+// outright. Otherwise reading past the limit fails with an
+// *http.MaxBytesError, which decodeError reports as a 413.
+func limitBodies(maxBytes, maxStreamBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := maxBytes
+		if isNDJSON(r) {
+			limit = maxStreamBytes
+		}
+		if limit > 0 {
+			if r.ContentLength > limit {
+				bodyTooLarge(limit).write(w)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverPanics wraps next so a panicking handler is answered with a 500
+// error envelope instead of taking down the server. The panic and its stack
+// are logged to logger and counted in panics_total. http.ErrAbortHandler is
+// re-raised, as net/http expects, and so is every panic in builds tagged
+// propagate_panics, so tests can let bugs surface instead of masking them.
+func recoverPanics(logger *slog.Logger, metrics *Metrics, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if propagatePanics || v == http.ErrAbortHandler {
+				panic(v)
+			}
+			metrics.Inc("panics_total")
+			logger.LogAttrs(r.Context(), slog.LevelError, "panic",
+				slog.String("request_id", w.Header().Get(RequestIDHeader)),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Any("panic", v),
+				slog.String("stack", string(debug.Stack())),
+			)
+			// Once the handler has started the response there is no
+			// status left to change; the client sees a truncated body.
+			if rec.status == 0 {
+				writeError(w, http.StatusInternalServerError, CodeInternal, "internal server error", "")
+			}
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
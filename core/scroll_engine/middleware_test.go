@@ -0,0 +1,195 @@
+package scroll_engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func loggedServer(cfg SimulationConfig) (*Server, *bytes.Buffer) {
+	var buf bytes.Buffer
+	srv := NewServer(cfg)
+	srv.logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	return srv, &buf
+}
+
+func TestAccessLog_RecordsRequest(t *testing.T) {
+	srv, buf := loggedServer(DefaultConfig())
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, newTenantRequest(http.MethodGet, "/loops/missing", nil))
+
+	id := rec.Header().Get(RequestIDHeader)
+	if id == "" {
+		t.Fatalf("expected %s response header", RequestIDHeader)
+	}
+	var entry struct {
+		RequestID string `json:"request_id"`
+		Method    string `json:"method"`
+		Path      string `json:"path"`
+		Status    int    `json:"status"`
+		Size      int    `json:"size"`
+		Duration  *int64 `json:"duration"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode log entry %q: %v", buf, err)
+	}
+	if entry.RequestID != id || entry.Method != http.MethodGet || entry.Path != "/loops/missing" {
+		t.Fatalf("unexpected log entry: %+v", entry)
+	}
+	if entry.Status != http.StatusNotFound || entry.Size != rec.Body.Len() {
+		t.Fatalf("expected status 404 and size %d, got %+v", rec.Body.Len(), entry)
+	}
+	if entry.Duration == nil || *entry.Duration < 0 {
+		t.Fatalf("expected a recorded duration, got %+v", entry)
+	}
+}
+
+func TestAccessLog_HonorsInboundRequestID(t *testing.T) {
+	srv, buf := loggedServer(DefaultConfig())
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	req.Header.Set(RequestIDHeader, "upstream-42")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	if got := rec.Header().Get(RequestIDHeader); got != "upstream-42" {
+		t.Fatalf("expected inbound request ID echoed, got %q", got)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"request_id":"upstream-42"`)) {
+		t.Fatalf("expected inbound ID in log: %s", buf)
+	}
+}
+
+func TestAccessLog_Disabled(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AccessLog = false
+	srv, buf := loggedServer(cfg)
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	if rec.Header().Get(RequestIDHeader) != "" || buf.Len() != 0 {
+		t.Fatalf("expected no access logging when disabled")
+	}
+}
+
+// panickingStrategy panics the way a scoring strategy might on data it
+// cannot handle.
+type panickingStrategy struct{}
+
+func (panickingStrategy) Score(context.Context, types.Scroll, []string) (Score, error) {
+	panic("index out of range")
+}
+
+func TestRecoverPanics_Returns500AndKeepsServing(t *testing.T) {
+	if propagatePanics {
+		t.Skip("panics propagate in this build")
+	}
+	cfg := DefaultConfig()
+	cfg.Scoring = panickingStrategy{}
+	srv, buf := loggedServer(cfg)
+	h := srv.Handler()
+
+	rec := postSimulate(h, `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`, "")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error.Code != CodeInternal {
+		t.Fatalf("expected an internal_error envelope, got %s", rec.Body)
+	}
+	if got := srv.metrics.Counter("panics_total"); got != 1 {
+		t.Fatalf("expected 1 panic counted, got %v", got)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"msg":"panic"`)) || !bytes.Contains(buf.Bytes(), []byte("index out of range")) {
+		t.Fatalf("expected the panic logged: %s", buf)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the server to keep serving, got %d", rec.Code)
+	}
+}
+
+func TestRecoverPanics_ReraisesAbortHandler(t *testing.T) {
+	h := recoverPanics(slog.New(slog.DiscardHandler), NewMetrics(), http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Fatalf("expected http.ErrAbortHandler re-raised, got %v", v)
+		}
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestLimitBodies_SingleOverLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 256
+	h := NewServer(cfg).Handler()
+	body := `{"id":"s","trust_score":0.5,"genetic_markers":["` + strings.Repeat("A", 512) + `"]}`
+
+	// Declared over the limit, and discovered over it while reading.
+	for _, length := range []int64{int64(len(body)), -1} {
+		req := newTenantRequest(http.MethodPost, "/simulate", strings.NewReader(body))
+		req.ContentLength = length
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("content length %d: expected 413, got %d: %s", length, rec.Code, rec.Body)
+		}
+		if e := decodeErrorResponse(t, rec); e.Code != CodeBodyTooLarge {
+			t.Fatalf("content length %d: expected %s, got %+v", length, CodeBodyTooLarge, e)
+		}
+	}
+
+	// A truncated body under the limit is still malformed, not too large.
+	if rec := postSimulate(h, `{"id":"s",`, ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed JSON, got %d", rec.Code)
+	}
+}
+
+func TestLimitBodies_StreamHasLargerLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 64
+	cfg.MaxStreamBodyBytes = 256
+	h := NewServer(cfg).Handler()
+	line := `{"id":"s","trust_score":0.5}` + "\n"
+
+	stream := func(body string, length int64) *httptest.ResponseRecorder {
+		req := newTenantRequest(http.MethodPost, "/simulate", strings.NewReader(body))
+		req.Header.Set("Content-Type", ndjsonContentType)
+		req.ContentLength = length
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Over the single limit but within the stream one.
+	ok := strings.Repeat(line, 3)
+	if rec := stream(ok, int64(len(ok))); rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), CodeBodyTooLarge) {
+		t.Fatalf("expected a stream under its limit to succeed, got %d: %s", rec.Code, rec.Body)
+	}
+
+	big := strings.Repeat(line, 20)
+	if rec := stream(big, int64(len(big))); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413 for a stream declared over its limit, got %d", rec.Code)
+	}
+	// Without a declared length the stream has started; the overrun is
+	// reported inline after the lines that fit.
+	rec := stream(big, -1)
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	var last StreamError
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil || last.Error.Code != CodeBodyTooLarge {
+		t.Fatalf("expected the stream to end with %s, got %s", CodeBodyTooLarge, lines[len(lines)-1])
+	}
+	if len(lines) < 2 {
+		t.Fatalf("expected plans for the lines within the limit, got %d lines", len(lines))
+	}
+}
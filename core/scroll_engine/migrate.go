@@ -0,0 +1,143 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// scrollUpgrades[v] rewrites a version v scroll document as version v+1.
+var scrollUpgrades = map[int]func(map[string]json.RawMessage) error{
+	// Version 1 flagged flares with a trigger, which normalizeFlare
+	// reconciles in every version, so it needs no upgrade of its own.
+	1: func(map[string]json.RawMessage) error { return nil },
+}
+
+// How normalizeFlare settles a trigger that contradicts is_flare_event; see
+// SimulationConfig.FlarePrecedence.
+const (
+	// FlareReject rejects the scroll.
+	FlareReject = "reject"
+	// FlareIsFlareEvent keeps is_flare_event.
+	FlareIsFlareEvent = "is_flare_event"
+	// FlareTrigger keeps the trigger.
+	FlareTrigger = "trigger"
+)
+
+// MigrateScroll decodes scroll JSON of any supported schema version into the
+// current shape, upgrading it one version at a time. A missing or zero
+// schema_version is version 1. The result carries
+// types.CurrentScrollSchemaVersion. A trigger contradicting is_flare_event
+// is rejected.
+func MigrateScroll(raw json.RawMessage) (types.Scroll, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return types.Scroll{}, err
+	}
+	upgraded, err := upgradeScrollDoc(doc, FlareReject)
+	if err != nil {
+		return types.Scroll{}, err
+	}
+	var scroll types.Scroll
+	if err := json.Unmarshal(upgraded, &scroll); err != nil {
+		return types.Scroll{}, err
+	}
+	return scroll, nil
+}
+
+// upgradeScrollDoc upgrades a decoded scroll document of any supported
+// schema version to the current shape, returning it re-encoded with its
+// schema_version set to types.CurrentScrollSchemaVersion and its trigger, if
+// any, reconciled under precedence. Keys it does not know are kept.
+func upgradeScrollDoc(doc map[string]json.RawMessage, precedence string) ([]byte, error) {
+	if doc == nil {
+		doc = map[string]json.RawMessage{}
+	}
+	version := 1
+	if v, ok := doc["schema_version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, &types.ValidationError{Field: "schema_version", Message: "must be an integer"}
+		}
+		if version == 0 {
+			version = 1
+		}
+	}
+	if version < 1 || version > types.CurrentScrollSchemaVersion {
+		return nil, &types.ValidationError{
+			Field:   "schema_version",
+			Message: fmt.Sprintf("unsupported version %d (this server reads 1 to %d)", version, types.CurrentScrollSchemaVersion),
+		}
+	}
+
+	for v := version; v < types.CurrentScrollSchemaVersion; v++ {
+		if err := scrollUpgrades[v](doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := normalizeFlare(doc, precedence); err != nil {
+		return nil, err
+	}
+	doc["schema_version"] = json.RawMessage(strconv.Itoa(types.CurrentScrollSchemaVersion))
+	return json.Marshal(doc)
+}
+
+// normalizeFlare reconciles a scroll document's trigger ("flare" or
+// "memory"), the version 1 way of flagging a flare still accepted in every
+// version, with is_flare_event, then drops the trigger: is_flare_event is
+// the scroll's one record of whether it is a flare, and its trigger is
+// derived from it wherever one is reported.
+//
+// When only one is set, it decides. When both are and they agree, nothing
+// changes. When they contradict, precedence decides: FlareReject fails with
+// a validation error on trigger, FlareIsFlareEvent keeps is_flare_event,
+// and FlareTrigger overwrites it. An is_flare_event that is not a boolean
+// is left for schema validation to report.
+func normalizeFlare(doc map[string]json.RawMessage, precedence string) error {
+	raw, ok := doc["trigger"]
+	if !ok {
+		return nil
+	}
+	var trigger string
+	if err := json.Unmarshal(raw, &trigger); err != nil {
+		return &types.ValidationError{Field: "trigger", Message: "must be a string"}
+	}
+	var flare bool
+	switch trigger {
+	case TriggerFlare:
+		flare = true
+	case TriggerMemory:
+	default:
+		return &types.ValidationError{Field: "trigger", Message: fmt.Sprintf("must be %q or %q", TriggerFlare, TriggerMemory)}
+	}
+	delete(doc, "trigger")
+
+	var isFlare *bool
+	if v, ok := doc["is_flare_event"]; ok {
+		if err := json.Unmarshal(v, &isFlare); err != nil {
+			return nil
+		}
+	}
+	switch {
+	case isFlare == nil, *isFlare == flare:
+	case precedence == FlareIsFlareEvent:
+		return nil
+	case precedence == FlareTrigger:
+	default:
+		return &types.ValidationError{
+			Field:   "trigger",
+			Message: fmt.Sprintf("trigger %q contradicts is_flare_event %t", trigger, *isFlare),
+		}
+	}
+	doc["is_flare_event"] = json.RawMessage(strconv.FormatBool(flare))
+	return nil
+}
+
+func validateFlarePrecedence(precedence string) error {
+	switch precedence {
+	case FlareReject, FlareIsFlareEvent, FlareTrigger:
+		return nil
+	}
+	return &ConfigError{Key: "flare_precedence", Message: fmt.Sprintf("must be %q, %q, or %q", FlareReject, FlareIsFlareEvent, FlareTrigger)}
+}
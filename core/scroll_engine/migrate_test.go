@@ -0,0 +1,122 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestMigrateScroll_Versions(t *testing.T) {
+	want := types.Scroll{
+		SchemaVersion:  types.CurrentScrollSchemaVersion,
+		ID:             "s1",
+		TrustScore:     0.8,
+		IsFlareEvent:   true,
+		GeneticMarkers: []string{"NOD2"},
+	}
+	for name, raw := range map[string]string{
+		"v1 implicit": `{"id":"s1","trust_score":0.8,"trigger":"flare","genetic_markers":["NOD2"]}`,
+		"v1 explicit": `{"schema_version":1,"id":"s1","trust_score":0.8,"trigger":"flare","genetic_markers":["NOD2"]}`,
+		"v2":          `{"schema_version":2,"id":"s1","trust_score":0.8,"is_flare_event":true,"genetic_markers":["NOD2"]}`,
+	} {
+		got, err := MigrateScroll(json.RawMessage(raw))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+
+	memory, err := MigrateScroll(json.RawMessage(`{"id":"m","trust_score":0.8,"trigger":"memory"}`))
+	if err != nil || memory.IsFlareEvent {
+		t.Fatalf("a v1 memory trigger should not be a flare event: %+v (%v)", memory, err)
+	}
+}
+
+func TestNormalizeFlare(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc, precedence string
+		flare                 bool
+		err                   bool
+	}{
+		{"only trigger", `{"trigger":"flare"}`, FlareReject, true, false},
+		{"only is_flare_event", `{"is_flare_event":true}`, FlareReject, true, false},
+		{"neither", `{}`, FlareReject, false, false},
+		{"null is_flare_event", `{"trigger":"flare","is_flare_event":null}`, FlareReject, true, false},
+		{"agree on flare", `{"trigger":"flare","is_flare_event":true}`, FlareReject, true, false},
+		{"agree on memory", `{"trigger":"memory","is_flare_event":false}`, FlareReject, false, false},
+		{"contradict rejected", `{"trigger":"memory","is_flare_event":true}`, FlareReject, false, true},
+		{"contradict rejected in v2", `{"schema_version":2,"trigger":"flare","is_flare_event":false}`, FlareReject, false, true},
+		{"is_flare_event wins", `{"trigger":"memory","is_flare_event":true}`, FlareIsFlareEvent, true, false},
+		{"trigger wins", `{"trigger":"memory","is_flare_event":true}`, FlareTrigger, false, false},
+	} {
+		var doc map[string]json.RawMessage
+		_ = json.Unmarshal([]byte(tc.doc), &doc)
+		upgraded, err := upgradeScrollDoc(doc, tc.precedence)
+		if tc.err {
+			var vErr *types.ValidationError
+			if !errors.As(err, &vErr) || vErr.Field != "trigger" {
+				t.Fatalf("%s: expected a trigger validation error, got %v", tc.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var scroll types.Scroll
+		_ = json.Unmarshal(upgraded, &scroll)
+		if scroll.IsFlareEvent != tc.flare {
+			t.Fatalf("%s: expected is_flare_event %t, got %t", tc.name, tc.flare, scroll.IsFlareEvent)
+		}
+		if strings.Contains(string(upgraded), "trigger") {
+			t.Fatalf("%s: expected the trigger dropped, got %s", tc.name, upgraded)
+		}
+	}
+}
+
+func TestSimulate_RejectsContradictoryTrigger(t *testing.T) {
+	body := `{"id":"c","trust_score":0.9,"trigger":"memory","is_flare_event":true,"genetic_markers":["NOD2"]}`
+	rec := postSimulate(NewServer(DefaultConfig()).Handler(), body, "")
+	if e := decodeErrorResponse(t, rec); rec.Code != http.StatusBadRequest || e.Code != CodeInvalidScroll || e.Field != "trigger" {
+		t.Fatalf("expected a 400 on trigger, got %d %+v", rec.Code, e)
+	}
+
+	cfg := DefaultConfig()
+	cfg.FlarePrecedence = FlareIsFlareEvent
+	rec = postSimulate(NewServer(cfg).Handler(), body, "")
+	var plan types.GeneInterventionPlan
+	_ = json.Unmarshal(rec.Body.Bytes(), &plan)
+	if rec.Code != http.StatusOK || plan.Branch != BranchFlare {
+		t.Fatalf("expected is_flare_event to win under its precedence, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMigrateScroll_Rejects(t *testing.T) {
+	for raw, field := range map[string]string{
+		`{"schema_version":3,"id":"s"}`:   "schema_version",
+		`{"schema_version":-1,"id":"s"}`:  "schema_version",
+		`{"id":"s","trigger":"sunburst"}`: "trigger",
+	} {
+		_, err := MigrateScroll(json.RawMessage(raw))
+		var vErr *types.ValidationError
+		if !errors.As(err, &vErr) || vErr.Field != field {
+			t.Fatalf("%s: expected a %s validation error, got %v", raw, field, err)
+		}
+	}
+}
+
+func TestSimulate_MigratesV1Scroll(t *testing.T) {
+	rec := postSimulate(NewServer(DefaultConfig()).Handler(),
+		`{"id":"old","trust_score":0.9,"trigger":"flare","genetic_markers":["NOD2"]}`, "")
+	var plan types.GeneInterventionPlan
+	_ = json.Unmarshal(rec.Body.Bytes(), &plan)
+	if rec.Code != http.StatusOK || plan.Branch != BranchFlare {
+		t.Fatalf("expected a v1 flare trigger to reach the flare branch, got %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,227 @@
+package scroll_engine
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// LoopState is a stage in a mutation loop's lifecycle.
+type LoopState string
+
+const (
+	LoopInitiated LoopState = "initiated"
+	LoopRunning   LoopState = "running"
+	LoopConverged LoopState = "converged"
+	LoopDiverged  LoopState = "diverged"
+	LoopClosed    LoopState = "closed"
+)
+
+// ErrIllegalTransition is returned when a loop is asked to move to a state
+// its current state cannot reach.
+var ErrIllegalTransition = errors.New("illegal mutation loop transition")
+
+// MutationLoop tracks the lifecycle of a single mutation loop:
+//
+//	initiated -> running -> converged | diverged -> closed
+type MutationLoop struct {
+	ID    string    `json:"id"`
+	State LoopState `json:"state"`
+	// Tenant and ScrollID name the scroll whose plan started the loop.
+	Tenant         string    `json:"tenant,omitempty"`
+	ScrollID       string    `json:"scroll_id,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	TransitionedAt time.Time `json:"transitioned_at"`
+}
+
+// NewMutationLoop returns a loop in the initiated state.
+func NewMutationLoop(id string) *MutationLoop {
+	now := time.Now().UTC()
+	return &MutationLoop{ID: id, State: LoopInitiated, CreatedAt: now, TransitionedAt: now}
+}
+
+// Valid reports whether s is a known loop state.
+func (s LoopState) Valid() bool {
+	switch s {
+	case LoopInitiated, LoopRunning, LoopConverged, LoopDiverged, LoopClosed:
+		return true
+	}
+	return false
+}
+
+// Advance moves the loop one step along the happy path: initiated starts
+// running, running converges, and a converged or diverged loop closes.
+func (l *MutationLoop) Advance() error {
+	switch l.State {
+	case LoopInitiated:
+		return l.transition(LoopRunning)
+	case LoopRunning:
+		return l.transition(LoopConverged)
+	case LoopConverged, LoopDiverged:
+		return l.transition(LoopClosed)
+	}
+	return l.illegal(l.State)
+}
+
+// Abort marks a loop that has not yet settled as diverged.
+func (l *MutationLoop) Abort() error {
+	switch l.State {
+	case LoopInitiated, LoopRunning:
+		return l.transition(LoopDiverged)
+	}
+	return l.illegal(LoopDiverged)
+}
+
+func (l *MutationLoop) transition(to LoopState) error {
+	l.State = to
+	l.TransitionedAt = time.Now().UTC()
+	return nil
+}
+
+func (l *MutationLoop) illegal(to LoopState) error {
+	return fmt.Errorf("%w: %s -> %s", ErrIllegalTransition, l.State, to)
+}
+
+// LoopRegistry stores mutation loops keyed by MutationLoopID. A loop is
+// kept for the registry's retention after its last transition; closed loops
+// and loops past their retention are dropped by a sweep that runs, at most
+// once per retention, as new loops start. A zero retention keeps every loop.
+type LoopRegistry struct {
+	mu        sync.RWMutex
+	retention time.Duration
+	now       func() time.Time
+	loops     map[string]*MutationLoop
+	nextSweep time.Time
+}
+
+func NewLoopRegistry(retention time.Duration) *LoopRegistry {
+	return &LoopRegistry{
+		retention: retention,
+		now:       time.Now,
+		loops:     make(map[string]*MutationLoop),
+	}
+}
+
+// Start registers a fresh initiated loop under id.
+func (r *LoopRegistry) Start(id string) MutationLoop {
+	return r.StartFor("", "", id)
+}
+
+// StartFor registers a fresh initiated loop under id, started by tenant's
+// scroll scrollID.
+func (r *LoopRegistry) StartFor(tenant, scrollID, id string) MutationLoop {
+	loop := NewMutationLoop(id)
+	loop.Tenant, loop.ScrollID = tenant, scrollID
+	r.mu.Lock()
+	r.sweep()
+	r.loops[id] = loop
+	r.mu.Unlock()
+	return *loop
+}
+
+// sweep drops closed loops and loops idle past the retention. The caller
+// must hold r.mu for writing.
+func (r *LoopRegistry) sweep() {
+	if r.retention <= 0 {
+		return
+	}
+	now := r.now()
+	if now.Before(r.nextSweep) {
+		return
+	}
+	r.nextSweep = now.Add(r.retention)
+	cutoff := now.Add(-r.retention)
+	for id, l := range r.loops {
+		if l.State == LoopClosed || l.TransitionedAt.Before(cutoff) {
+			delete(r.loops, id)
+		}
+	}
+}
+
+// Get returns a snapshot of the loop stored under id.
+func (r *LoopRegistry) Get(id string) (MutationLoop, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	loop, ok := r.loops[id]
+	if !ok {
+		return MutationLoop{}, false
+	}
+	return *loop, true
+}
+
+// LoopQuery selects a page of loops. Empty fields match every loop; a zero
+// Limit means no limit.
+type LoopQuery struct {
+	Tenant   string
+	ScrollID string
+	State    LoopState
+	Limit    int
+	Offset   int
+}
+
+func (q LoopQuery) matches(l *MutationLoop) bool {
+	return (q.Tenant == "" || l.Tenant == q.Tenant) &&
+		(q.ScrollID == "" || l.ScrollID == q.ScrollID) &&
+		(q.State == "" || l.State == q.State)
+}
+
+// List returns snapshots of the loops matching q, most recently
+// transitioned first, and how many matched before paging.
+func (r *LoopRegistry) List(q LoopQuery) ([]MutationLoop, int) {
+	r.mu.RLock()
+	out := []MutationLoop{}
+	for _, l := range r.loops {
+		if q.matches(l) {
+			out = append(out, *l)
+		}
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(out, func(a, b MutationLoop) int {
+		if c := b.TransitionedAt.Compare(a.TransitionedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	total := len(out)
+	if q.Offset >= len(out) {
+		return []MutationLoop{}, total
+	}
+	out = out[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(out) {
+		out = out[:q.Limit]
+	}
+	return out, total
+}
+
+// Advance applies MutationLoop.Advance to the stored loop.
+func (r *LoopRegistry) Advance(id string) (MutationLoop, error) {
+	return r.apply(id, (*MutationLoop).Advance)
+}
+
+// Abort applies MutationLoop.Abort to the stored loop.
+func (r *LoopRegistry) Abort(id string) (MutationLoop, error) {
+	return r.apply(id, (*MutationLoop).Abort)
+}
+
+// Settle ends a running loop: it converges if ok and diverges otherwise.
+func (r *LoopRegistry) Settle(id string, ok bool) (MutationLoop, error) {
+	if ok {
+		return r.Advance(id)
+	}
+	return r.Abort(id)
+}
+
+func (r *LoopRegistry) apply(id string, step func(*MutationLoop) error) (MutationLoop, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	loop, ok := r.loops[id]
+	if !ok {
+		return MutationLoop{}, fmt.Errorf("mutation loop %q not found", id)
+	}
+	err := step(loop)
+	return *loop, err
+}
@@ -0,0 +1,256 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestMutationLoop_HappyPath(t *testing.T) {
+	loop := NewMutationLoop("loop_1")
+
+	for _, want := range []LoopState{LoopRunning, LoopConverged, LoopClosed} {
+		if err := loop.Advance(); err != nil {
+			t.Fatalf("advance to %s: %v", want, err)
+		}
+		if loop.State != want {
+			t.Fatalf("expected %s, got %s", want, loop.State)
+		}
+	}
+}
+
+func TestMutationLoop_AbortThenClose(t *testing.T) {
+	loop := NewMutationLoop("loop_1")
+	_ = loop.Advance()
+
+	if err := loop.Abort(); err != nil {
+		t.Fatalf("abort running loop: %v", err)
+	}
+	if loop.State != LoopDiverged {
+		t.Fatalf("expected diverged, got %s", loop.State)
+	}
+	if err := loop.Advance(); err != nil || loop.State != LoopClosed {
+		t.Fatalf("expected diverged loop to close, got %s (%v)", loop.State, err)
+	}
+}
+
+func TestMutationLoop_IllegalTransitionsRejected(t *testing.T) {
+	closed := &MutationLoop{ID: "c", State: LoopClosed}
+	if err := closed.Advance(); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("expected ErrIllegalTransition advancing closed loop, got %v", err)
+	}
+	if err := closed.Abort(); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("expected ErrIllegalTransition aborting closed loop, got %v", err)
+	}
+
+	converged := &MutationLoop{ID: "v", State: LoopConverged}
+	if err := converged.Abort(); !errors.Is(err, ErrIllegalTransition) {
+		t.Fatalf("expected ErrIllegalTransition aborting converged loop, got %v", err)
+	}
+	if converged.State != LoopConverged {
+		t.Fatalf("rejected transition must not change state, got %s", converged.State)
+	}
+}
+
+func TestLoopHandler(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	srv.loops.StartFor(testTenant, "s1", "loop_1")
+	if _, err := srv.loops.Advance("loop_1"); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, newTenantRequest(http.MethodGet, "/loops/loop_1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var loop MutationLoop
+	if err := json.NewDecoder(rec.Body).Decode(&loop); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if loop.State != LoopRunning {
+		t.Fatalf("expected running, got %s", loop.State)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, newTenantRequest(http.MethodGet, "/loops/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown loop, got %d", rec.Code)
+	}
+}
+
+func TestLoopHandler_ScopedToTenant(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	srv.loops.StartFor("other", "s1", "loop_1")
+	h := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/loops/loop_1", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for another tenant's loop, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/loops/loop_1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a tenant, got %d", rec.Code)
+	}
+}
+
+func listLoops(t *testing.T, h http.Handler, query string) LoopPage {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/loops"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /loops%s: expected 200, got %d: %s", query, rec.Code, rec.Body)
+	}
+	var page LoopPage
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return page
+}
+
+func TestListLoops_FiltersByState(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	for id, steps := range map[string]func(string){
+		"initiated": func(string) {},
+		"running-1": func(id string) { _, _ = srv.loops.Advance(id) },
+		"running-2": func(id string) { _, _ = srv.loops.Advance(id) },
+		"converged": func(id string) { _, _ = srv.loops.Advance(id); _, _ = srv.loops.Advance(id) },
+		"diverged":  func(id string) { _, _ = srv.loops.Abort(id) },
+	} {
+		srv.loops.StartFor(testTenant, "scroll-"+id, id)
+		steps(id)
+	}
+	srv.loops.StartFor("other", "scroll-x", "elsewhere")
+	_, _ = srv.loops.Advance("elsewhere")
+	h := srv.Handler()
+
+	all := listLoops(t, h, "")
+	if all.Total != 5 || len(all.Loops) != 5 {
+		t.Fatalf("expected the tenant's 5 loops, got %d", all.Total)
+	}
+	for i := 1; i < len(all.Loops); i++ {
+		if all.Loops[i].TransitionedAt.After(all.Loops[i-1].TransitionedAt) {
+			t.Fatalf("expected most recently transitioned first, got %+v", all.Loops)
+		}
+	}
+
+	running := listLoops(t, h, "?state=running")
+	if running.Total != 2 {
+		t.Fatalf("expected 2 running loops, got %+v", running.Loops)
+	}
+	for _, l := range running.Loops {
+		if l.State != LoopRunning || l.CreatedAt.IsZero() || l.TransitionedAt.Before(l.CreatedAt) {
+			t.Fatalf("unexpected running loop %+v", l)
+		}
+	}
+	if page := listLoops(t, h, "?state=running&limit=1&offset=1"); page.Total != 2 || len(page.Loops) != 1 {
+		t.Fatalf("expected one loop on the second page of two, got %+v", page)
+	}
+	if page := listLoops(t, h, "?state=diverged&scroll_id=scroll-diverged"); page.Total != 1 || page.Loops[0].ID != "diverged" {
+		t.Fatalf("expected the diverged loop by its scroll, got %+v", page)
+	}
+	if page := listLoops(t, h, "?state=running&scroll_id=scroll-diverged"); page.Total != 0 {
+		t.Fatalf("expected no match combining filters, got %+v", page)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/loops?state=spinning", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown state, got %d", rec.Code)
+	}
+}
+
+func TestListLoops_RecordsOriginatingScroll(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+	plan := decodePlan(t, postSimulate(h, `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`, ""))
+
+	page := listLoops(t, h, "?scroll_id=s1")
+	if page.Total != 1 || page.Loops[0].ID != plan.MutationLoopID || page.Loops[0].State != LoopConverged {
+		t.Fatalf("expected the plan's loop listed for s1, got %+v", page)
+	}
+}
+
+func TestLoopRegistry_SweepsClosedAndStaleLoops(t *testing.T) {
+	r := NewLoopRegistry(time.Minute)
+	now := time.Now()
+	r.now = func() time.Time { return now.Add(-time.Minute) }
+	r.StartFor(testTenant, "s1", "open")
+	r.StartFor(testTenant, "s2", "closed")
+	for range 3 {
+		_, _ = r.Advance("closed")
+	}
+
+	r.now = func() time.Time { return now.Add(time.Second) }
+	r.StartFor(testTenant, "s3", "next")
+	if _, ok := r.Get("closed"); ok {
+		t.Fatalf("expected the closed loop swept")
+	}
+	if _, ok := r.Get("open"); !ok {
+		t.Fatalf("expected a loop within its retention kept")
+	}
+
+	r.now = func() time.Time { return now.Add(2 * time.Minute) }
+	r.StartFor(testTenant, "s4", "last")
+	if _, total := r.List(LoopQuery{}); total != 1 {
+		t.Fatalf("expected only the newest loop left after retention, got %d", total)
+	}
+}
+
+// gatedStore holds SaveScroll until release is closed, announcing each
+// call on entered.
+type gatedStore struct {
+	*MemoryStore
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s gatedStore) SaveScroll(tenant string, scroll types.Scroll) error {
+	s.entered <- struct{}{}
+	<-s.release
+	return s.MemoryStore.SaveScroll(tenant, scroll)
+}
+
+func TestListLoops_FollowsPlanLifecycle(t *testing.T) {
+	store := gatedStore{MemoryStore: NewMemoryStore(), entered: make(chan struct{}), release: make(chan struct{})}
+	h := NewServerWithStore(DefaultConfig(), store).Handler()
+	body := `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postSimulate(h, body, "") }()
+	<-store.entered
+	if page := listLoops(t, h, "?state=running"); page.Total != 1 || page.Loops[0].ScrollID != "s1" {
+		t.Fatalf("expected s1's loop running while its plan persists, got %+v", page)
+	}
+	close(store.release)
+	if rec := <-done; rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if page := listLoops(t, h, "?state=running"); page.Total != 0 {
+		t.Fatalf("expected no loop left running, got %+v", page)
+	}
+	if page := listLoops(t, h, "?state=converged"); page.Total != 1 || page.Loops[0].ScrollID != "s1" {
+		t.Fatalf("expected s1's loop converged once persisted, got %+v", page)
+	}
+
+	cfg := DefaultConfig()
+	cfg.StoreFailure = StoreFailOpen
+	h = NewServerWithStore(cfg, downStore{NewMemoryStore()}).Handler()
+	if rec := postSimulate(h, body, ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected the plan returned unpersisted, got %d", rec.Code)
+	}
+	if page := listLoops(t, h, "?state=diverged"); page.Total != 1 || page.Loops[0].ScrollID != "s1" {
+		t.Fatalf("expected s1's loop diverged when its plan was not persisted, got %+v", page)
+	}
+	if page := listLoops(t, h, "?state=converged"); page.Total != 0 {
+		t.Fatalf("expected no converged loop, got %+v", page)
+	}
+}
@@ -0,0 +1,80 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Response media types a client may negotiate with Accept.
+const (
+	jsonContentType = "application/json"
+	xmlContentType  = "application/xml"
+)
+
+// negotiate picks the response media type for r from its Accept header:
+// JSON unless the client prefers XML. Wildcards select JSON, as does a
+// missing header. It returns false when the client accepts neither.
+func negotiate(r *http.Request) (string, bool) {
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return jsonContentType, true
+	}
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		var offer string
+		switch mt {
+		case jsonContentType, "*/*", "application/*":
+			offer = jsonContentType
+		case xmlContentType, "text/xml":
+			offer = xmlContentType
+		default:
+			continue
+		}
+		// On a tie the first listed wins, so ties resolve as the client
+		// ordered them.
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, best != ""
+}
+
+// negotiateOr406 is negotiate writing a 406 when nothing is acceptable.
+// Error responses are always JSON.
+func negotiateOr406(w http.ResponseWriter, r *http.Request) (string, bool) {
+	mt, ok := negotiate(r)
+	if !ok {
+		writeError(w, http.StatusNotAcceptable, CodeNotAcceptable,
+			"supported response types are "+jsonContentType+" and "+xmlContentType, "")
+	}
+	return mt, ok
+}
+
+// writeNegotiated writes v as mt, which negotiate chose. XML documents are
+// rooted at an element named root.
+func writeNegotiated(w http.ResponseWriter, mt, root string, status int, v any) {
+	w.Header().Set("Content-Type", mt)
+	w.WriteHeader(status)
+	if mt == xmlContentType {
+		_, _ = w.Write([]byte(xml.Header))
+		enc := xml.NewEncoder(w)
+		_ = enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: root}})
+		_, _ = w.Write([]byte("\n"))
+		return
+	}
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,126 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func simulateAccepting(h http.Handler, accept, key string) *httptest.ResponseRecorder {
+	req := newTenantRequest(http.MethodPost, "/simulate",
+		strings.NewReader(`{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2","IL23R"]}`))
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestNegotiate(t *testing.T) {
+	for _, tc := range []struct {
+		accept, want string
+		ok           bool
+	}{
+		{"", jsonContentType, true},
+		{"*/*", jsonContentType, true},
+		{"application/json", jsonContentType, true},
+		{"application/xml", xmlContentType, true},
+		{"text/xml", xmlContentType, true},
+		{"application/json;q=0.5, application/xml", xmlContentType, true},
+		{"text/html, application/*;q=0.1", jsonContentType, true},
+		{"text/html", "", false},
+		{"application/xml;q=0", "", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tc.accept)
+		if got, ok := negotiate(r); got != tc.want || ok != tc.ok {
+			t.Errorf("negotiate(%q) = %q, %v; want %q, %v", tc.accept, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestSimulate_NegotiatesJSON(t *testing.T) {
+	rec := simulateAccepting(NewServer(DefaultConfig()).Handler(), "application/json", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != jsonContentType {
+		t.Fatalf("expected a JSON plan, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	var plan types.GeneInterventionPlan
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); err != nil || plan.Branch != BranchFlare {
+		t.Fatalf("unexpected body %s (%v)", rec.Body, err)
+	}
+}
+
+func TestSimulate_NegotiatesXML(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	for _, key := range []string{"k1", "k1"} { // the second is an idempotent replay
+		rec := simulateAccepting(h, "application/xml", key)
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != xmlContentType {
+			t.Fatalf("expected an XML plan, got %d %q: %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body)
+		}
+		body := rec.Body.String()
+		for _, want := range []string{
+			"<plan>", "<branch>flare</branch>",
+			"<targeted_genes><gene>IL23R</gene><gene>NOD2</gene></targeted_genes>",
+			`<reason kind="trust_threshold" passed="true">`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Fatalf("expected %s in XML body:\n%s", want, body)
+			}
+		}
+		var plan struct {
+			Branch   string    `xml:"branch"`
+			Genes    []string  `xml:"targeted_genes>gene"`
+			ReliefCI []float64 `xml:"relief_ci>bound"`
+		}
+		if err := xml.Unmarshal(rec.Body.Bytes(), &plan); err != nil || plan.Branch != BranchFlare || len(plan.Genes) != 2 || len(plan.ReliefCI) != 2 {
+			t.Fatalf("expected well-formed plan XML, got %+v (%v)", plan, err)
+		}
+	}
+}
+
+func TestSimulate_NotAcceptable(t *testing.T) {
+	rec := simulateAccepting(NewServer(DefaultConfig()).Handler(), "text/html", "")
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected 406, got %d", rec.Code)
+	}
+	if e := decodeErrorResponse(t, rec); e.Code != CodeNotAcceptable {
+		t.Fatalf("unexpected error body: %+v", e)
+	}
+}
+
+func TestGetScroll_NegotiatesXML(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	simulateAccepting(h, "", "")
+
+	req := newTenantRequest(http.MethodGet, "/scrolls/s1", nil)
+	req.Header.Set("Accept", "application/xml")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != xmlContentType {
+		t.Fatalf("expected an XML scroll, got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), "<genetic_markers><marker>NOD2</marker><marker>IL23R</marker></genetic_markers>") {
+		t.Fatalf("unexpected XML body:\n%s", rec.Body)
+	}
+	var scroll types.Scroll
+	if err := xml.Unmarshal(rec.Body.Bytes(), &scroll); err != nil || scroll.ID != "s1" || scroll.TrustScore != 0.9 {
+		t.Fatalf("expected the XML to round-trip, got %+v (%v)", scroll, err)
+	}
+
+	req = newTenantRequest(http.MethodGet, "/scrolls/s1", nil)
+	req.Header.Set("Accept", "image/png")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected 406, got %d", rec.Code)
+	}
+}
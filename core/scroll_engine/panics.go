@@ -0,0 +1,7 @@
+//go:build !propagate_panics
+
+package scroll_engine
+
+// propagatePanics is false in ordinary builds: recoverPanics answers a
+// panicking request with a 500 and keeps serving.
+const propagatePanics = false
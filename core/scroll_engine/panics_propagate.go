@@ -0,0 +1,7 @@
+//go:build propagate_panics
+
+package scroll_engine
+
+// propagatePanics is true in builds tagged propagate_panics (for example
+// go test -tags propagate_panics), so recoverPanics re-raises every panic.
+const propagatePanics = true
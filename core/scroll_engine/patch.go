@@ -0,0 +1,123 @@
+package scroll_engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// requiredScrollFields may be patched but not cleared with null.
+var requiredScrollFields = []string{"id", "trust_score", "is_flare_event"}
+
+// PatchResponse is the scroll a PATCH produced and the plan re-simulated
+// from it.
+type PatchResponse struct {
+	Scroll types.Scroll               `json:"scroll"`
+	Plan   types.GeneInterventionPlan `json:"plan"`
+}
+
+// mergePatch applies an RFC 7386 merge patch to target: objects merge key
+// by key, null removes a key, and anything else replaces the value whole.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergePatch(t[k], v)
+	}
+	return t
+}
+
+// patchScrollHandler applies a JSON merge patch to a stored scroll, then
+// validates, re-simulates, and stores it as a new version with its new plan,
+// exactly as /simulate would. The patched scroll must pass the same checks a
+// submitted one does, including its signature when scrolls are signed;
+// failing them is a 422, since the patch itself was well-formed.
+func (s *Server) patchScrollHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	id := r.PathValue("id")
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	var patch map[string]any
+	if err := decodeBody(bytes.NewReader(raw), &patch); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if patch == nil {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "patch must be a JSON object", "")
+		return
+	}
+	for _, f := range requiredScrollFields {
+		if v, ok := patch[f]; ok && v == nil {
+			writeError(w, http.StatusUnprocessableEntity, CodeInvalidScroll, "cannot be cleared", f)
+			return
+		}
+	}
+	if v, ok := patch["id"]; ok && v != id {
+		writeError(w, http.StatusUnprocessableEntity, CodeInvalidScroll, "cannot be changed", "id")
+		return
+	}
+
+	stored, err := s.store.GetScroll(tenant, id)
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "scroll not found", "id")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+	if stored.Frozen {
+		persistError(ErrFrozen).write(w)
+		return
+	}
+	var doc any
+	current, _ := json.Marshal(stored)
+	_ = json.Unmarshal(current, &doc)
+	merged, err := json.Marshal(mergePatch(doc, patch))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	req, rerr := s.parseSimulateRequest(merged, tenant)
+	if rerr != nil {
+		if rerr.status == http.StatusBadRequest {
+			rerr.status = http.StatusUnprocessableEntity
+		}
+		rerr.write(w)
+		return
+	}
+	req.Version = max(stored.Version, 1) + 1
+
+	plan, err := s.simulate(r.Context(), req)
+	if err != nil {
+		writeSimulationError(w, err)
+		return
+	}
+	if err := s.persist(r.Context(), tenant, req.Scroll, plan); err != nil {
+		persistError(err).write(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(PatchResponse{Scroll: req.Scroll, Plan: plan})
+}
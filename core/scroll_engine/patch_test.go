@@ -0,0 +1,98 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func patchScroll(h http.Handler, id, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := newTenantRequest(http.MethodPatch, "/scrolls/"+id, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodePatch(t *testing.T, rec *httptest.ResponseRecorder) PatchResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp PatchResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp
+}
+
+func TestPatchScroll_UpdatesTrust(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+	before := decodePlan(t, postSimulate(h, `{"id":"s","trust_score":0.2,"is_flare_event":true,"genetic_markers":["NOD2"],"tags":["cohort-a"]}`, ""))
+	if before.Branch != BranchCompost {
+		t.Fatalf("expected low trust to compost, got %s", before.Branch)
+	}
+
+	resp := decodePatch(t, patchScroll(h, "s", `{"trust_score":0.9}`))
+	if resp.Scroll.TrustScore != 0.9 || resp.Scroll.Version != 2 {
+		t.Fatalf("expected trust 0.9 at version 2, got %+v", resp.Scroll)
+	}
+	if !slices.Equal(resp.Scroll.GeneticMarkers, []string{"NOD2"}) || !slices.Equal(resp.Scroll.Tags, []string{"cohort-a"}) || !resp.Scroll.IsFlareEvent {
+		t.Fatalf("expected unpatched fields unchanged, got %+v", resp.Scroll)
+	}
+	if resp.Plan.Branch != BranchFlare {
+		t.Fatalf("expected the re-simulated plan to flare, got %s", resp.Plan.Branch)
+	}
+	stored, _ := srv.store.GetScroll(testTenant, "s")
+	plan, _ := srv.store.GetPlan(testTenant, "s")
+	if stored.TrustScore != 0.9 || stored.Version != 2 || plan.MutationLoopID != resp.Plan.MutationLoopID {
+		t.Fatalf("expected the patch and new plan stored, got %+v / %+v", stored, plan)
+	}
+
+	if again := decodePatch(t, patchScroll(h, "s", `{"tags":null}`)); again.Scroll.Version != 3 || again.Scroll.Tags != nil {
+		t.Fatalf("expected null to clear tags at version 3, got %+v", again.Scroll)
+	}
+}
+
+func TestPatchScroll_AddsMarker(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	decodePlan(t, postSimulate(h, `{"id":"s","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`, ""))
+
+	resp := decodePatch(t, patchScroll(h, "s", `{"genetic_markers":["NOD2","IL23R"]}`))
+	if !slices.Equal(resp.Scroll.GeneticMarkers, []string{"NOD2", "IL23R"}) {
+		t.Fatalf("expected the marker added, got %v", resp.Scroll.GeneticMarkers)
+	}
+	if !slices.Contains(resp.Plan.TargetedGenes, "IL23R") {
+		t.Fatalf("expected the plan to target the new marker, got %v", resp.Plan.TargetedGenes)
+	}
+}
+
+func TestPatchScroll_Errors(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	decodePlan(t, postSimulate(h, `{"id":"s","trust_score":0.5}`, ""))
+
+	for _, tc := range []struct {
+		name, id, body string
+		status         int
+		field          string
+	}{
+		{"unknown id", "nope", `{"trust_score":0.9}`, http.StatusNotFound, "id"},
+		{"out of range", "s", `{"trust_score":1.5}`, http.StatusUnprocessableEntity, "trust_score"},
+		{"wrong type", "s", `{"trust_score":"high"}`, http.StatusUnprocessableEntity, "trust_score"},
+		{"cleared required", "s", `{"trust_score":null}`, http.StatusUnprocessableEntity, "trust_score"},
+		{"changed id", "s", `{"id":"t"}`, http.StatusUnprocessableEntity, "id"},
+		{"not an object", "s", `[1]`, http.StatusBadRequest, ""},
+	} {
+		rec := patchScroll(h, tc.id, tc.body)
+		if rec.Code != tc.status {
+			t.Fatalf("%s: expected %d, got %d: %s", tc.name, tc.status, rec.Code, rec.Body)
+		}
+		if e := decodeErrorResponse(t, rec); e.Field != tc.field {
+			t.Fatalf("%s: expected field %q, got %+v", tc.name, tc.field, e)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package scroll_engine
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// scrollContentHash hashes scroll's canonical content, so identical content
+// submitted under different IDs, or with its markers in another order,
+// shares a cache entry.
+func scrollContentHash(scroll types.Scroll) ([sha256.Size]byte, error) {
+	payload, err := canonicalContentJSON(scroll)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(payload), nil
+}
+
+type planCacheEntry struct {
+	key  [sha256.Size]byte
+	plan types.GeneInterventionPlan
+}
+
+// planCache is a fixed-size LRU of plans keyed by scroll content hash.
+// Entries never expire; the least recently used entry is evicted when full.
+type planCache struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[[sha256.Size]byte]*list.Element
+}
+
+func newPlanCache(size int) *planCache {
+	return &planCache{
+		size:  size,
+		order: list.New(),
+		items: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+func (c *planCache) get(key [sha256.Size]byte) (types.GeneInterventionPlan, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return types.GeneInterventionPlan{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*planCacheEntry).plan, true
+}
+
+func (c *planCache) put(key [sha256.Size]byte, plan types.GeneInterventionPlan) {
+	if c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*planCacheEntry).plan = plan
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&planCacheEntry{key: key, plan: plan})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*planCacheEntry).key)
+	}
+}
+
+func (c *planCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
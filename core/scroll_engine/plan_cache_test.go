@@ -0,0 +1,127 @@
+package scroll_engine
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestServerSimulate_ChangedMarkerMissesCache(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	ctx := context.Background()
+	scroll := types.Scroll{ID: "a", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"g1"}}
+
+	_, _ = srv.simulate(ctx, simulateRequest{Scroll: scroll})
+	scroll.ID = "b"
+	_, _ = srv.simulate(ctx, simulateRequest{Scroll: scroll})
+	if hits := srv.metrics.Counter("plan_cache_hits_total"); hits != 1 {
+		t.Fatalf("expected identical content under a new ID to hit the cache, got %v hits", hits)
+	}
+
+	scroll.GeneticMarkers = []string{"g2"}
+	if _, err := srv.simulate(ctx, simulateRequest{Scroll: scroll}); err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+
+	if hits := srv.metrics.Counter("plan_cache_hits_total"); hits != 1 {
+		t.Fatalf("expected 1 hit, got %v", hits)
+	}
+	if misses := srv.metrics.Counter("plan_cache_misses_total"); misses != 2 {
+		t.Fatalf("expected 2 misses, got %v", misses)
+	}
+}
+
+func TestServerSimulate_CacheHitStartsFreshLoop(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LoopIDs = &CounterLoopIDGenerator{}
+	srv := NewServer(cfg)
+	ctx := context.Background()
+	scroll := types.Scroll{ID: "a", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"g1"}}
+
+	first, _ := srv.simulate(ctx, simulateRequest{Tenant: testTenant, Scroll: scroll})
+	scroll.ID = "b"
+	second, _ := srv.simulate(ctx, simulateRequest{Tenant: testTenant, Scroll: scroll})
+	if srv.metrics.Counter("plan_cache_hits_total") != 1 {
+		t.Fatalf("expected the second simulation to hit the cache")
+	}
+	if first.MutationLoopID == second.MutationLoopID {
+		t.Fatalf("expected a cache hit to get its own loop ID, both got %s", first.MutationLoopID)
+	}
+	loop, ok := srv.loops.Get(second.MutationLoopID)
+	if !ok {
+		t.Fatalf("expected the cache hit's loop %s to be registered", second.MutationLoopID)
+	}
+	if loop.ScrollID != "b" {
+		t.Fatalf("expected loop registered for scroll b, got %q", loop.ScrollID)
+	}
+}
+
+func TestPlanCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := newPlanCache(2)
+	a, b, d := sha256.Sum256([]byte("a")), sha256.Sum256([]byte("b")), sha256.Sum256([]byte("d"))
+
+	c.put(a, types.GeneInterventionPlan{MutationLoopID: "a"})
+	c.put(b, types.GeneInterventionPlan{MutationLoopID: "b"})
+	c.get(a)
+	c.put(d, types.GeneInterventionPlan{MutationLoopID: "d"})
+
+	if _, ok := c.get(b); ok {
+		t.Fatalf("expected least recently used entry to be evicted")
+	}
+	if _, ok := c.get(a); !ok {
+		t.Fatalf("expected recently used entry to survive")
+	}
+}
+
+func TestMetricsHandler_ExposesCacheCounters(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+	body := `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["g1"]}`
+	postSimulate(h, body, "")
+	postSimulate(h, body, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{"plan_cache_hits_total 1", "plan_cache_misses_total 1", "plan_cache_entries 1"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Fatalf("expected %q in metrics output:\n%s", want, rec.Body)
+		}
+	}
+}
+
+// costlyStrategy stands in for a model-backed scorer whose cost dominates
+// the simulation.
+type costlyStrategy struct{}
+
+func (costlyStrategy) Score(ctx context.Context, scroll types.Scroll, targets []string) (Score, error) {
+	sum := sha256.Sum256([]byte(scroll.ID))
+	for i := 0; i < 2000; i++ {
+		sum = sha256.Sum256(sum[:])
+	}
+	return WeightedStrategy{Default: MarkerWeight{Relief: 0.87, Suppression: 0.91}}.Score(ctx, scroll, targets)
+}
+
+func benchmarkSimulate(b *testing.B, cacheSize int) {
+	cfg := DefaultConfig()
+	cfg.PlanCacheSize = cacheSize
+	cfg.Scoring = costlyStrategy{}
+	srv := NewServer(cfg)
+	scroll := types.Scroll{ID: "s", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2", "ATG16L1"}}
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := srv.simulate(ctx, simulateRequest{Scroll: scroll}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSimulateUncached(b *testing.B) { benchmarkSimulate(b, 0) }
+
+func BenchmarkSimulateCached(b *testing.B) { benchmarkSimulate(b, 1024) }
@@ -0,0 +1,79 @@
+package scroll_engine
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// FieldChange records a plan field whose value differs between two runs.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+// PlanDiff is a structured comparison of two simulation runs. All slices are
+// sorted and non-nil so the JSON encoding is deterministic.
+type PlanDiff struct {
+	ChangedFields    []FieldChange `json:"changed_fields"`
+	AddedGenes       []string      `json:"added_genes"`
+	RemovedGenes     []string      `json:"removed_genes"`
+	ReliefDelta      float64       `json:"predicted_relief_delta"`
+	SuppressionDelta float64       `json:"flare_suppression_delta"`
+}
+
+// DiffPlans compares before and after. Fields are reported in struct order
+// under their JSON names; targeted genes are reported as set additions and
+// removals rather than as a changed field.
+func DiffPlans(before, after types.GeneInterventionPlan) PlanDiff {
+	diff := PlanDiff{
+		ChangedFields:    []FieldChange{},
+		ReliefDelta:      after.PredictedRelief - before.PredictedRelief,
+		SuppressionDelta: after.FlareSuppression - before.FlareSuppression,
+	}
+
+	bv, av := reflect.ValueOf(before), reflect.ValueOf(after)
+	planType := bv.Type()
+	for i := 0; i < planType.NumField(); i++ {
+		name := jsonFieldName(planType.Field(i))
+		if name == "targeted_genes" {
+			continue
+		}
+		b, a := bv.Field(i).Interface(), av.Field(i).Interface()
+		if !reflect.DeepEqual(b, a) {
+			diff.ChangedFields = append(diff.ChangedFields, FieldChange{Field: name, Before: b, After: a})
+		}
+	}
+
+	diff.AddedGenes = geneDifference(after.TargetedGenes, before.TargetedGenes)
+	diff.RemovedGenes = geneDifference(before.TargetedGenes, after.TargetedGenes)
+	return diff
+}
+
+// geneDifference returns the sorted genes in a that are not in b.
+func geneDifference(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, g := range b {
+		seen[g] = true
+	}
+	out := []string{}
+	for _, g := range a {
+		if !seen[g] {
+			out = append(out, g)
+			seen[g] = true
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func jsonFieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
@@ -0,0 +1,83 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestDiffPlans(t *testing.T) {
+	before := types.GeneInterventionPlan{
+		MutationLoopID:  "compost_stream",
+		TargetedGenes:   []string{"g1", "g2"},
+		PredictedRelief: 0.5,
+	}
+	after := types.GeneInterventionPlan{
+		MutationLoopID:  "flare_mutation_loop",
+		TargetedGenes:   []string{"g3", "g2"},
+		TrustAligned:    true,
+		PredictedRelief: 0.75,
+	}
+
+	diff := DiffPlans(before, after)
+
+	var fields []string
+	for _, c := range diff.ChangedFields {
+		fields = append(fields, c.Field)
+	}
+	want := []string{"mutation_loop_id", "trust_aligned", "predicted_relief"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("expected changed fields %v, got %v", want, fields)
+	}
+	if !reflect.DeepEqual(diff.AddedGenes, []string{"g3"}) || !reflect.DeepEqual(diff.RemovedGenes, []string{"g1"}) {
+		t.Fatalf("unexpected gene diff: added=%v removed=%v", diff.AddedGenes, diff.RemovedGenes)
+	}
+	if diff.ReliefDelta != 0.25 {
+		t.Fatalf("expected relief delta 0.25, got %v", diff.ReliefDelta)
+	}
+}
+
+func TestPlanDiffHandler_ByScrollID(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+	postSimulate(h, `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["g1"]}`, "")
+	postSimulate(h, `{"id":"s2","trust_score":0.2,"genetic_markers":["g1"]}`, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodPost, "/plans/diff",
+		strings.NewReader(`{"before_id":"s1","after_id":"s2"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var diff PlanDiff
+	if err := json.NewDecoder(rec.Body).Decode(&diff); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if diff.ReliefDelta >= 0 {
+		t.Fatalf("expected relief to drop moving to compost, got %v", diff.ReliefDelta)
+	}
+}
+
+func TestPlanDiffHandler_MissingPlanNamesSide(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	postSimulate(h, `{"id":"s1","trust_score":0.9}`, "")
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodPost, "/plans/diff",
+		strings.NewReader(`{"before_id":"s1","after_id":"nope"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Error.Field != "after_id" || !strings.Contains(resp.Error.Message, `"nope"`) {
+		t.Fatalf("expected error to name the missing side, got %+v", resp.Error)
+	}
+}
@@ -0,0 +1,125 @@
+package scroll_engine
+
+import (
+	"sort"
+	"strings"
+)
+
+// MarkerRegistry maps the many spellings of a genetic marker onto one
+// canonical symbol so markers from different sources compare equal.
+type MarkerRegistry struct {
+	aliases map[string]string
+	// resolved maps each alias straight to the end of its chain once
+	// resolveChains has run.
+	resolved map[string]string
+}
+
+// NewMarkerRegistry returns a registry resolving each alias key to its
+// value, e.g. {"TL1A": "TNFSF15"}. Aliases may chain; a symbol resolves to
+// the end of its chain. Use aliasCycle to reject cyclic maps up front.
+func NewMarkerRegistry(aliases map[string]string) *MarkerRegistry {
+	r := &MarkerRegistry{aliases: make(map[string]string, len(aliases))}
+	for alias, target := range aliases {
+		r.aliases[normalizeSymbol(alias)] = normalizeSymbol(target)
+	}
+	return r
+}
+
+// normalizeSymbol folds spelling differences in a gene symbol. Gene symbols
+// are upper case, so "atg16l1 " and "ATG16L1" are the same marker.
+func normalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// Canonicalize returns the canonical form of a marker symbol: normalized,
+// then resolved through any alias chain. A cyclic chain stops after visiting
+// every alias once rather than looping.
+func (r *MarkerRegistry) Canonicalize(symbol string) string {
+	s := normalizeSymbol(symbol)
+	if r.resolved != nil {
+		if end, ok := r.resolved[s]; ok {
+			return end
+		}
+		return s
+	}
+	return r.follow(s)
+}
+
+// follow resolves a normalized symbol through the alias chain.
+func (r *MarkerRegistry) follow(s string) string {
+	for range r.aliases {
+		next, ok := r.aliases[s]
+		if !ok || next == s {
+			break
+		}
+		s = next
+	}
+	return s
+}
+
+// resolveChains precomputes the end of every alias chain so Canonicalize
+// is a single lookup. It must run before the registry is shared.
+func (r *MarkerRegistry) resolveChains() {
+	resolved := make(map[string]string, len(r.aliases))
+	for alias := range r.aliases {
+		resolved[alias] = r.follow(alias)
+	}
+	r.resolved = resolved
+}
+
+// CanonicalizeAll canonicalizes each marker, preserving order and a nil
+// slice.
+func (r *MarkerRegistry) CanonicalizeAll(markers []string) []string {
+	if markers == nil {
+		return nil
+	}
+	out := make([]string, len(markers))
+	for i, m := range markers {
+		out[i] = r.Canonicalize(m)
+	}
+	return out
+}
+
+// ContainsAll reports whether markers includes every wanted marker once both
+// sides are canonicalized.
+func (r *MarkerRegistry) ContainsAll(markers, wanted []string) bool {
+	have := make(map[string]bool, len(markers))
+	for _, m := range markers {
+		have[r.Canonicalize(m)] = true
+	}
+	for _, w := range wanted {
+		if !have[r.Canonicalize(w)] {
+			return false
+		}
+	}
+	return true
+}
+
+// aliasCycle returns an alias chain that revisits a symbol, or nil if
+// aliases is acyclic. Symbols are normalized before comparison, and chains
+// are tried in sorted order so the same cycle is always reported.
+func aliasCycle(aliases map[string]string) []string {
+	r := NewMarkerRegistry(aliases)
+	starts := make([]string, 0, len(r.aliases))
+	for s := range r.aliases {
+		starts = append(starts, s)
+	}
+	sort.Strings(starts)
+	for _, start := range starts {
+		chain := []string{start}
+		seen := map[string]bool{start: true}
+		for s := start; ; {
+			next, ok := r.aliases[s]
+			if !ok || next == s {
+				break
+			}
+			chain = append(chain, next)
+			if seen[next] {
+				return chain
+			}
+			seen[next] = true
+			s = next
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func searchScrolls(t *testing.T, h http.Handler, query string) (int, ScrollPage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/scrolls/search?"+query, nil))
+	var page ScrollPage
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return rec.Code, page
+}
+
+func seedSearchServer() http.Handler {
+	h := NewServer(DefaultConfig()).Handler()
+	postSimulate(h, `{"id":"s1","trust_score":0.9,"genetic_markers":["ATG16L1","NOD2"]}`, "")
+	postSimulate(h, `{"id":"s2","trust_score":0.9,"genetic_markers":["ATG16L1"]}`, "")
+	postSimulate(h, `{"id":"s3","trust_score":0.9,"genetic_markers":["IL23R"]}`, "")
+	return h
+}
+
+func TestSearchScrolls_SingleMarkerCanonicalized(t *testing.T) {
+	code, page := searchScrolls(t, seedSearchServer(), "marker=atg16l1")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if page.Total != 2 || page.Scrolls[0].ID != "s1" || page.Scrolls[1].ID != "s2" {
+		t.Fatalf("expected s1, s2; got %+v", page)
+	}
+}
+
+func TestSearchScrolls_MultiMarkerAND(t *testing.T) {
+	_, page := searchScrolls(t, seedSearchServer(), "marker=ATG16L1&marker=nod2")
+	if page.Total != 1 || page.Scrolls[0].ID != "s1" {
+		t.Fatalf("expected only s1, got %+v", page)
+	}
+}
+
+func TestSearchScrolls_Pagination(t *testing.T) {
+	_, page := searchScrolls(t, seedSearchServer(), "marker=ATG16L1&limit=1&offset=1")
+	if page.Total != 2 || len(page.Scrolls) != 1 || page.Scrolls[0].ID != "s2" {
+		t.Fatalf("expected second page to hold s2, got %+v", page)
+	}
+}
+
+func TestSearchScrolls_MissingMarker(t *testing.T) {
+	if code, _ := searchScrolls(t, seedSearchServer(), ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without marker, got %d", code)
+	}
+}
+
+func TestCanonicalize_DirectAlias(t *testing.T) {
+	reg := NewMarkerRegistry(map[string]string{"TL1A": "TNFSF15"})
+	for _, in := range []string{"TL1A", " tl1a", "TNFSF15", "tnfsf15"} {
+		if got := reg.Canonicalize(in); got != "TNFSF15" {
+			t.Fatalf("Canonicalize(%q) = %q, want TNFSF15", in, got)
+		}
+	}
+}
+
+func TestCanonicalize_ChainedAlias(t *testing.T) {
+	reg := NewMarkerRegistry(map[string]string{"a": "b", "B": "c", "c": "TNFSF15"})
+	if got := reg.Canonicalize("A"); got != "TNFSF15" {
+		t.Fatalf("expected chain to resolve to TNFSF15, got %q", got)
+	}
+}
+
+func TestCanonicalize_UnknownSymbol(t *testing.T) {
+	reg := NewMarkerRegistry(map[string]string{"TL1A": "TNFSF15"})
+	if got := reg.Canonicalize(" nod2 "); got != "NOD2" {
+		t.Fatalf("expected unknown symbol to normalize to NOD2, got %q", got)
+	}
+}
+
+func TestAliasCycle(t *testing.T) {
+	if chain := aliasCycle(map[string]string{"A": "B", "B": "C", "X": "X"}); chain != nil {
+		t.Fatalf("expected no cycle, got %v", chain)
+	}
+	chain := aliasCycle(map[string]string{"A": "B", "b": "C", "C": "a"})
+	if want := []string{"A", "B", "C", "A"}; !reflect.DeepEqual(chain, want) {
+		t.Fatalf("cycle %v, want %v", chain, want)
+	}
+
+	var cerr *ConfigError
+	_, err := ParseConfig([]byte(`{"marker_aliases":{"A":"B","B":"A"}}`))
+	if !errors.As(err, &cerr) || cerr.Key != "marker_aliases.A" {
+		t.Fatalf("expected marker_aliases.A config error, got %v", err)
+	}
+}
+
+func TestSimulate_TargetsCanonicalAliases(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MarkerAliases = map[string]string{"TL1A": "TNFSF15"}
+	cfg.MarkerWeights = map[string]MarkerWeight{"tnfsf15": {Relief: 0.4, Suppression: 0.5}}
+	scroll := types.Scroll{ID: "s", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"tl1a", "NOD2"}}
+
+	plan := mustSimulate(t, scroll, cfg)
+	if want := []string{"NOD2", "TNFSF15"}; !reflect.DeepEqual(plan.TargetedGenes, want) {
+		t.Fatalf("targeted genes %v, want %v", plan.TargetedGenes, want)
+	}
+	if want := (0.4 + cfg.DefaultMarkerWeight.Relief) / 2; plan.PredictedRelief != want {
+		t.Fatalf("expected aliased marker to pick up its weight: relief %v, want %v", plan.PredictedRelief, want)
+	}
+}
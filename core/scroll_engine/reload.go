@@ -0,0 +1,97 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// activeConfig is the config simulations run under together with the state
+// derived from it. A reload swaps in a whole new activeConfig, so a request
+// that loaded the old one finishes with it consistently, and plans cached
+// under the old config are never served under the new one.
+type activeConfig struct {
+	cfg   SimulationConfig
+	plans *planCache
+
+	once     sync.Once
+	compiled *compiledConfig
+
+	// idfs holds each tenant's marker IDF under IDF scoring.
+	idfMu sync.Mutex
+	idfs  map[string]*MarkerIDF
+}
+
+func newActiveConfig(cfg SimulationConfig) *activeConfig {
+	return &activeConfig{cfg: cfg, plans: newPlanCache(cfg.PlanCacheSize)}
+}
+
+// engine returns the compiled config, compiling it on first use unless
+// Warmup already has.
+func (a *activeConfig) engine() *compiledConfig {
+	a.once.Do(func() { a.compiled = compileConfig(a.cfg) })
+	return a.compiled
+}
+
+// engineFor returns the compiled config with a request's weight overrides,
+// if any, applied.
+func (a *activeConfig) engineFor(overrides map[string]MarkerWeight) *compiledConfig {
+	if len(overrides) == 0 {
+		return a.engine()
+	}
+	return a.engine().withWeightOverrides(overrides)
+}
+
+// config returns the active simulation config.
+func (s *Server) config() SimulationConfig {
+	return s.active.Load().cfg
+}
+
+// SetConfigPath names the config file POST /admin/reload re-reads.
+func (s *Server) SetConfigPath(path string) {
+	s.configPath = path
+}
+
+// Reload validates cfg, compiles it, and makes it the active config.
+// Simulations already running keep the config they started with. Settings
+// consumed at startup (store, async workers, cache TTLs, access logging,
+// compost decay, the IDF refresh interval) take effect only on restart.
+func (s *Server) Reload(cfg SimulationConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	active := newActiveConfig(cfg)
+	active.engine()
+	s.active.Store(active)
+	s.metrics.Inc("config_reloads_total")
+	return nil
+}
+
+func (s *Server) reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	if s.configPath == "" {
+		writeError(w, http.StatusConflict, CodeUnavailable, "server was not started from a config file", "")
+		return
+	}
+	cfg, err := LoadConfig(s.configPath)
+	if err == nil {
+		err = s.Reload(cfg)
+	}
+	var cerr *ConfigError
+	switch {
+	case errors.As(err, &cerr):
+		writeError(w, http.StatusUnprocessableEntity, CodeInvalidConfig, cerr.Error(), cerr.Key)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, CodeInternal, "read config: "+err.Error(), "")
+		return
+	}
+	log.Printf("Reloaded config from %s (hash %s)", s.configPath, cfg.Hash())
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": "reloaded", "config_hash": cfg.Hash()})
+}
@@ -0,0 +1,97 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestReload_SwapsThresholdForNextSimulation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeConfig := func(body string) {
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatalf("write config: %v", err)
+		}
+	}
+	writeConfig(`{"trust_threshold": 0.7, "admin_token": "s3cret"}`)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	srv := NewServer(cfg)
+	srv.SetConfigPath(path)
+	h := srv.Handler()
+
+	simulate := func() types.GeneInterventionPlan {
+		rec := postSimulate(h, `{"id":"s1","trust_score":0.6}`, "")
+		var plan types.GeneInterventionPlan
+		_ = json.Unmarshal(rec.Body.Bytes(), &plan)
+		return plan
+	}
+	reload := func() *httptest.ResponseRecorder {
+		return postScroll(h, "/admin/reload", testAdminToken, "")
+	}
+
+	if simulate().TrustAligned {
+		t.Fatalf("trust 0.6 should miss the 0.7 threshold")
+	}
+
+	writeConfig(`{"trust_threshold": 0.5, "admin_token": "s3cret"}`)
+	if rec := reload(); rec.Code != http.StatusOK {
+		t.Fatalf("reload: %d %s", rec.Code, rec.Body)
+	}
+	if !simulate().TrustAligned {
+		t.Fatalf("trust 0.6 should clear the reloaded 0.5 threshold")
+	}
+
+	writeConfig(`{"trust_threshold": 1.5, "admin_token": "s3cret"}`)
+	rec := reload()
+	var resp ErrorResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if rec.Code != http.StatusUnprocessableEntity || resp.Error.Code != CodeInvalidConfig || resp.Error.Field != "trust_threshold" {
+		t.Fatalf("expected 422 invalid_config on trust_threshold, got %d %s", rec.Code, rec.Body)
+	}
+	if srv.config().TrustThreshold != 0.5 {
+		t.Fatalf("invalid reload changed the active config: %v", srv.config().TrustThreshold)
+	}
+}
+
+func TestReload_WithoutConfigFile(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.AdminToken = testAdminToken
+	rec := postScroll(NewServer(cfg).Handler(), "/admin/reload", testAdminToken, "")
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409 without a config file, got %d", rec.Code)
+	}
+}
+
+func TestReload_RequiresAdminToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"trust_threshold": 0.5}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.AdminToken = testAdminToken
+	srv := NewServer(cfg)
+	srv.SetConfigPath(path)
+	h := srv.Handler()
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := postScroll(h, "/admin/reload", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: expected 401, got %d", token, rec.Code)
+		}
+	}
+	if srv.config().TrustThreshold != cfg.TrustThreshold {
+		t.Fatalf("unauthorized reload changed the active config")
+	}
+
+	rec := postScroll(NewServer(DefaultConfig()).Handler(), "/admin/reload", testAdminToken, "")
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 with no admin token configured, got %d", rec.Code)
+	}
+}
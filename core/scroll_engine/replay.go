@@ -0,0 +1,244 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// ReplayResult pairs a stored scroll's original plan with the plan it gets
+// under the replay config.
+type ReplayResult struct {
+	ScrollID string                     `json:"scroll_id"`
+	Original types.GeneInterventionPlan `json:"original"`
+	Replayed types.GeneInterventionPlan `json:"replayed"`
+}
+
+// Flipped reports whether the replay took a different branch.
+func (r ReplayResult) Flipped() bool {
+	return r.Original.Branch != r.Replayed.Branch
+}
+
+// ReplayReport summarizes a replay. Flips counts branch changes keyed
+// "<original>-><replayed>", e.g. "compost->flare".
+type ReplayReport struct {
+	Results []ReplayResult `json:"results"`
+	Total   int            `json:"total"`
+	Flipped int            `json:"flipped"`
+	Flips   map[string]int `json:"flips"`
+}
+
+// ReplayPlanKey is the plan key a replayed plan is stored under so it never
+// overwrites the scroll's original plan.
+func ReplayPlanKey(namespace, scrollID string) string {
+	return "replay:" + namespace + ":" + scrollID
+}
+
+// Replay re-simulates tenant's stored scrolls accepted by match (all of
+// them when match is nil) under cfg. Nothing is written to the store. A
+// scroll with no stored plan is compared against an empty original. Under
+// IDF scoring, marker IDF is counted over tenant's stored scrolls.
+func Replay(ctx context.Context, store ScrollStore, tenant string, cfg SimulationConfig, match func(types.Scroll) bool) (ReplayReport, error) {
+	return ReplayWithProgress(ctx, store, tenant, cfg, match, nil)
+}
+
+// ReplayWithProgress is Replay calling progress, when non-nil, after each
+// scroll with the number replayed so far and the number matched.
+func ReplayWithProgress(ctx context.Context, store ScrollStore, tenant string, cfg SimulationConfig, match func(types.Scroll) bool, progress func(done, total int)) (ReplayReport, error) {
+	scrolls, err := store.ListScrolls(tenant, ScrollQuery{})
+	if err != nil {
+		return ReplayReport{}, err
+	}
+	if match != nil {
+		scrolls = slices.DeleteFunc(scrolls, func(s types.Scroll) bool { return !match(s) })
+	}
+
+	engine := compileConfig(cfg)
+	if cfg.ScoringKind == ScoringIDF {
+		idf := &MarkerIDF{Registry: engine.reg}
+		if err := idf.Refresh(store, tenant); err != nil {
+			return ReplayReport{}, err
+		}
+		engine = engine.withIDF(idf)
+	}
+	report := ReplayReport{Results: []ReplayResult{}, Flips: map[string]int{}}
+	for i, scroll := range scrolls {
+		original, err := store.GetPlan(tenant, scroll.ID)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return report, fmt.Errorf("load plan for %q: %w", scroll.ID, err)
+		}
+		replayed, err := engine.simulate(ctx, scroll)
+		if err != nil {
+			return report, fmt.Errorf("replay %q: %w", scroll.ID, err)
+		}
+
+		res := ReplayResult{ScrollID: scroll.ID, Original: original, Replayed: replayed}
+		report.Results = append(report.Results, res)
+		if res.Flipped() {
+			report.Flipped++
+			report.Flips[original.Branch+"->"+replayed.Branch]++
+		}
+		if progress != nil {
+			progress(i+1, len(scrolls))
+		}
+	}
+	report.Total = len(report.Results)
+	return report, nil
+}
+
+// BranchChange is a scroll whose replay took a different branch.
+type BranchChange struct {
+	ScrollID string `json:"scroll_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// ReplaySummary is a ReplayReport without the plans: the counts plus the
+// scrolls that changed branch.
+type ReplaySummary struct {
+	Total   int            `json:"total"`
+	Flipped int            `json:"flipped"`
+	Flips   map[string]int `json:"flips"`
+	Changes []BranchChange `json:"changes"`
+}
+
+// Summary returns the report's ReplaySummary.
+func (r ReplayReport) Summary() ReplaySummary {
+	sum := ReplaySummary{Total: r.Total, Flipped: r.Flipped, Flips: r.Flips, Changes: []BranchChange{}}
+	for _, res := range r.Results {
+		if res.Flipped() {
+			sum.Changes = append(sum.Changes, BranchChange{ScrollID: res.ScrollID, From: res.Original.Branch, To: res.Replayed.Branch})
+		}
+	}
+	return sum
+}
+
+// ReplayAll re-simulates every scroll tenant has stored under cfg and
+// returns the new plans in store order. Stored plans are left untouched.
+func ReplayAll(store ScrollStore, tenant string, cfg SimulationConfig) ([]types.GeneInterventionPlan, error) {
+	report, err := Replay(context.Background(), store, tenant, cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	plans := make([]types.GeneInterventionPlan, len(report.Results))
+	for i, res := range report.Results {
+		plans[i] = res.Replayed
+	}
+	return plans, nil
+}
+
+// SaveReplay writes each replayed plan into tenant's namespace via
+// ReplayPlanKey.
+func SaveReplay(store ScrollStore, tenant, namespace string, report ReplayReport) error {
+	for _, res := range report.Results {
+		if err := store.SavePlan(tenant, ReplayPlanKey(namespace, res.ScrollID), res.Replayed); err != nil {
+			return fmt.Errorf("save replay of %q: %w", res.ScrollID, err)
+		}
+	}
+	return nil
+}
+
+// replayProgressEvery is how many scrolls pass between progress lines in a
+// streamed admin replay.
+const replayProgressEvery = 100
+
+// adminReplayRequest is the body of POST /admin/replay. Config holds config
+// keys to apply over the active config, in the format LoadConfig reads.
+type adminReplayRequest struct {
+	Markers []string        `json:"markers"`
+	Config  json.RawMessage `json:"config,omitempty"`
+}
+
+// ReplayProgress is a progress line in a streamed admin replay.
+type ReplayProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+// replayStreamLine is one line of a streamed admin replay: progress while
+// replaying, then the summary, or an error if the replay fails partway.
+type replayStreamLine struct {
+	Progress *ReplayProgress `json:"progress,omitempty"`
+	Summary  *ReplaySummary  `json:"summary,omitempty"`
+	Error    *ErrorBody      `json:"error,omitempty"`
+}
+
+// adminReplayHandler re-simulates the tenant's stored scrolls carrying every
+// requested marker under the active config with the request's overrides
+// applied, and returns a ReplaySummary. Nothing is persisted. A client
+// accepting application/x-ndjson gets a progress line every
+// replayProgressEvery scrolls before the summary. It requires the admin
+// token.
+func (s *Server) adminReplayHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAdmin(w, r) {
+		return
+	}
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	var req adminReplayRequest
+	if err := decodeBody(r.Body, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if len(req.Markers) == 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "at least one marker is required", "markers")
+		return
+	}
+	active := s.config()
+	cfg := active
+	if len(req.Config) > 0 {
+		var err error
+		if cfg, err = parseConfigOver(active, req.Config); err != nil {
+			var cerr *ConfigError
+			if errors.As(err, &cerr) {
+				writeError(w, http.StatusUnprocessableEntity, CodeInvalidConfig, cerr.Error(), "config."+cerr.Key)
+				return
+			}
+			writeError(w, http.StatusBadRequest, CodeInvalidInput, err.Error(), "config")
+			return
+		}
+	}
+	// Scrolls are selected the way /scrolls/search selects them, by the
+	// active config's aliases.
+	reg := s.active.Load().engine().reg
+	match := func(scroll types.Scroll) bool { return reg.ContainsAll(scroll.GeneticMarkers, req.Markers) }
+
+	if !acceptsNDJSON(r) {
+		report, err := Replay(r.Context(), s.store, tenant, cfg, match)
+		if err != nil {
+			writeSimulationError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(report.Summary())
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	emit := func(line replayStreamLine) {
+		_ = enc.Encode(line)
+		_ = rc.Flush()
+	}
+	report, err := ReplayWithProgress(r.Context(), s.store, tenant, cfg, match, func(done, total int) {
+		if done%replayProgressEvery == 0 || done == total {
+			emit(replayStreamLine{Progress: &ReplayProgress{Done: done, Total: total}})
+		}
+	})
+	if err != nil {
+		body := simulationError(err).body
+		emit(replayStreamLine{Error: &body})
+		return
+	}
+	sum := report.Summary()
+	emit(replayStreamLine{Summary: &sum})
+}
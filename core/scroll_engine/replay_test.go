@@ -0,0 +1,173 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func seedReplayStore(t *testing.T) *MemoryStore {
+	t.Helper()
+	store := NewMemoryStore()
+	for _, s := range []types.Scroll{
+		{ID: "flare", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2"}},
+		{ID: "borderline", TrustScore: 0.65, IsFlareEvent: true, GeneticMarkers: []string{"IL23R"}},
+		{ID: "memory", TrustScore: 0.65, GeneticMarkers: []string{"ATG16L1"}},
+	} {
+		plan := mustSimulate(t, s, DefaultConfig())
+		_ = store.SaveScroll(testTenant, s)
+		_ = store.SavePlan(testTenant, s.ID, plan)
+	}
+	return store
+}
+
+func TestReplayAll_LowerThresholdFlipsBorderline(t *testing.T) {
+	store := seedReplayStore(t)
+	cfg := DefaultConfig()
+	cfg.TrustThreshold = 0.6
+
+	plans, err := ReplayAll(store, testTenant, cfg)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if len(plans) != 3 || plans[1].Branch != BranchFlare {
+		t.Fatalf("expected borderline scroll to replay as flare, got %+v", plans)
+	}
+
+	report, _ := Replay(context.Background(), store, testTenant, cfg, nil)
+	if report.Flipped != 1 || report.Flips["compost->flare"] != 1 {
+		t.Fatalf("expected one compost->flare flip, got %+v", report.Flips)
+	}
+}
+
+func TestSaveReplay_KeepsOriginals(t *testing.T) {
+	store := seedReplayStore(t)
+	cfg := DefaultConfig()
+	cfg.TrustThreshold = 0.6
+	report, _ := Replay(context.Background(), store, testTenant, cfg, nil)
+
+	if err := SaveReplay(store, testTenant, "t1", report); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	original, _ := store.GetPlan(testTenant, "borderline")
+	replayed, err := store.GetPlan(testTenant, ReplayPlanKey("t1", "borderline"))
+	if err != nil {
+		t.Fatalf("load replay plan: %v", err)
+	}
+	if original.Branch != BranchCompost || replayed.Branch != BranchFlare {
+		t.Fatalf("expected original compost and replayed flare, got %s / %s", original.Branch, replayed.Branch)
+	}
+}
+
+func seedAdminReplayServer(t *testing.T) *Server {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.FlareMarkers = []string{"NOD2"}
+	cfg.AdminToken = testAdminToken
+	srv := NewServer(cfg)
+	h := srv.Handler()
+	for _, body := range []string{
+		`{"id":"panel-miss","trust_score":0.9,"is_flare_event":true,"genetic_markers":["IL23R"]}`,
+		`{"id":"on-panel","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`,
+		`{"id":"quiet","trust_score":0.9,"genetic_markers":["il23r"]}`,
+		`{"id":"unknown","trust_score":0.2}`,
+	} {
+		if rec := postSimulate(h, body, ""); rec.Code != http.StatusOK {
+			t.Fatalf("seed: %d %s", rec.Code, rec.Body)
+		}
+	}
+	return srv
+}
+
+func adminReplay(h http.Handler, body, accept string) *httptest.ResponseRecorder {
+	req := newTenantRequest(http.MethodPost, "/admin/replay", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminReplay_RequiresAdminToken(t *testing.T) {
+	h := seedAdminReplayServer(t).Handler()
+	if rec := postScroll(h, "/admin/replay", "wrong", `{"markers":["IL23R"]}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a wrong token, got %d", rec.Code)
+	}
+	if rec := adminReplay(NewServer(DefaultConfig()).Handler(), `{"markers":["IL23R"]}`, ""); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 with no admin token configured, got %d", rec.Code)
+	}
+}
+
+func TestAdminReplay_ScopesToMarkerUnderOverride(t *testing.T) {
+	srv := seedAdminReplayServer(t)
+	h := srv.Handler()
+
+	rec := adminReplay(h, `{"markers":["IL23R"],"config":{"flare_markers":["NOD2","IL23R"]}}`, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var sum ReplaySummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &sum); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if sum.Total != 2 || sum.Flipped != 1 || sum.Flips["compost->flare"] != 1 {
+		t.Fatalf("expected the two IL23R scrolls replayed with one flip, got %+v", sum)
+	}
+	if len(sum.Changes) != 1 || sum.Changes[0] != (BranchChange{ScrollID: "panel-miss", From: BranchCompost, To: BranchFlare}) {
+		t.Fatalf("unexpected changes %+v", sum.Changes)
+	}
+
+	if plan, _ := srv.store.GetPlan(testTenant, "panel-miss"); plan.Branch != BranchCompost {
+		t.Fatalf("expected the stored plan untouched, got %s", plan.Branch)
+	}
+	if got := srv.config().FlareMarkers; len(got) != 1 || got[0] != "NOD2" {
+		t.Fatalf("expected the active config untouched, got panel %v", got)
+	}
+}
+
+func TestAdminReplay_StreamsProgress(t *testing.T) {
+	h := seedAdminReplayServer(t).Handler()
+	rec := adminReplay(h, `{"markers":["IL23R"]}`, ndjsonContentType)
+	if ct := rec.Header().Get("Content-Type"); ct != ndjsonContentType {
+		t.Fatalf("expected an NDJSON response, got %q", ct)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	var progress, last replayStreamLine
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[0]), &progress) != nil || json.Unmarshal([]byte(lines[1]), &last) != nil {
+		t.Fatalf("expected a progress line and a summary line, got %q", lines)
+	}
+	if progress.Progress == nil || *progress.Progress != (ReplayProgress{Done: 2, Total: 2}) {
+		t.Fatalf("unexpected progress %+v", progress)
+	}
+	if last.Summary == nil || last.Summary.Total != 2 || last.Summary.Flipped != 0 {
+		t.Fatalf("unexpected summary %+v", last)
+	}
+}
+
+func TestAdminReplay_RejectsBadRequests(t *testing.T) {
+	h := seedAdminReplayServer(t).Handler()
+	cases := []struct {
+		body  string
+		code  int
+		field string
+	}{
+		{`{"config":{}}`, http.StatusBadRequest, "markers"},
+		{`{"markers":["NOD2"],"config":{"trust_threshold":2}}`, http.StatusUnprocessableEntity, "config.trust_threshold"},
+	}
+	for _, c := range cases {
+		rec := adminReplay(h, c.body, "")
+		var resp ErrorResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != c.code || resp.Error.Field != c.field {
+			t.Errorf("%s: expected %d on %s, got %d %s", c.body, c.code, c.field, rec.Code, rec.Body)
+		}
+	}
+}
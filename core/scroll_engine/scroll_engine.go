@@ -1,45 +1,375 @@
 package scroll_engine
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"time"
 
 	"Maple-OS/modem_os/core/shared/types"
 )
 
+// Simulation branches a plan can come from.
+const (
+	BranchDiscovery = "discovery"
+	BranchFlare     = "flare"
+	BranchCompost   = "compost"
+)
+
 // StartScrollSimulation initializes a new scroll simulation.
-func StartScrollSimulation(scroll types.Scroll) types.GeneInterventionPlan {
-	trustAligned := scroll.TrustScore >= 0.7
-	hasMarkers := len(scroll.GeneticMarkers) > 0
+func StartScrollSimulation(ctx context.Context, scroll types.Scroll) (types.GeneInterventionPlan, error) {
+	return SimulateWithConfig(ctx, scroll, DefaultConfig())
+}
+
+// SimulateInCohort normalizes the scroll's trust against cohort before the
+// threshold comparison, so scores from differently calibrated sources are
+// judged on the same scale. See NormalizeTrust.
+func SimulateInCohort(ctx context.Context, scroll types.Scroll, cohort []types.Scroll, cfg SimulationConfig) (types.GeneInterventionPlan, error) {
+	prov := newTrustProvenance(scroll.TrustScore)
+	if lo, hi := trustRange(append(cohort[:len(cohort):len(cohort)], scroll)); hi > lo {
+		applyTrustStep(&prov, TrustStepNormalize, lo, 1/(hi-lo))
+	}
+	return compileConfig(cfg).simulateFrom(ctx, scroll, prov)
+}
+
+// ErrNoEligibleTargets is returned by TriggerGeneIntervention when fewer of
+// a scroll's markers are on the flare panel than cfg.MinFlareMarkers.
+var ErrNoEligibleTargets = errors.New("too few markers on the flare panel")
+
+// SimulateWithConfig runs a scroll simulation using the tunables in cfg. It
+// returns ctx.Err() if ctx is done before the plan is complete.
+func SimulateWithConfig(ctx context.Context, scroll types.Scroll, cfg SimulationConfig) (types.GeneInterventionPlan, error) {
+	return compileConfig(cfg).simulate(ctx, scroll)
+}
+
+// TriggerGeneIntervention builds the flare-branch plan for a scroll,
+// targeting those of its markers on cfg's flare panel. It does not check
+// that the scroll is a trust-aligned flare; SimulateWithConfig does. If too
+// few markers are on the panel it returns ErrNoEligibleTargets rather than a
+// plan, so the caller can hold the scroll in memory instead.
+func TriggerGeneIntervention(ctx context.Context, scroll types.Scroll, cfg SimulationConfig) (types.GeneInterventionPlan, error) {
+	c := compileConfig(cfg)
+	if err := ctx.Err(); err != nil {
+		return types.GeneInterventionPlan{}, err
+	}
+	markers, err := c.scrollMarkers(scroll)
+	if err != nil {
+		return types.GeneInterventionPlan{}, err
+	}
+	prov := c.trustProvenance(newTrustProvenance(scroll.TrustScore), scroll, time.Now())
+	scroll.TrustScore = prov.Effective
+	explain := c.baseExplanation(prov, scroll.IsFlareEvent, scroll.TrustScore >= cfg.TrustThreshold)
+	return c.triggerGeneIntervention(ctx, scroll, markers, explain)
+}
+
+// compiledConfig is a SimulationConfig with the structures simulation
+// derives from it built once: the marker registry with alias chains
+// resolved, the scoring strategy with weights keyed canonically, the
+// canonical flare panel, and the config hash audit entries record.
+type compiledConfig struct {
+	cfg     SimulationConfig
+	reg     *MarkerRegistry
+	scoring ScoringStrategy
+	// breaker guards external scoring; nil when there is none or the
+	// breaker is disabled.
+	breaker *CircuitBreaker
+	// idf is the marker IDF IDF scoring reads; nil until withIDF sets it.
+	idf *MarkerIDF
+	// panel is the canonical flare panel; nil places no restriction.
+	panel map[string]bool
+	hash  string
+}
+
+func compileConfig(cfg SimulationConfig) *compiledConfig {
+	reg := cfg.markerRegistry()
+	reg.resolveChains()
+	c := &compiledConfig{cfg: cfg, reg: reg, hash: cfg.Hash()}
+	if cfg.ScoringURL != "" && cfg.Scoring == nil {
+		c.breaker = cfg.ScoringBreaker.newBreaker()
+	}
+	c.scoring = cfg.scoringWith(reg, c.breaker, nil)
+	if len(cfg.FlareMarkers) > 0 {
+		c.panel = make(map[string]bool, len(cfg.FlareMarkers))
+		for _, m := range cfg.FlareMarkers {
+			c.panel[reg.Canonicalize(m)] = true
+		}
+	}
+	return c
+}
+
+// withWeightOverrides returns c with scoring rebuilt for overrides, sharing
+// everything else.
+func (c *compiledConfig) withWeightOverrides(overrides map[string]MarkerWeight) *compiledConfig {
+	out := *c
+	out.cfg = c.cfg.WithWeightOverrides(overrides)
+	out.scoring = out.cfg.scoringWith(c.reg, c.breaker, c.idf)
+	return &out
+}
+
+// withIDF returns c scoring with idf. Only IDF scoring reads it.
+func (c *compiledConfig) withIDF(idf *MarkerIDF) *compiledConfig {
+	out := *c
+	out.idf = idf
+	out.scoring = out.cfg.scoringWith(c.reg, c.breaker, idf)
+	return &out
+}
 
-	// Low trust + no markers → discovery loop + recalibration
-	if !trustAligned && !hasMarkers {
+func (c *compiledConfig) simulate(ctx context.Context, scroll types.Scroll) (types.GeneInterventionPlan, error) {
+	return c.simulateFrom(ctx, scroll, newTrustProvenance(scroll.TrustScore))
+}
+
+// simulateFrom simulates scroll with the trust transformations already
+// applied to it recorded in prov, applying the configured ones after them.
+func (c *compiledConfig) simulateFrom(ctx context.Context, scroll types.Scroll, prov types.TrustProvenance) (types.GeneInterventionPlan, error) {
+	ctx, span := startSpan(ctx, "Simulate", scrollAttrs(scroll)...)
+	plan, err := c.decide(ctx, scroll, prov)
+	span.SetAttributes(attrBranch.String(plan.Branch))
+	endSpan(span, err)
+	return plan, err
+}
+
+// decide is simulateFrom without its span.
+func (c *compiledConfig) decide(ctx context.Context, scroll types.Scroll, prov types.TrustProvenance) (types.GeneInterventionPlan, error) {
+	if err := ctx.Err(); err != nil {
+		return types.GeneInterventionPlan{}, err
+	}
+
+	cfg := c.cfg
+	prov = c.trustProvenance(prov, scroll, time.Now())
+	scroll.TrustScore = prov.Effective
+	trustAligned := scroll.TrustScore >= cfg.TrustThreshold
+	explain := c.baseExplanation(prov, scroll.IsFlareEvent, trustAligned)
+
+	// Most scrolls fall below the threshold, so they are decided before any
+	// marker work their plans do not need.
+	if !trustAligned {
+		return c.decideLowTrust(scroll, explain)
+	}
+
+	markers, err := c.scrollMarkers(scroll)
+	if err != nil {
+		return types.GeneInterventionPlan{}, err
+	}
+	hasMarkers := len(markers) > 0
+
+	// High trust + flare + markers on the panel → flare mutation loop
+	rebirth := explainRebirth(trustAligned, scroll.IsFlareEvent, hasMarkers)
+	if scroll.IsFlareEvent && hasMarkers {
+		plan, err := c.triggerGeneIntervention(ctx, scroll, markers, explain)
+		if !errors.Is(err, ErrNoEligibleTargets) {
+			return plan, err
+		}
+		matched := len(c.matchFlarePanel(markers))
+		explain = append(explain, explainFlarePanel(cfg.FlareMarkers, matched, c.minFlareMarkers()))
+		rebirth = explainRebirth(trustAligned, scroll.IsFlareEvent, false)
+	}
+	return c.compost(scroll, markers, trustAligned, append(explain, explainMarkers(markers, nil), rebirth)), nil
+}
+
+// decideLowTrust decides a scroll below the trust threshold, which never
+// reaches the flare panel or scoring. Without markers it starts a discovery
+// loop and needs no marker work at all; otherwise it is composted.
+func (c *compiledConfig) decideLowTrust(scroll types.Scroll, explain []types.ExplanationReason) (types.GeneInterventionPlan, error) {
+	// Canonicalizing and deduplicating never empties a marker list, so
+	// the raw list says whether the scroll has markers.
+	if len(scroll.GeneticMarkers) == 0 {
 		return types.GeneInterventionPlan{
-			MutationLoopID:      "discovery_loop",
+			MutationLoopID:      c.cfg.loopIDs().NextLoopID(BranchDiscovery),
+			Branch:              BranchDiscovery,
 			TargetedGenes:       []string{},
 			TrustAligned:        false,
 			RequiredRecalibrate: true,
-		}
+			Explanation: append(explain, explainMarkers(nil, nil),
+				explainRebirth(false, scroll.IsFlareEvent, false)),
+		}, nil
 	}
-
-	// High trust + flare + markers → flare mutation loop
-	if trustAligned && scroll.IsFlareEvent && hasMarkers {
-		return types.GeneInterventionPlan{
-			MutationLoopID:      "flare_mutation_loop",
-			TargetedGenes:       scroll.GeneticMarkers,
-			TrustAligned:        true,
-			RequiredRecalibrate: false,
-			PredictedRelief:     0.87,
-			FlareSuppression:    0.91,
-			RebirthEligible:     true,
-		}
+	markers, err := c.scrollMarkers(scroll)
+	if err != nil {
+		return types.GeneInterventionPlan{}, err
 	}
+	return c.compost(scroll, markers, false,
+		append(explain, explainMarkers(markers, nil), explainRebirth(false, scroll.IsFlareEvent, true))), nil
+}
 
-	// Default fallback
-	fmt.Printf("Scroll %s falling back to compost stream\n", scroll.ID)
+// compost builds the plan holding scroll in memory, targeting its distinct
+// canonical markers, as scrollMarkers returns them, in alphabetical order.
+func (c *compiledConfig) compost(scroll types.Scroll, markers []string, trustAligned bool, explain []types.ExplanationReason) types.GeneInterventionPlan {
+	log.Printf("Scroll %s falling back to compost stream", scroll.ID)
+	// markers are already distinct, so sorting a copy is targetGenes
+	// without its second deduplication.
+	targets := append(make([]string, 0, len(markers)), markers...)
+	slices.Sort(targets)
 	return types.GeneInterventionPlan{
-		MutationLoopID:      "compost_stream",
-		TargetedGenes:       scroll.GeneticMarkers,
+		MutationLoopID:      c.cfg.loopIDs().NextLoopID(BranchCompost),
+		Branch:              BranchCompost,
+		TargetedGenes:       targets,
+		TargetDetails:       c.targetDetails(targets, nil),
 		TrustAligned:        trustAligned,
 		RequiredRecalibrate: true,
+		Explanation:         explain,
+	}
+}
+
+func (c *compiledConfig) triggerGeneIntervention(ctx context.Context, scroll types.Scroll, markers []string, explain []types.ExplanationReason) (types.GeneInterventionPlan, error) {
+	cfg := c.cfg
+	targets := targetGenes(c.matchFlarePanel(markers))
+	if len(targets) < c.minFlareMarkers() {
+		return types.GeneInterventionPlan{}, ErrNoEligibleTargets
+	}
+	scoreCtx, span := startSpan(ctx, "Score", attrScrollID.String(scroll.ID))
+	score, err := c.scoring.Score(scoreCtx, scroll, targets)
+	endSpan(span, err)
+	if err != nil && budgetSpent(ctx) {
+		// Out of request budget: return the plan unscored rather than
+		// nothing.
+		details := c.targetDetails(targets, nil)
+		return types.GeneInterventionPlan{
+			MutationLoopID:  cfg.loopIDs().NextLoopID(BranchFlare),
+			Branch:          BranchFlare,
+			TargetedGenes:   detailGenes(details),
+			TargetDetails:   details,
+			TrustAligned:    scroll.TrustScore >= cfg.TrustThreshold,
+			RebirthEligible: true,
+			Explanation: append(explain, explainMarkers(targets, nil),
+				explainRebirth(true, true, true), explainBudget()),
+		}, nil
+	}
+	if err != nil {
+		return types.GeneInterventionPlan{}, err
+	}
+	// A strategy scoring NaN or out of range is a fault of the strategy,
+	// not the scroll, so it is not reported as a ValidationError.
+	if err := score.validate(); err != nil {
+		return types.GeneInterventionPlan{}, fmt.Errorf("scoring strategy returned an invalid score: %v", err)
+	}
+	details := c.targetDetails(targets, score.Contributions)
+	return types.GeneInterventionPlan{
+		MutationLoopID:      cfg.loopIDs().NextLoopID(BranchFlare),
+		Branch:              BranchFlare,
+		TargetedGenes:       detailGenes(details),
+		TargetDetails:       details,
+		TrustAligned:        scroll.TrustScore >= cfg.TrustThreshold,
+		RequiredRecalibrate: false,
+		PredictedRelief:     score.PredictedRelief,
+		ReliefCI:            ReliefInterval(score.PredictedRelief, scroll.TrustScore, score.Contributions),
+		FlareSuppression:    score.FlareSuppression,
+		RebirthEligible:     true,
+		FlareSeverity: ClassifyFlareSeverity(
+			scroll.TrustScore, len(targets), score.FlareSuppression, cfg.FlareSeverity),
+		Explanation: append(append(explain, explainMarkers(targets, score.Contributions),
+			explainRebirth(true, true, true)), explainScoringFallback(score)...),
+	}, nil
+}
+
+// scrollMarkers returns the scroll's distinct canonical markers, rejecting
+// scrolls carrying more than cfg.MaxMarkers.
+func (c *compiledConfig) scrollMarkers(scroll types.Scroll) ([]string, error) {
+	markers := dedupMarkers(c.reg.CanonicalizeAll(scroll.GeneticMarkers))
+	if c.cfg.MaxMarkers > 0 && len(markers) > c.cfg.MaxMarkers {
+		return nil, &types.ValidationError{
+			Field:   "genetic_markers",
+			Message: fmt.Sprintf("%d distinct markers exceeds the limit of %d", len(markers), c.cfg.MaxMarkers),
+		}
+	}
+	return markers, nil
+}
+
+// baseExplanation is the explanation every branch starts from.
+func (c *compiledConfig) baseExplanation(prov types.TrustProvenance, flare, trustAligned bool) []types.ExplanationReason {
+	overrides := explainWeightOverrides(c.cfg.WeightOverrides, c.reg)
+	// Every branch appends at least the markers and rebirth reasons.
+	explain := make([]types.ExplanationReason, 0, 4+len(overrides))
+	explain = append(explain,
+		explainTrust(prov, c.cfg.TrustThreshold, trustAligned),
+		explainFlareEvent(flare),
+	)
+	return append(explain, overrides...)
+}
+
+// rebirthEligible reports whether simulating scroll would take the flare
+// branch, without simulating it.
+func (c *compiledConfig) rebirthEligible(scroll types.Scroll) bool {
+	prov := c.trustProvenance(newTrustProvenance(scroll.TrustScore), scroll, time.Now())
+	if !scroll.IsFlareEvent || prov.Effective < c.cfg.TrustThreshold {
+		return false
+	}
+	markers, err := c.scrollMarkers(scroll)
+	return err == nil && len(c.matchFlarePanel(markers)) >= c.minFlareMarkers()
+}
+
+// minFlareMarkers is how many markers must match the flare panel for a
+// flare to be intervened on.
+func (c *compiledConfig) minFlareMarkers() int {
+	return max(c.cfg.MinFlareMarkers, 1)
+}
+
+// matchFlarePanel returns the canonical markers that appear on the flare
+// panel. An empty panel matches every marker.
+func (c *compiledConfig) matchFlarePanel(markers []string) []string {
+	if c.panel == nil {
+		return markers
+	}
+	matched := []string{}
+	for _, m := range markers {
+		if c.panel[m] {
+			matched = append(matched, m)
+		}
+	}
+	return matched
+}
+
+// dedupMarkers drops repeated markers, keeping the first occurrence of
+// each. Markers should already be canonical.
+func dedupMarkers(markers []string) []string {
+	if markers == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(markers))
+	out := make([]string, 0, len(markers))
+	for _, m := range markers {
+		if !seen[m] {
+			seen[m] = true
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// targetDetails returns an entry for each target, in order, carrying the
+// contribution scoring attributed to it, if any, and whether it is on the
+// flare panel.
+func (c *compiledConfig) targetDetails(targets []string, contributions []types.MarkerContribution) []types.MarkerContribution {
+	details := make([]types.MarkerContribution, len(targets))
+	for i, g := range targets {
+		details[i] = types.MarkerContribution{Gene: g}
+		if j := slices.IndexFunc(contributions, func(mc types.MarkerContribution) bool { return mc.Gene == g }); j >= 0 {
+			details[i] = contributions[j]
+		}
+		details[i].OnPanel = c.panel == nil || c.panel[g]
+	}
+	return details
+}
+
+// detailGenes returns the genes details are for, which a plan reports as
+// its TargetedGenes. It never returns nil.
+func detailGenes(details []types.MarkerContribution) []string {
+	genes := make([]string, len(details))
+	for i, d := range details {
+		genes[i] = d.Gene
+	}
+	return genes
+}
+
+// targetGenes returns the plan's targeted genes: the distinct markers in
+// alphabetical order, so equal marker sets always yield equal plans. It never
+// returns nil.
+func targetGenes(markers []string) []string {
+	genes := slices.Clone(dedupMarkers(markers))
+	if genes == nil {
+		genes = []string{}
 	}
+	slices.Sort(genes)
+	return genes
 }
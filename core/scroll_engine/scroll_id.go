@@ -0,0 +1,51 @@
+package scroll_engine
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// Ways of assigning an ID to a scroll submitted without one; see
+// SimulationConfig.ScrollIDs.
+const (
+	// ScrollIDContent derives the ID from the scroll's content, so
+	// resubmitting the same scroll replaces it rather than adding another.
+	ScrollIDContent = "content"
+	// ScrollIDUUID assigns a random UUID, so every submission is stored.
+	ScrollIDUUID = "uuid"
+)
+
+// assignScrollID returns an ID for scroll, which arrived without one,
+// according to mode.
+func assignScrollID(mode string, scroll types.Scroll) (string, error) {
+	if mode == ScrollIDUUID {
+		return newUUID(), nil
+	}
+	return contentScrollID(scroll)
+}
+
+// contentScrollID derives an ID from the scroll's signed fields, with its
+// markers sorted so their order does not matter.
+func contentScrollID(scroll types.Scroll) (string, error) {
+	scroll.GeneticMarkers = slices.Sorted(slices.Values(scroll.GeneticMarkers))
+	payload, err := canonicalScroll(scroll)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return "s-" + hex.EncodeToString(sum[:16]), nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,79 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+func decodeAssignedID(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var resp simulateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.ScrollID == "" || rec.Header().Get("Location") != "/scrolls/"+resp.ScrollID {
+		t.Fatalf("expected the assigned ID echoed in body and Location, got %q and %q", resp.ScrollID, rec.Header().Get("Location"))
+	}
+	return resp.ScrollID
+}
+
+func TestScrollIDs_ContentModeDedups(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+
+	a := decodeAssignedID(t, postSimulate(h, `{"trust_score":0.9,"genetic_markers":["NOD2","IL23R"]}`, ""))
+	b := decodeAssignedID(t, postSimulate(h, `{"genetic_markers":["IL23R","NOD2"],"trust_score":0.9}`, ""))
+	c := decodeAssignedID(t, postSimulate(h, `{"trust_score":0.8,"genetic_markers":["NOD2","IL23R"]}`, ""))
+	if a != b {
+		t.Fatalf("expected identical content to get the same ID, got %q and %q", a, b)
+	}
+	if a == c {
+		t.Fatalf("expected different content to get a different ID, both got %q", a)
+	}
+	if n, _ := srv.store.CountScrolls(testTenant, ScrollQuery{}); n != 2 {
+		t.Fatalf("expected 2 stored scrolls after dedup, got %d", n)
+	}
+	if _, err := srv.store.GetScroll(testTenant, ""); err == nil {
+		t.Fatal("expected nothing stored under an empty ID")
+	}
+}
+
+func TestScrollIDs_UUIDModeAlwaysNew(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ScrollIDs = ScrollIDUUID
+	srv := NewServer(cfg)
+	h := srv.Handler()
+	body := `{"trust_score":0.9,"genetic_markers":["NOD2"]}`
+
+	a := decodeAssignedID(t, postSimulate(h, body, ""))
+	b := decodeAssignedID(t, postSimulate(h, body, ""))
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if a == b || !uuid.MatchString(a) || !uuid.MatchString(b) {
+		t.Fatalf("expected two distinct v4 UUIDs, got %q and %q", a, b)
+	}
+	if n, _ := srv.store.CountScrolls(testTenant, ScrollQuery{}); n != 2 {
+		t.Fatalf("expected both submissions stored, got %d", n)
+	}
+}
+
+func TestScrollIDs_ClientIDPassesThrough(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	rec := postSimulate(srv.Handler(), `{"id":"mine","trust_score":0.9}`, "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Location") != "" {
+		t.Fatalf("expected 200 without Location, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+	var resp simulateResponse
+	_ = json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.ScrollID != "" {
+		t.Fatalf("expected no echoed ID for a client-supplied one, got %q", resp.ScrollID)
+	}
+	if _, err := srv.store.GetScroll(testTenant, "mine"); err != nil {
+		t.Fatalf("expected the scroll stored under its own ID: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package scroll_engine
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// scrollSchemaJSON is the published JSON Schema for a submitted scroll,
+// served at /schema/scroll.
+//
+//go:embed scroll.schema.json
+var scrollSchemaJSON []byte
+
+// scrollSchema is scrollSchemaJSON compiled, with formats asserted.
+var scrollSchema = mustCompileScrollSchema()
+
+// schemaPrinter renders violation messages.
+var schemaPrinter = message.NewPrinter(language.English)
+
+func mustCompileScrollSchema() *jsonschema.Schema {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(scrollSchemaJSON))
+	if err != nil {
+		panic("scroll schema: " + err.Error())
+	}
+	c := jsonschema.NewCompiler()
+	c.AssertFormat()
+	if err := c.AddResource("scroll.schema.json", doc); err != nil {
+		panic("scroll schema: " + err.Error())
+	}
+	return c.MustCompile("scroll.schema.json")
+}
+
+// SchemaViolation is one way a document failed the scroll schema: the JSON
+// Pointer to the offending value, the schema keyword it broke, and why.
+type SchemaViolation struct {
+	Path       string `json:"path"`
+	Constraint string `json:"constraint"`
+	Message    string `json:"message"`
+}
+
+// validateScrollSchema checks a scroll document against the scroll schema,
+// returning its violations ordered by path, or nil if it conforms.
+func validateScrollSchema(raw []byte) ([]SchemaViolation, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	err = scrollSchema.Validate(doc)
+	var vErr *jsonschema.ValidationError
+	if !errors.As(err, &vErr) {
+		return nil, err
+	}
+	var violations []SchemaViolation
+	collectViolations(vErr, &violations)
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	return violations, nil
+}
+
+// collectViolations appends the leaves of e's cause tree, which name the
+// keywords actually broken, to out.
+func collectViolations(e *jsonschema.ValidationError, out *[]SchemaViolation) {
+	if len(e.Causes) > 0 {
+		for _, cause := range e.Causes {
+			collectViolations(cause, out)
+		}
+		return
+	}
+	v := SchemaViolation{
+		Path:       "/" + strings.Join(e.InstanceLocation, "/"),
+		Constraint: strings.Join(e.ErrorKind.KeywordPath(), "/"),
+		Message:    e.ErrorKind.LocalizedString(schemaPrinter),
+	}
+	if isOverflow(e.ErrorKind) {
+		v.Message = "out of range: must be a finite number"
+	}
+	*out = append(*out, v)
+}
+
+// isOverflow reports whether k is a range violation by a number too large
+// for a float64, such as 1e400.
+func isOverflow(k jsonschema.ErrorKind) bool {
+	var got float64
+	switch k := k.(type) {
+	case *kind.Minimum:
+		got, _ = k.Got.Float64()
+	case *kind.Maximum:
+		got, _ = k.Got.Float64()
+	default:
+		return false
+	}
+	return math.IsInf(got, 0)
+}
+
+// schemaError reports a scroll that failed the schema, naming the first
+// violation and listing all of them. A value of the wrong type or too large
+// to represent is invalid input, as it is to the decoder; any other
+// violation makes the scroll invalid.
+func schemaError(violations []SchemaViolation) *requestError {
+	first := violations[0]
+	code := CodeInvalidScroll
+	if first.Constraint == "type" || strings.HasPrefix(first.Message, "out of range") {
+		code = CodeInvalidInput
+	}
+	msg := first.Message
+	if len(violations) > 1 {
+		msg = fmt.Sprintf("%s (and %d more schema violations)", msg, len(violations)-1)
+	}
+	rerr := newRequestError(http.StatusBadRequest, code, msg, pointerField(first.Path))
+	rerr.body.Violations = violations
+	return rerr
+}
+
+// pointerField renders a JSON Pointer as the dotted field name error
+// responses use, such as "genetic_markers.2".
+func pointerField(ptr string) string {
+	return strings.ReplaceAll(strings.TrimPrefix(ptr, "/"), "/", ".")
+}
+
+func scrollSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/schema+json")
+	_, _ = w.Write(scrollSchemaJSON)
+}
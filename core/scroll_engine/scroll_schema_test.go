@@ -0,0 +1,129 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// schemaTypeOf is the JSON Schema type encoding/json gives a Go type.
+func schemaTypeOf(t reflect.Type) any {
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return "string"
+	case t.Kind() == reflect.Slice:
+		return []any{"array", "null"}
+	case t.Kind() == reflect.String:
+		return "string"
+	case t.Kind() == reflect.Bool:
+		return "boolean"
+	case t.Kind() == reflect.Float64:
+		return "number"
+	case t.Kind() == reflect.Int:
+		return "integer"
+	}
+	return nil
+}
+
+func TestScrollSchema_MatchesStruct(t *testing.T) {
+	generated := map[string]any{}
+	st := reflect.TypeOf(types.Scroll{})
+	for i := range st.NumField() {
+		name, _, _ := strings.Cut(st.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		typ := schemaTypeOf(st.Field(i).Type)
+		if typ == nil {
+			t.Fatalf("field %s: no schema type for %s", name, st.Field(i).Type)
+		}
+		generated[name] = typ
+	}
+
+	var schema struct {
+		Properties map[string]struct {
+			Type any `json:"type"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(scrollSchemaJSON, &schema); err != nil {
+		t.Fatalf("decode schema: %v", err)
+	}
+	published := map[string]any{}
+	for name, p := range schema.Properties {
+		published[name] = p.Type
+	}
+	if !reflect.DeepEqual(generated, published) {
+		t.Fatalf("scroll.schema.json is out of sync with types.Scroll:\n struct: %v\n schema: %v", generated, published)
+	}
+}
+
+func TestValidateScrollSchema_Valid(t *testing.T) {
+	doc := `{"schema_version":2,"id":"s1","trust_score":0.8,"is_flare_event":true,
+		"genetic_markers":["NOD2","IL23R"],"timestamp":"2026-01-02T03:04:05Z",
+		"tags":null,"parent_id":"s0","weight_overrides":{"NOD2":{"relief":0.5}}}`
+	violations, err := validateScrollSchema([]byte(doc))
+	if err != nil || len(violations) != 0 {
+		t.Fatalf("expected a valid scroll, got %+v (%v)", violations, err)
+	}
+}
+
+func TestValidateScrollSchema_Violations(t *testing.T) {
+	for _, tc := range []struct {
+		name, doc, path, constraint string
+	}{
+		{"above maximum", `{"trust_score":1.5}`, "/trust_score", "maximum"},
+		{"below minimum", `{"trust_score":-0.5}`, "/trust_score", "minimum"},
+		{"wrong type", `{"trust_score":"high"}`, "/trust_score", "type"},
+		{"marker not a string", `{"genetic_markers":["NOD2",7]}`, "/genetic_markers/1", "type"},
+		{"bad timestamp", `{"timestamp":"yesterday"}`, "/timestamp", "format"},
+		{"not an object", `[]`, "/", "type"},
+	} {
+		violations, err := validateScrollSchema([]byte(tc.doc))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(violations) != 1 || violations[0].Path != tc.path || violations[0].Constraint != tc.constraint || violations[0].Message == "" {
+			t.Fatalf("%s: expected a %s violation at %s, got %+v", tc.name, tc.constraint, tc.path, violations)
+		}
+	}
+}
+
+func TestSimulate_ReportsEverySchemaViolation(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	rec := postSimulate(h, `{"id":"s1","trust_score":2,"is_flare_event":"yes","tags":["a",1]}`, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+	var resp ErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []struct{ path, constraint string }{
+		{"/is_flare_event", "type"},
+		{"/tags/1", "type"},
+		{"/trust_score", "maximum"},
+	}
+	if len(resp.Error.Violations) != len(want) {
+		t.Fatalf("expected %d violations, got %+v", len(want), resp.Error.Violations)
+	}
+	for i, w := range want {
+		if v := resp.Error.Violations[i]; v.Path != w.path || v.Constraint != w.constraint {
+			t.Fatalf("violation %d: expected %s at %s, got %+v", i, w.constraint, w.path, v)
+		}
+	}
+	if resp.Error.Code != CodeInvalidInput || resp.Error.Field != "is_flare_event" {
+		t.Fatalf("expected the first violation named, got %+v", resp.Error)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/schema/scroll", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != string(scrollSchemaJSON) {
+		t.Fatalf("expected /schema/scroll to serve the published schema, got %d", rec.Code)
+	}
+}
@@ -1,29 +1,761 @@
 package scroll_engine
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
 
 	"Maple-OS/modem_os/core/shared/types"
 )
 
-func simulateHandler(w http.ResponseWriter, r *http.Request) {
+// Server wires the scroll engine's HTTP handlers to their shared state.
+type Server struct {
+	active      atomic.Pointer[activeConfig]
+	configPath  string
+	store       ScrollStore
+	loops       *LoopRegistry
+	idem        *idempotencyCache
+	metrics     *Metrics
+	queue       *scrollQueue
+	stats       *statsCache
+	logger      *slog.Logger
+	flares      *eventHub
+	webhooks    chan WebhookEvent
+	warm        atomic.Bool
+	maintenance atomic.Bool
+	tracer      trace.Tracer
+	// unsignedWebhooks warns, once, that webhooks are sent unsigned.
+	unsignedWebhooks sync.Once
+}
+
+// NewServer returns a Server running with cfg and empty in-memory state.
+func NewServer(cfg SimulationConfig) *Server {
+	return NewServerWithStore(cfg, NewMemoryStore())
+}
+
+// NewServerWithStore returns a Server running with cfg that persists scrolls
+// and plans to store.
+func NewServerWithStore(cfg SimulationConfig, store ScrollStore) *Server {
+	s := &Server{
+		store:    store,
+		loops:    NewLoopRegistry(time.Duration(cfg.LoopRetention)),
+		idem:     newIdempotencyCache(time.Duration(cfg.IdempotencyTTL)),
+		metrics:  NewMetrics(),
+		queue:    newScrollQueue(),
+		stats:    newStatsCache(time.Duration(cfg.StatsCacheTTL)),
+		logger:   slog.Default(),
+		webhooks: make(chan WebhookEvent, webhookBuffer),
+		tracer:   noopTracer(),
+	}
+	s.active.Store(newActiveConfig(cfg))
+	s.flares = newEventHub(func() { s.metrics.Inc("flare_events_dropped_total") })
+	s.metrics.Gauge("plan_cache_entries", func() float64 { return float64(s.active.Load().plans.len()) })
+	s.metrics.Gauge("async_queue_depth", func() float64 { return float64(s.queue.len()) })
+	s.metrics.Gauge("flare_event_subscribers", func() float64 { return float64(s.flares.len()) })
+	s.metrics.Gauge("scoring_breaker_state", func() float64 {
+		if b := s.active.Load().engine().breaker; b != nil {
+			return float64(b.State())
+		}
+		return float64(BreakerClosed)
+	})
+	return s
+}
+
+// simulateRequest is the body of a simulation request: a scroll plus
+// optional per-request settings.
+type simulateRequest struct {
+	types.Scroll
+	WeightOverrides map[string]MarkerWeight `json:"weight_overrides,omitempty"`
+	// Tenant is taken from the request header, never the body.
+	Tenant string `json:"-"`
+	// assignedID is set when the scroll arrived without an ID and was
+	// given one.
+	assignedID bool
+}
+
+// validate checks the scroll and any weight overrides.
+func (req simulateRequest) validate() error {
+	if err := req.Scroll.Validate(); err != nil {
+		return err
+	}
+	markers := make([]string, 0, len(req.WeightOverrides))
+	for m := range req.WeightOverrides {
+		markers = append(markers, m)
+	}
+	sort.Strings(markers)
+	for _, m := range markers {
+		if err := validateWeight("weight_overrides."+m, req.WeightOverrides[m]); err != nil {
+			var cerr *ConfigError
+			if errors.As(err, &cerr) {
+				return &types.ValidationError{Field: cerr.Key, Message: cerr.Message}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// simulate returns the plan for req, serving it from the plan cache when
+// identical content has been simulated before. Requests carrying weight
+// overrides bypass the cache, as do all requests while trust decays or
+// scoring reads the tenant's marker IDF. Every plan, cached or not, gets its
+// own mutation loop.
+func (s *Server) simulate(ctx context.Context, req simulateRequest) (types.GeneInterventionPlan, error) {
+	active := s.active.Load()
+	engine := s.engineFor(active, req.Tenant, req.WeightOverrides)
+	if len(req.WeightOverrides) > 0 || active.cfg.TrustDecay.Kind != "" || active.cfg.ScoringKind == ScoringIDF {
+		plan, err := engine.simulate(ctx, req.Scroll)
+		if err != nil {
+			return plan, err
+		}
+		s.loops.StartFor(req.Tenant, req.ID, plan.MutationLoopID)
+		return plan, nil
+	}
+
+	key, err := scrollContentHash(req.Scroll)
+	if err != nil {
+		return types.GeneInterventionPlan{}, err
+	}
+	if plan, ok := active.plans.get(key); ok {
+		s.metrics.Inc("plan_cache_hits_total")
+		plan.MutationLoopID = active.cfg.loopIDs().NextLoopID(plan.Branch)
+		s.loops.StartFor(req.Tenant, req.ID, plan.MutationLoopID)
+		return plan, nil
+	}
+	s.metrics.Inc("plan_cache_misses_total")
+
+	plan, err := engine.simulate(ctx, req.Scroll)
+	if err != nil {
+		return plan, err
+	}
+	s.loops.StartFor(req.Tenant, req.ID, plan.MutationLoopID)
+	// An unscored plan is only what fit this request's budget.
+	if timedOutStage(plan) == "" {
+		active.plans.put(key, plan)
+	}
+	return plan, nil
+}
+
+// simulateAndRecord runs req as POST /simulate does: it simulates, persists
+// the plan (or degrades if the store fails), and fires the webhook, with
+// scoring and then the webhook sharing the request budget. Streamed scrolls
+// take the same path, each with a budget of its own.
+func (s *Server) simulateAndRecord(ctx context.Context, req simulateRequest) (simulateResponse, *requestError) {
+	bctx, cancel := withBudget(ctx, time.Duration(s.config().RequestBudget))
+	defer cancel()
+	plan, err := s.simulate(bctx, req)
+	if err != nil {
+		recordSpanError(trace.SpanFromContext(ctx), err)
+		return simulateResponse{}, simulationError(err)
+	}
+	persisted, err := s.persistOrDegrade(ctx, req.Tenant, req.Scroll, plan)
+	if err != nil {
+		return simulateResponse{}, persistError(err)
+	}
+
+	resp := simulateResponse{GeneInterventionPlan: plan, TimedOutStage: timedOutStage(plan), Persisted: persisted}
+	if req.assignedID {
+		resp.ScrollID = req.ID
+	}
+	if s.notify(bctx, req.Tenant, req.ID, plan) && resp.TimedOutStage == "" {
+		resp.TimedOutStage = StageWebhook
+	}
+	return resp, nil
+}
+
+func (s *Server) simulateHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed", "")
+		return
+	}
+	ctx, span := s.startRequestSpan(r, "POST /simulate")
+	defer span.End()
+	r = r.WithContext(ctx)
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	span.SetAttributes(attrTenant.String(tenant))
+	if isNDJSON(r) {
+		s.simulateStream(w, r, tenant)
+		return
+	}
+	mt, ok := negotiateOr406(w, r)
+	if !ok {
+		return
+	}
+
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	// A retried request carrying the same Idempotency-Key replays the
+	// original plan rather than simulating (and firing side effects) again;
+	// one arriving while the original is still running gets a 409. Keys are
+	// scoped to the tenant.
+	key := r.Header.Get("Idempotency-Key")
+	bodyHash := canonicalBodyHash(raw)
+	if key != "" {
+		key = tenant + "\x00" + key
+		entry, res := s.idem.claim(key)
+		switch res {
+		case claimBusy:
+			writeError(w, http.StatusConflict, CodeIdempotencyBusy,
+				"a request with this idempotency key is still in progress", "")
+			return
+		case claimReplay:
+			if entry.bodyHash != bodyHash {
+				writeError(w, http.StatusUnprocessableEntity, CodeIdempotencyConflict,
+					"idempotency key reused with a different body", "")
+				return
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			// Replays are cached as JSON; re-encode for an XML client.
+			var cached simulateResponse
+			if mt == xmlContentType && json.Unmarshal(entry.body, &cached) == nil {
+				writeNegotiated(w, mt, "plan", entry.status, cached)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(entry.status)
+			_, _ = w.Write(entry.body)
+			return
+		}
+		// Claimed: a request that fails records nothing and frees the key.
+		defer s.idem.release(key)
+	}
+
+	req, ok := s.decodeSimulateRequest(w, raw, tenant)
+	if !ok {
+		return
+	}
+	span.SetAttributes(scrollAttrs(req.Scroll)...)
+
+	resp, rerr := s.simulateAndRecord(r.Context(), req)
+	if rerr != nil {
+		rerr.write(w)
+		return
+	}
+	span.SetAttributes(attrBranch.String(resp.Branch))
+	if resp.ScrollID != "" {
+		w.Header().Set("Location", "/scrolls/"+url.PathEscape(resp.ScrollID))
+	}
+	if r.URL.Query().Get("include_config") == "true" {
+		resp.ConfigSnapshot = newConfigSnapshot(s.active.Load().engine(), req.WeightOverrides)
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "encode plan: "+err.Error(), "")
+		return
+	}
+	body = append(body, '\n')
+	if key != "" {
+		s.idem.store(key, bodyHash, http.StatusOK, body)
+	}
+
+	if mt == xmlContentType {
+		writeNegotiated(w, mt, "plan", http.StatusOK, resp)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// decodeSimulateRequest decodes, validates, and verifies a scroll submitted
+// for tenant, stamping it with the receive time if it carries none. On
+// failure it writes the error response and reports false.
+func (s *Server) decodeSimulateRequest(w http.ResponseWriter, raw []byte, tenant string) (simulateRequest, bool) {
+	req, rerr := s.parseSimulateRequest(raw, tenant)
+	if rerr != nil {
+		rerr.write(w)
+		return req, false
+	}
+	return req, true
+}
+
+// parseSimulateRequest is decodeSimulateRequest without the response: it
+// returns the error that should be reported instead. Scrolls in an older
+// schema version are migrated to the current shape.
+func (s *Server) parseSimulateRequest(raw []byte, tenant string) (simulateRequest, *requestError) {
+	req := simulateRequest{Tenant: tenant}
+	var doc map[string]json.RawMessage
+	if err := decodeBody(bytes.NewReader(raw), &doc); err != nil {
+		return req, decodeError(err)
+	}
+	// Clients still sending an older schema version are upgraded, and the
+	// result checked against the published schema, before it is decoded.
+	upgraded, err := upgradeScrollDoc(doc, s.config().FlarePrecedence)
+	var vErr *types.ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		return req, validationError(err)
+	case err != nil:
+		return req, decodeError(err)
+	}
+	// Read the ID first so a scroll that fails the schema can be named.
+	_ = json.Unmarshal(doc["id"], &req.ID)
+	violations, err := validateScrollSchema(upgraded)
+	if err != nil {
+		return req, decodeError(err)
+	}
+	if len(violations) > 0 {
+		return req, schemaError(violations)
+	}
+	if err := json.Unmarshal(upgraded, &req); err != nil {
+		return req, decodeError(err)
+	}
+	if err := req.validate(); err != nil {
+		return req, validationError(err)
+	}
+	// Overrides reweight the configured marker weights, so a custom or
+	// external scorer would silently ignore them.
+	if len(req.WeightOverrides) > 0 && !s.config().weightedScoring() {
+		return req, validationError(&types.ValidationError{
+			Field:   "weight_overrides",
+			Message: "weight overrides need the built-in weighted scoring; this server scores with a custom or external strategy",
+		})
+	}
+
+	if key := s.config().ScrollSigningKey; key != "" {
+		if err := VerifyScroll(req.Scroll, []byte(key)); err != nil {
+			return req, newRequestError(http.StatusUnauthorized, CodeInvalidSignature, err.Error(), "signature")
+		}
+	}
+	// Tags are normalized after verification so the signature covers them
+	// as sent.
+	tags, err := NormalizeTags(req.Tags)
+	if err != nil {
+		return req, validationError(err)
+	}
+	req.Tags = tags
+	if req.ID == "" {
+		id, err := assignScrollID(s.config().ScrollIDs, req.Scroll)
+		if err != nil {
+			return req, newRequestError(http.StatusInternalServerError, CodeInternal, "assign scroll ID: "+err.Error(), "")
+		}
+		req.ID, req.assignedID = id, true
+	}
+	req.Version, req.Frozen = 1, false
+	if req.Timestamp.IsZero() {
+		req.Timestamp = time.Now().UTC()
+	}
+	return req, nil
+}
+
+// persist stores a simulated scroll and its plan for tenant, appends the
+// decision to the audit log, and announces flare plans to the tenant's event
+// subscribers. Each write is traced as a child of the span in ctx. The
+// plan's mutation loop runs while it is recorded and then settles:
+// converged if every write succeeded, diverged otherwise.
+func (s *Server) persist(ctx context.Context, tenant string, scroll types.Scroll, plan types.GeneInterventionPlan) (err error) {
+	_, _ = s.loops.Advance(plan.MutationLoopID)
+	defer func() { _, _ = s.loops.Settle(plan.MutationLoopID, err == nil) }()
+	if err := tracedWrite(ctx, "SaveScroll", scroll.ID, func() error {
+		return s.store.SaveScroll(tenant, scroll)
+	}); err != nil {
+		return err
+	}
+	if err := tracedWrite(ctx, "SavePlan", scroll.ID, func() error {
+		return s.store.SavePlan(tenant, scroll.ID, plan)
+	}); err != nil {
+		return err
+	}
+	if err := tracedWrite(ctx, "AppendAudit", scroll.ID, func() error {
+		return s.store.AppendAudit(tenant, newAuditEntry(scroll, plan, s.active.Load().engine(), time.Now().UTC()))
+	}); err != nil {
+		return err
+	}
+	s.publishFlare(tenant, scroll, plan)
+	return nil
+}
+
+// planDiffRequest names the two plans to compare, either inline or by the ID
+// of the scroll whose stored plan should be used.
+type planDiffRequest struct {
+	Before   *types.GeneInterventionPlan `json:"before,omitempty"`
+	After    *types.GeneInterventionPlan `json:"after,omitempty"`
+	BeforeID string                      `json:"before_id,omitempty"`
+	AfterID  string                      `json:"after_id,omitempty"`
+}
+
+func (s *Server) planDiffHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	var req planDiffRequest
+	if err := decodeBody(r.Body, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+
+	before, err := s.resolvePlan(tenant, req.Before, req.BeforeID, "before")
+	if err != nil {
+		writePlanLookupError(w, err, "before_id")
+		return
+	}
+	after, err := s.resolvePlan(tenant, req.After, req.AfterID, "after")
+	if err != nil {
+		writePlanLookupError(w, err, "after_id")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(DiffPlans(before, after))
+}
+
+var errMissingOperand = errors.New("missing plan operand")
+
+// resolvePlan returns the inline plan if given, otherwise tenant's stored
+// plan for id. side names the operand in error messages.
+func (s *Server) resolvePlan(tenant string, inline *types.GeneInterventionPlan, id, side string) (types.GeneInterventionPlan, error) {
+	if inline != nil {
+		return *inline, nil
+	}
+	if id == "" {
+		return types.GeneInterventionPlan{}, fmt.Errorf("%w: need %s or %s_id", errMissingOperand, side, side)
+	}
+	plan, err := s.store.GetPlan(tenant, id)
+	if errors.Is(err, ErrNotFound) {
+		return plan, fmt.Errorf("no stored plan for %s_id %q: %w", side, id, err)
+	}
+	return plan, err
+}
+
+func writePlanLookupError(w http.ResponseWriter, err error, field string) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, CodeNotFound, err.Error(), field)
+	case errors.Is(err, errMissingOperand):
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, err.Error(), field)
+	default:
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+	}
+}
+
+// ScrollPage is one page of a scroll listing along with the total number of
+// scrolls that matched before paging.
+type ScrollPage struct {
+	Scrolls []types.Scroll `json:"scrolls"`
+	Total   int            `json:"total"`
+}
+
+const defaultPageLimit = 50
+
+// parsePage reads the limit and offset query parameters, writing a 400 and
+// returning false if either is malformed.
+func parsePage(w http.ResponseWriter, r *http.Request) (ScrollQuery, bool) {
+	q := ScrollQuery{Limit: defaultPageLimit}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &q.Limit}, {"offset", &q.Offset}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, CodeInvalidInput, p.name+" must be a non-negative integer", p.name)
+			return q, false
+		}
+		*p.dst = n
+	}
+	return q, true
+}
+
+// parseTimeRange reads the from and to RFC 3339 query parameters into q,
+// writing a 400 and returning false if either is malformed or from > to.
+func parseTimeRange(w http.ResponseWriter, r *http.Request, q *ScrollQuery) bool {
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"from", &q.From}, {"to", &q.To}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, CodeInvalidInput, p.name+" must be an RFC 3339 timestamp", p.name)
+			return false
+		}
+		*p.dst = t
+	}
+	if !q.From.IsZero() && !q.To.IsZero() && q.From.After(q.To) {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "from must not be after to", "from")
+		return false
+	}
+	return true
+}
+
+// parseTags reads the repeated tag query parameter into q, writing a 400
+// and returning false if a tag is invalid.
+func parseTags(w http.ResponseWriter, r *http.Request, q *ScrollQuery) bool {
+	tags, err := NormalizeTags(r.URL.Query()["tag"])
+	var vErr *types.ValidationError
+	if errors.As(err, &vErr) {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, vErr.Message, "tag")
+		return false
+	}
+	q.Tags = tags
+	return true
+}
+
+func (s *Server) listScrollsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	q, ok := parsePage(w, r)
+	if !ok || !parseTimeRange(w, r, &q) || !parseTags(w, r, &q) {
+		return
+	}
+
+	scrolls, err := s.store.ListScrolls(tenant, q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+	total, err := s.store.CountScrolls(tenant, q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ScrollPage{Scrolls: scrolls, Total: total})
+}
+
+func (s *Server) searchScrollsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	markers := r.URL.Query()["marker"]
+	if len(markers) == 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "at least one marker parameter is required", "marker")
+		return
+	}
+	q, ok := parsePage(w, r)
+	if !ok {
 		return
 	}
 
-	var scroll types.Scroll
-	if err := json.NewDecoder(r.Body).Decode(&scroll); err != nil {
-		http.Error(w, "invalid input", http.StatusBadRequest)
+	all, err := s.store.ListScrolls(tenant, ScrollQuery{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
 		return
 	}
+	reg := s.active.Load().engine().reg
+	matched := []types.Scroll{}
+	for _, scroll := range all {
+		if reg.ContainsAll(scroll.GeneticMarkers, markers) {
+			matched = append(matched, scroll)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ScrollPage{Scrolls: q.page(matched), Total: len(matched)})
+}
 
-	result := StartScrollSimulation(scroll)
+func (s *Server) bulkCompostHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Get("confirm") != "true" {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "bulk compost requires confirm=true", "confirm")
+		return
+	}
+	var filter CompostFilter
+	if err := decodeBody(r.Body, &filter); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	if filter.empty() {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "filter must set max_trust or older_than", "")
+		return
+	}
+	if filter.Reason != "" && !filter.Reason.Valid() {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, fmt.Sprintf("unknown compost reason %q", filter.Reason), "reason")
+		return
+	}
+
+	res, err := BulkCompost(s.store, tenant, filter, time.Now().UTC())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+	for _, o := range res.Composted {
+		s.metrics.Inc(compostSeries(o.Reason))
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(result)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (s *Server) cooccurrenceHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	minSupport := 1
+	if v := r.URL.Query().Get("min_support"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, CodeInvalidInput, "min_support must be a positive integer", "min_support")
+			return
+		}
+		minSupport = n
+	}
+
+	scrolls, err := s.store.ListScrolls(tenant, ScrollQuery{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(MarkerCooccurrence(scrolls, minSupport))
+}
+
+func (s *Server) topMarkersHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	k := 20
+	if v := r.URL.Query().Get("k"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, CodeInvalidInput, "k must be a positive integer", "k")
+			return
+		}
+		k = n
+	}
+
+	ranked, err := TopReliefMarkers(s.store, tenant, k)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(ranked)
+}
+
+func (s *Server) getScrollHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	mt, ok := negotiateOr406(w, r)
+	if !ok {
+		return
+	}
+	scroll, err := s.store.GetScroll(tenant, r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "scroll not found", "id")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	writeNegotiated(w, mt, "scroll", http.StatusOK, scroll)
+}
+
+// LoopPage is one page of a loop listing and how many loops matched in
+// all.
+type LoopPage struct {
+	Loops []MutationLoop `json:"loops"`
+	Total int            `json:"total"`
+}
+
+// listLoopsHandler lists the tenant's mutation loops, most recently
+// transitioned first, optionally only those in ?state or started by
+// ?scroll_id. A loop is running while its plan is persisted, then
+// converged, or diverged if persisting failed.
+func (s *Server) listLoopsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	page, ok := parsePage(w, r)
+	if !ok {
+		return
+	}
+	q := LoopQuery{
+		Tenant:   tenant,
+		ScrollID: r.URL.Query().Get("scroll_id"),
+		State:    LoopState(r.URL.Query().Get("state")),
+		Limit:    page.Limit,
+		Offset:   page.Offset,
+	}
+	if q.State != "" && !q.State.Valid() {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, fmt.Sprintf("unknown loop state %q", q.State), "state")
+		return
+	}
+	loops, total := s.loops.List(q)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(LoopPage{Loops: loops, Total: total})
+}
+
+// loopHandler returns one of the tenant's mutation loops. Another tenant's
+// loop is reported as not found, so loop IDs cannot be probed across tenants.
+func (s *Server) loopHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	loop, ok := s.loops.Get(r.PathValue("id"))
+	if !ok || loop.Tenant != tenant {
+		writeError(w, http.StatusNotFound, CodeNotFound, "loop not found", "id")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(loop)
+}
+
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = s.metrics.WritePrometheus(w)
+}
+
+func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	stats, err := s.stats.get(tenant, func() (StoreStats, error) {
+		return ComputeStats(s.store, tenant, s.active.Load().engine().reg)
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, "compute stats: "+err.Error(), "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stats)
 }
 
 func healthHandler(w http.ResponseWriter, r *http.Request) {
@@ -35,33 +767,198 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 func schemaHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
+		"tenant_header": TenantHeader,
 		"endpoints": map[string]any{
+			"/admin/maintenance": map[string]string{
+				"method": "POST",
+				"desc":   "turn maintenance mode on or off with {enabled}; requires Authorization: Bearer <admin_token>. In maintenance, simulations and other writes return 503 with Retry-After and /readyz returns 503",
+			},
+			"/admin/reload": map[string]string{
+				"method": "POST",
+				"desc":   "re-read the config file and swap it in for subsequent simulations; requires Authorization: Bearer <admin_token>; 422 if invalid",
+			},
+			"/admin/replay": map[string]string{
+				"method": "POST",
+				"desc":   "re-simulate stored scrolls carrying every one of {markers} under the active config with {config} applied over it, and summarize branch changes without persisting; requires Authorization: Bearer <admin_token>; Accept: application/x-ndjson streams progress",
+			},
+			"/analysis/cooccurrence": map[string]string{
+				"method": "GET",
+				"desc":   "marker pairs co-occurring in at least ?min_support scrolls",
+			},
+			"/analysis/top-markers": map[string]string{
+				"method": "GET",
+				"desc":   "the ?k (default 20) targeted genes with the most predicted relief attributed to them, summed across stored plans",
+			},
+			"/audit": map[string]string{
+				"method": "GET",
+				"desc":   "append-only decision audit trail for ?scroll_id",
+			},
+			"/events/flares": map[string]string{
+				"method": "GET",
+				"desc":   "Server-Sent Events stream of flare-branch plans as they are produced",
+			},
 			"/health": map[string]string{
 				"method": "GET",
 				"desc":   "service health check",
 			},
+			"/readyz": map[string]string{
+				"method": "GET",
+				"desc":   "200 once warm-up has compiled the active config, 503 before",
+			},
+			"/scrolls": map[string]string{
+				"method": "GET",
+				"desc":   "stored scrolls, optionally within ?from&to (RFC 3339) and carrying every ?tag, paged by ?limit&offset",
+			},
+			"/scrolls/compost": map[string]string{
+				"method": "POST",
+				"desc":   "compost stored scrolls matching {max_trust, older_than}, recorded under an optional reason (low_trust, drift, expired, manual); requires ?confirm=true; frozen scrolls are reported as failed",
+			},
+			"/scrolls/search": map[string]string{
+				"method": "GET",
+				"desc":   "stored scrolls carrying every ?marker (canonicalized), paged by ?limit&offset",
+			},
+			"/scrolls/{id}": map[string]string{
+				"method": "GET, PATCH",
+				"desc":   "a stored scroll by ID (Accept: application/xml returns XML); PATCH applies a JSON merge patch, re-simulates, and stores the scroll as a new version, returning it with its plan",
+			},
+			"/scrolls/{id}/freeze": map[string]string{
+				"method": "POST",
+				"desc":   "freeze a stored scroll once its plan has been acted on; PATCH, re-simulation, tag changes, and compost of a frozen scroll fail with 409",
+			},
+			"/scrolls/{id}/unfreeze": map[string]string{
+				"method": "POST",
+				"desc":   "unfreeze a frozen scroll; requires the admin bearer token",
+			},
+			"/scrolls/{id}/lineage": map[string]string{
+				"method": "GET",
+				"desc":   "ancestry of a scroll via parent_id links, oldest first",
+			},
+			"/scrolls/{id}/similar": map[string]string{
+				"method": "GET",
+				"desc":   "the ?top (default 10) stored scrolls most similar to a scroll by Jaccard similarity of their canonical markers",
+			},
+			"/scrolls/{id}/tags": map[string]string{
+				"method": "POST",
+				"desc":   "add {tags} to a stored scroll; tags are trimmed, lowercased, and deduplicated",
+			},
+			"/scrolls/{id}/tags/{tag}": map[string]string{
+				"method": "DELETE",
+				"desc":   "remove a tag from a stored scroll",
+			},
 			"/simulate": map[string]string{
 				"method": "POST",
-				"desc":   "run scroll simulation and return a GeneInterventionPlan",
+				"desc":   "run scroll simulation and return a GeneInterventionPlan; honors Idempotency-Key (409 while a request with the same key is in progress) and optional weight_overrides (400 unless scoring is the built-in weighted strategy); ?include_config=true attaches the config_snapshot used; an application/x-ndjson body streams one plan per scroll line; Accept: application/xml returns XML; timed_out_stage names the stage the request_budget cut short",
+			},
+			"/simulate/async": map[string]string{
+				"method": "POST",
+				"desc":   "queue a scroll for background simulation (flares first, then by trust); returns 202",
+			},
+			"/simulate/explain": map[string]string{
+				"method": "POST",
+				"desc":   "decide a scroll as /simulate would, without scoring or storing it, and return only the branch and explanation",
+			},
+			"/loops": map[string]string{
+				"method": "GET",
+				"desc":   "the tenant's mutation loops, most recently transitioned first, optionally filtered by ?state (running while the plan persists, then converged or diverged) and ?scroll_id, paged by ?limit&offset",
+			},
+			"/loops/{id}": map[string]string{
+				"method": "GET",
+				"desc":   "inspect the current state of one of the tenant's mutation loops",
+			},
+			"/metrics": map[string]string{
+				"method": "GET",
+				"desc":   "Prometheus text-format counters and gauges",
+			},
+			"/plans/diff": map[string]string{
+				"method": "POST",
+				"desc":   "diff two plans given inline or by scroll ID",
+			},
+			"/stats": map[string]string{
+				"method": "GET",
+				"desc":   "store summary: totals, trigger counts, compost, rebirth, mean trust, top markers",
 			},
 			"/schema": map[string]string{
 				"method": "GET",
 				"desc":   "self-description of the service",
 			},
+			"/schema/scroll": map[string]string{
+				"method": "GET",
+				"desc":   "the JSON Schema every submitted scroll is validated against; violations are listed in the error's violations",
+			},
 		},
 		"types": map[string]any{
 			"Scroll":               "core/shared/types.Scroll",
 			"GeneInterventionPlan": "core/shared/types.GeneInterventionPlan",
+			"MutationLoop":         "core/scroll_engine.MutationLoop",
 		},
 	})
 }
 
-func StartServer(addr string) error {
+// Handler returns the routed HTTP handler for the server.
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/health", healthHandler)
+	mux.HandleFunc("GET /readyz", s.readyzHandler)
 	mux.HandleFunc("/schema", schemaHandler)
-	mux.HandleFunc("/simulate", simulateHandler)
+	mux.HandleFunc("GET /schema/scroll", scrollSchemaHandler)
+	mux.HandleFunc("/simulate", s.drained(s.simulateHandler))
+	mux.HandleFunc("POST /simulate/async", s.drained(s.asyncSimulateHandler))
+	mux.HandleFunc("POST /simulate/explain", s.explainHandler)
+	mux.HandleFunc("GET /loops", s.listLoopsHandler)
+	mux.HandleFunc("GET /loops/{id}", s.loopHandler)
+	mux.HandleFunc("POST /plans/diff", s.planDiffHandler)
+	mux.HandleFunc("GET /analysis/cooccurrence", s.cooccurrenceHandler)
+	mux.HandleFunc("GET /analysis/top-markers", s.topMarkersHandler)
+	mux.HandleFunc("GET /scrolls", s.listScrollsHandler)
+	mux.HandleFunc("GET /scrolls/search", s.searchScrollsHandler)
+	mux.HandleFunc("GET /scrolls/{id}", s.getScrollHandler)
+	mux.HandleFunc("PATCH /scrolls/{id}", s.drained(s.patchScrollHandler))
+	mux.HandleFunc("GET /scrolls/{id}/lineage", s.lineageHandler)
+	mux.HandleFunc("GET /scrolls/{id}/similar", s.similarScrollsHandler)
+	mux.HandleFunc("POST /scrolls/{id}/freeze", s.drained(s.freezeHandler))
+	mux.HandleFunc("POST /scrolls/{id}/unfreeze", s.drained(s.unfreezeHandler))
+	mux.HandleFunc("POST /scrolls/{id}/tags", s.drained(s.addTagsHandler))
+	mux.HandleFunc("DELETE /scrolls/{id}/tags/{tag}", s.drained(s.removeTagHandler))
+	mux.HandleFunc("POST /scrolls/compost", s.drained(s.bulkCompostHandler))
+	mux.HandleFunc("GET /metrics", s.metricsHandler)
+	mux.HandleFunc("GET /stats", s.statsHandler)
+	mux.HandleFunc("GET /audit", s.auditHandler)
+	mux.HandleFunc("GET /events/flares", s.flareEventsHandler)
+	mux.HandleFunc("POST /admin/reload", s.reloadHandler)
+	mux.HandleFunc("POST /admin/maintenance", s.maintenanceHandler)
+	mux.HandleFunc("POST /admin/replay", s.adminReplayHandler)
+	cfg := s.config()
+	h := limitBodies(cfg.MaxBodyBytes, cfg.MaxStreamBodyBytes, mux)
+	h = recoverPanics(s.logger, s.metrics, h)
+	if cfg.GzipMinBytes > 0 {
+		h = compress(cfg.GzipMinBytes, h)
+	}
+	if cfg.AccessLog {
+		return accessLog(s.logger, h)
+	}
+	return h
+}
+
+// StartServer serves the scroll engine API on addr using cfg, persisting to
+// the store cfg.Store selects. If cfg was loaded from configPath, POST
+// /admin/reload re-reads it.
+func StartServer(addr string, cfg SimulationConfig, configPath string) error {
+	store, err := OpenStore(cfg.Store)
+	if err != nil {
+		return fmt.Errorf("open store: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	log.Printf("Scroll Engine API listening on %s", addr)
-	return http.ListenAndServe(addr, mux)
+	srv := NewServerWithStore(cfg, store)
+	srv.SetConfigPath(configPath)
+	srv.StartWorkers(ctx, cfg.AsyncWorkers)
+	srv.StartCompostDecay(ctx, time.Duration(cfg.CompostDecayInterval), time.Duration(cfg.CompostRetention))
+	srv.StartIDFRefresh(ctx, time.Duration(cfg.IDFRefreshInterval))
+	if cfg.CompostRebirth {
+		srv.StartCompostRebirth(ctx, time.Duration(cfg.CompostRebirthInterval))
+	}
+	srv.Warmup()
+	log.Printf("Scroll Engine API listening on %s (store: %s)", addr, cfg.Store.Driver)
+	return http.ListenAndServe(addr, srv.Handler())
 }
@@ -1,11 +1,24 @@
 package scroll_engine
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
 
 	"Maple-OS/modem_os/core/shared/types"
 )
 
+func mustSimulate(t *testing.T, scroll types.Scroll, cfg SimulationConfig) types.GeneInterventionPlan {
+	t.Helper()
+	out, err := SimulateWithConfig(context.Background(), scroll, cfg)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	return out
+}
+
 func TestStartScrollSimulation_LowTrustNoMarkers(t *testing.T) {
 	scroll := types.Scroll{
 		ID:             "test_low_trust",
@@ -14,7 +27,10 @@ func TestStartScrollSimulation_LowTrustNoMarkers(t *testing.T) {
 		GeneticMarkers: []string{},
 	}
 
-	out := StartScrollSimulation(scroll)
+	out, err := StartScrollSimulation(context.Background(), scroll)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
 
 	if out.TrustAligned {
 		t.Fatalf("expected TrustAligned=false for low trust")
@@ -22,8 +38,8 @@ func TestStartScrollSimulation_LowTrustNoMarkers(t *testing.T) {
 	if !out.RequiredRecalibrate {
 		t.Fatalf("expected RequiredRecalibrate=true when low trust or missing markers")
 	}
-	if out.MutationLoopID != "discovery_loop" {
-		t.Fatalf("expected discovery_loop, got %q", out.MutationLoopID)
+	if out.Branch != BranchDiscovery {
+		t.Fatalf("expected discovery branch, got %q", out.Branch)
 	}
 }
 
@@ -35,7 +51,10 @@ func TestStartScrollSimulation_FlareMarkersHighTrust(t *testing.T) {
 		GeneticMarkers: []string{"g1", "g2"},
 	}
 
-	out := StartScrollSimulation(scroll)
+	out, err := StartScrollSimulation(context.Background(), scroll)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
 
 	if !out.TrustAligned {
 		t.Fatalf("expected TrustAligned=true for high trust")
@@ -43,7 +62,100 @@ func TestStartScrollSimulation_FlareMarkersHighTrust(t *testing.T) {
 	if out.RequiredRecalibrate {
 		t.Fatalf("expected RequiredRecalibrate=false for high trust + markers")
 	}
-	if out.MutationLoopID != "flare_mutation_loop" {
-		t.Fatalf("expected flare_mutation_loop, got %q", out.MutationLoopID)
+	if out.Branch != BranchFlare {
+		t.Fatalf("expected flare branch, got %q", out.Branch)
+	}
+}
+
+func TestSimulateWithConfig_InjectedLoopIDs(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LoopIDs = &CounterLoopIDGenerator{}
+	scroll := types.Scroll{ID: "s", TrustScore: 0.92, IsFlareEvent: true, GeneticMarkers: []string{"g1"}}
+
+	first := mustSimulate(t, scroll, cfg)
+	second := mustSimulate(t, scroll, cfg)
+
+	if first.MutationLoopID != "flare-1" || second.MutationLoopID != "flare-2" {
+		t.Fatalf("expected flare-1, flare-2; got %q, %q", first.MutationLoopID, second.MutationLoopID)
+	}
+}
+
+func TestSimulateWithConfig_DefaultLoopIDsUnique(t *testing.T) {
+	scroll := types.Scroll{ID: "s", TrustScore: 0.1}
+
+	a := mustSimulate(t, scroll, DefaultConfig()).MutationLoopID
+	b := mustSimulate(t, scroll, DefaultConfig()).MutationLoopID
+
+	if a == b {
+		t.Fatalf("expected distinct loop IDs, both were %q", a)
+	}
+	if !strings.HasPrefix(a, BranchDiscovery+"-") {
+		t.Fatalf("expected branch-prefixed ID, got %q", a)
+	}
+}
+
+func TestSimulate_DedupsCanonicalMarkers(t *testing.T) {
+	scroll := types.Scroll{ID: "d", TrustScore: 0.9, IsFlareEvent: true,
+		GeneticMarkers: []string{"nod2", "IL23R", "NOD2 ", "il23r", "ATG16L1"}}
+	plan := mustSimulate(t, scroll, DefaultConfig())
+	if got := strings.Join(plan.TargetedGenes, ","); got != "ATG16L1,IL23R,NOD2" {
+		t.Fatalf("expected deduped genes in alphabetical order, got %s", got)
+	}
+}
+
+func TestSimulate_TargetedGenesSortedOnEveryBranch(t *testing.T) {
+	markers := []string{"NOD2", "ATG16L1", "nod2", "IL23R", "ATG16L1"}
+	for _, scroll := range []types.Scroll{
+		{ID: "flare", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: markers},
+		{ID: "compost", TrustScore: 0.2, GeneticMarkers: markers},
+	} {
+		plan := mustSimulate(t, scroll, DefaultConfig())
+		if got := strings.Join(plan.TargetedGenes, ","); got != "ATG16L1,IL23R,NOD2" {
+			t.Fatalf("%s: expected sorted distinct genes, got %s", scroll.ID, got)
+		}
+	}
+	if plan := mustSimulate(t, types.Scroll{ID: "discovery", TrustScore: 0.2}, DefaultConfig()); plan.TargetedGenes == nil || len(plan.TargetedGenes) != 0 {
+		t.Fatalf("discovery: expected an empty gene list, got %#v", plan.TargetedGenes)
+	}
+}
+
+func TestSimulate_RejectsTooManyMarkers(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxMarkers = 2
+	scroll := types.Scroll{ID: "m", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"A", "a", "B"}}
+	if _, err := SimulateWithConfig(context.Background(), scroll, cfg); err != nil {
+		t.Fatalf("expected duplicates not to count toward the limit: %v", err)
+	}
+
+	scroll.GeneticMarkers = append(scroll.GeneticMarkers, "C")
+	_, err := SimulateWithConfig(context.Background(), scroll, cfg)
+	var vErr *types.ValidationError
+	if !errors.As(err, &vErr) || vErr.Field != "genetic_markers" {
+		t.Fatalf("expected a genetic_markers validation error, got %v", err)
+	}
+
+	rec := postSimulate(NewServer(cfg).Handler(), `{"id":"m","trust_score":0.9,"genetic_markers":["A","B","C"]}`, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 over the marker limit, got %d", rec.Code)
+	}
+}
+
+// BenchmarkSimulateDuplicateMarkers scores a scroll of 10,000 markers drawn
+// from 8 genes; dedup keeps the scoring work proportional to the 8.
+func BenchmarkSimulateDuplicateMarkers(b *testing.B) {
+	genes := []string{"NOD2", "IL23R", "ATG16L1", "TNFSF15", "nod2", "il23r", "atg16l1", "tnfsf15"}
+	markers := make([]string, 10000)
+	for i := range markers {
+		markers[i] = genes[i%len(genes)]
+	}
+	scroll := types.Scroll{ID: "dup", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: markers}
+	cfg := DefaultConfig()
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		plan, err := SimulateWithConfig(ctx, scroll, cfg)
+		if err != nil || len(plan.TargetedGenes) != 4 {
+			b.Fatalf("unexpected plan %v, %v", plan.TargetedGenes, err)
+		}
 	}
 }
@@ -0,0 +1,42 @@
+package scroll_engine
+
+// Flare severity labels.
+const (
+	SeverityMild     = "mild"
+	SeverityModerate = "moderate"
+	SeveritySevere   = "severe"
+)
+
+// SeverityBound is the minimum evidence for a flare to reach a severity
+// level. A flare reaches the level when it has at least MinMarkers matched
+// flare-panel markers, at least MinTrust trust, and a predicted flare
+// suppression of at most MaxSuppression — flares the intervention is
+// expected to suppress less well are treated as more severe.
+type SeverityBound struct {
+	MinMarkers     int     `json:"min_markers"`
+	MinTrust       float64 `json:"min_trust"`
+	MaxSuppression float64 `json:"max_suppression"`
+}
+
+func (b SeverityBound) reached(trust float64, matched int, suppression float64) bool {
+	return matched >= b.MinMarkers && trust >= b.MinTrust && suppression <= b.MaxSuppression
+}
+
+// FlareSeverityThresholds classifies a flare as severe if it reaches
+// Severe, otherwise moderate if it reaches Moderate, otherwise mild.
+type FlareSeverityThresholds struct {
+	Severe   SeverityBound `json:"severe"`
+	Moderate SeverityBound `json:"moderate"`
+}
+
+// ClassifyFlareSeverity labels a flare from the scroll's trust, the number
+// of markers that matched the flare panel, and the predicted suppression.
+func ClassifyFlareSeverity(trust float64, matched int, suppression float64, t FlareSeverityThresholds) string {
+	switch {
+	case t.Severe.reached(trust, matched, suppression):
+		return SeveritySevere
+	case t.Moderate.reached(trust, matched, suppression):
+		return SeverityModerate
+	}
+	return SeverityMild
+}
@@ -0,0 +1,51 @@
+package scroll_engine
+
+import (
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestClassifyFlareSeverity_Boundaries(t *testing.T) {
+	th := DefaultConfig().FlareSeverity
+	cases := []struct {
+		name        string
+		trust       float64
+		matched     int
+		suppression float64
+		want        string
+	}{
+		{"severe at every bound", 0.85, 3, 0.95, SeveritySevere},
+		{"severe trust just below", 0.8499, 3, 0.95, SeverityModerate},
+		{"severe markers just below", 0.95, 2, 0.5, SeverityModerate},
+		{"severe suppression just above", 0.95, 5, 0.9501, SeverityModerate},
+		{"moderate at every bound", 0.75, 2, 1, SeverityModerate},
+		{"moderate trust just below", 0.7499, 2, 0.5, SeverityMild},
+		{"moderate markers just below", 0.95, 1, 0.5, SeverityMild},
+	}
+	for _, c := range cases {
+		if got := ClassifyFlareSeverity(c.trust, c.matched, c.suppression, th); got != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, got)
+		}
+	}
+}
+
+func TestSimulate_FlareSeverityOnlyForFlares(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FlareMarkers = []string{"NOD2", "ATG16L1", "IL23R"}
+
+	flare := mustSimulate(t, types.Scroll{
+		ID: "f", TrustScore: 0.9, IsFlareEvent: true,
+		GeneticMarkers: []string{"nod2", "ATG16L1", "IL23R", "OTHER"},
+	}, cfg)
+	if flare.FlareSeverity != SeveritySevere {
+		t.Fatalf("expected severe flare, got %q", flare.FlareSeverity)
+	}
+
+	memory := mustSimulate(t, types.Scroll{
+		ID: "m", TrustScore: 0.9, GeneticMarkers: []string{"NOD2", "ATG16L1", "IL23R"},
+	}, cfg)
+	if memory.FlareSeverity != "" {
+		t.Fatalf("expected no severity for a non-flare scroll, got %q", memory.FlareSeverity)
+	}
+}
@@ -0,0 +1,60 @@
+package scroll_engine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+var (
+	ErrMissingSignature = errors.New("scroll signature missing")
+	ErrBadSignature     = errors.New("scroll signature mismatch")
+)
+
+// canonicalScroll serializes the scroll's fields in struct order with the
+// signature itself excluded. Because it is derived from the decoded struct,
+// the result does not depend on the key order of the JSON the scroll arrived
+// in. The schema version is excluded too, so migration on decode does not
+// invalidate a signature, and so is the server-assigned version.
+func canonicalScroll(scroll types.Scroll) ([]byte, error) {
+	scroll.Signature = ""
+	scroll.SchemaVersion = 0
+	scroll.Version = 0
+	return json.Marshal(scroll)
+}
+
+// SignScroll returns the hex-encoded HMAC-SHA256 of the scroll's canonical
+// serialization under key.
+func SignScroll(scroll types.Scroll, key []byte) (string, error) {
+	payload, err := canonicalScroll(scroll)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// VerifyScroll checks the scroll's Signature against key.
+func VerifyScroll(scroll types.Scroll, key []byte) error {
+	if scroll.Signature == "" {
+		return ErrMissingSignature
+	}
+	got, err := hex.DecodeString(scroll.Signature)
+	if err != nil {
+		return ErrBadSignature
+	}
+	want, err := SignScroll(scroll, key)
+	if err != nil {
+		return err
+	}
+	wantRaw, _ := hex.DecodeString(want)
+	if !hmac.Equal(got, wantRaw) {
+		return ErrBadSignature
+	}
+	return nil
+}
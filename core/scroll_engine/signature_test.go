@@ -0,0 +1,74 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+var testSigningKey = []byte("shared-secret")
+
+func signedScroll(t *testing.T) types.Scroll {
+	t.Helper()
+	scroll := types.Scroll{ID: "s1", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"g1"}}
+	sig, err := SignScroll(scroll, testSigningKey)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	scroll.Signature = sig
+	return scroll
+}
+
+func TestVerifyScroll_Valid(t *testing.T) {
+	if err := VerifyScroll(signedScroll(t), testSigningKey); err != nil {
+		t.Fatalf("expected valid signature, got %v", err)
+	}
+}
+
+func TestVerifyScroll_TamperedField(t *testing.T) {
+	scroll := signedScroll(t)
+	scroll.TrustScore = 0.99
+
+	if err := VerifyScroll(scroll, testSigningKey); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("expected ErrBadSignature for tampered scroll, got %v", err)
+	}
+}
+
+func TestVerifyScroll_Missing(t *testing.T) {
+	scroll := signedScroll(t)
+	scroll.Signature = ""
+
+	if err := VerifyScroll(scroll, testSigningKey); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("expected ErrMissingSignature, got %v", err)
+	}
+}
+
+func TestSignScroll_IndependentOfKeyOrder(t *testing.T) {
+	var a, b types.Scroll
+	_ = json.Unmarshal([]byte(`{"id":"s1","trust_score":0.9,"genetic_markers":["g1"]}`), &a)
+	_ = json.Unmarshal([]byte(`{"genetic_markers":["g1"],"trust_score":0.9,"id":"s1"}`), &b)
+
+	sa, _ := SignScroll(a, testSigningKey)
+	sb, _ := SignScroll(b, testSigningKey)
+	if sa != sb {
+		t.Fatalf("expected key order not to affect signature")
+	}
+}
+
+func TestSimulate_RejectsUnsignedScroll(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ScrollSigningKey = string(testSigningKey)
+	h := NewServer(cfg).Handler()
+
+	if rec := postSimulate(h, `{"id":"s1","trust_score":0.9}`, ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for unsigned scroll, got %d", rec.Code)
+	}
+
+	body, _ := json.Marshal(signedScroll(t))
+	if rec := postSimulate(h, string(body), ""); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 for signed scroll, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,104 @@
+package scroll_engine
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// defaultSimilarTop is how many similar scrolls /scrolls/{id}/similar
+// returns without ?top.
+const defaultSimilarTop = 10
+
+// SimilarScroll is a stored scroll and its similarity to the one compared.
+type SimilarScroll struct {
+	Scroll     types.Scroll `json:"scroll"`
+	Similarity float64      `json:"similarity"`
+}
+
+// SimilarScrolls returns up to top of candidates most similar to scroll by
+// the Jaccard similarity of their markers, canonicalized through reg: the
+// markers both carry over the markers either carries. They are ordered most
+// similar first, then by ID. Scroll itself, and scrolls sharing no marker
+// with it, are left out, so a scroll without markers has no similar ones.
+func SimilarScrolls(scroll types.Scroll, candidates []types.Scroll, reg *MarkerRegistry, top int) []SimilarScroll {
+	out := []SimilarScroll{}
+	markers := canonicalSet(scroll.GeneticMarkers, reg)
+	if len(markers) == 0 {
+		return out
+	}
+	for _, c := range candidates {
+		if c.ID == scroll.ID {
+			continue
+		}
+		other := canonicalSet(c.GeneticMarkers, reg)
+		shared := 0
+		for m := range other {
+			if markers[m] {
+				shared++
+			}
+		}
+		if shared == 0 {
+			continue
+		}
+		out = append(out, SimilarScroll{
+			Scroll:     c,
+			Similarity: float64(shared) / float64(len(markers)+len(other)-shared),
+		})
+	}
+	slices.SortFunc(out, func(a, b SimilarScroll) int {
+		if c := cmp.Compare(b.Similarity, a.Similarity); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Scroll.ID, b.Scroll.ID)
+	})
+	return out[:min(top, len(out))]
+}
+
+// canonicalSet returns the distinct canonical forms of markers.
+func canonicalSet(markers []string, reg *MarkerRegistry) map[string]bool {
+	set := make(map[string]bool, len(markers))
+	for _, m := range reg.CanonicalizeAll(markers) {
+		set[m] = true
+	}
+	return set
+}
+
+func (s *Server) similarScrollsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	top := defaultSimilarTop
+	if v := r.URL.Query().Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, CodeInvalidInput, "top must be a positive integer", "top")
+			return
+		}
+		top = n
+	}
+
+	scroll, err := s.store.GetScroll(tenant, r.PathValue("id"))
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "scroll not found", "id")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+	scrolls, err := s.store.ListScrolls(tenant, ScrollQuery{})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(SimilarScrolls(scroll, scrolls, s.active.Load().engine().reg, top))
+}
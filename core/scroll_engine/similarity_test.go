@@ -0,0 +1,86 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func getSimilar(t *testing.T, h http.Handler, path string) (int, []SimilarScroll) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, path, nil))
+	var similar []SimilarScroll
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&similar); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return rec.Code, similar
+}
+
+func similarityServer() http.Handler {
+	cfg := DefaultConfig()
+	cfg.MarkerAliases = map[string]string{"CARD15": "NOD2"}
+	srv := NewServer(cfg)
+	for _, s := range []types.Scroll{
+		{ID: "a", GeneticMarkers: []string{"NOD2", "IL23R", "ATG16L1"}},
+		{ID: "b", GeneticMarkers: []string{"card15", "IL23R", "ATG16L1"}}, // {NOD2, IL23R, ATG16L1}: 3/3
+		{ID: "c", GeneticMarkers: []string{"NOD2", "IL23R", "TNFSF15"}},   // 2/4
+		{ID: "d", GeneticMarkers: []string{"IL23R", "PTPN22"}},            // 1/4
+		{ID: "e", GeneticMarkers: []string{"PTPN22"}},                     // 0/4
+		{ID: "empty"},
+	} {
+		_ = srv.store.SaveScroll(testTenant, s)
+	}
+	return srv.Handler()
+}
+
+func TestSimilarScrolls_JaccardOrdering(t *testing.T) {
+	h := similarityServer()
+
+	code, similar := getSimilar(t, h, "/scrolls/a/similar")
+	if code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	want := []SimilarScroll{
+		{Scroll: types.Scroll{ID: "b"}, Similarity: 1},
+		{Scroll: types.Scroll{ID: "c"}, Similarity: 0.5},
+		{Scroll: types.Scroll{ID: "d"}, Similarity: 0.25},
+	}
+	if len(similar) != len(want) {
+		t.Fatalf("expected %d similar scrolls, got %+v", len(want), similar)
+	}
+	for i, w := range want {
+		if similar[i].Scroll.ID != w.Scroll.ID || math.Abs(similar[i].Similarity-w.Similarity) > 1e-9 {
+			t.Fatalf("rank %d: expected %s at %.2f, got %s at %.4f",
+				i, w.Scroll.ID, w.Similarity, similar[i].Scroll.ID, similar[i].Similarity)
+		}
+	}
+
+	if _, top := getSimilar(t, h, "/scrolls/a/similar?top=1"); len(top) != 1 || top[0].Scroll.ID != "b" {
+		t.Fatalf("expected ?top=1 to return only b, got %+v", top)
+	}
+}
+
+func TestSimilarScrolls_NoMarkers(t *testing.T) {
+	code, similar := getSimilar(t, similarityServer(), "/scrolls/empty/similar")
+	if code != http.StatusOK || similar == nil || len(similar) != 0 {
+		t.Fatalf("expected an empty list, got %d %+v", code, similar)
+	}
+}
+
+func TestSimilarScrolls_Errors(t *testing.T) {
+	h := similarityServer()
+
+	if code, _ := getSimilar(t, h, "/scrolls/missing/similar"); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown scroll, got %d", code)
+	}
+	if code, _ := getSimilar(t, h, "/scrolls/a/similar?top=0"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for ?top=0, got %d", code)
+	}
+}
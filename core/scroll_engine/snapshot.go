@@ -0,0 +1,64 @@
+package scroll_engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// ConfigSnapshot is the effective config a plan was produced under,
+// attached to a /simulate response when ?include_config=true is set.
+type ConfigSnapshot struct {
+	TrustThreshold float64  `json:"trust_threshold" xml:"trust_threshold"`
+	FlareMarkers   []string `json:"flare_markers" xml:"flare_markers>marker"`
+	// WeightsHash digests the marker weights scoring used, including any
+	// per-request overrides, keyed canonically.
+	WeightsHash string `json:"weights_hash" xml:"weights_hash"`
+	// ConfigHash is SimulationConfig.Hash of the active config, the same
+	// digest audit entries record.
+	ConfigHash string `json:"config_hash" xml:"config_hash"`
+}
+
+// simulateResponse is a plan with its optional config snapshot.
+type simulateResponse struct {
+	types.GeneInterventionPlan
+	ConfigSnapshot *ConfigSnapshot `json:"config_snapshot,omitempty" xml:"config_snapshot,omitempty"`
+	// TimedOutStage names the first stage the request budget cut short:
+	// StageScoring (the plan is unscored) or StageWebhook (delivery was
+	// deferred).
+	TimedOutStage string `json:"timed_out_stage,omitempty" xml:"timed_out_stage,omitempty"`
+	// ScrollID echoes the ID assigned to a scroll submitted without one.
+	ScrollID string `json:"scroll_id,omitempty" xml:"scroll_id,omitempty"`
+	// Persisted is false when the store failed and StoreFailOpen returned
+	// the plan without recording it.
+	Persisted bool `json:"persisted" xml:"persisted"`
+}
+
+func newConfigSnapshot(c *compiledConfig, overrides map[string]MarkerWeight) *ConfigSnapshot {
+	weights := make(map[string]MarkerWeight, len(c.cfg.MarkerWeights)+len(overrides))
+	for marker, w := range c.cfg.MarkerWeights {
+		weights[c.reg.Canonicalize(marker)] = w
+	}
+	for marker, w := range overrides {
+		weights[c.reg.Canonicalize(marker)] = w
+	}
+	// encoding/json sorts map keys, so equal weights always hash equally.
+	raw, _ := json.Marshal(struct {
+		Default MarkerWeight            `json:"default"`
+		Weights map[string]MarkerWeight `json:"weights"`
+	}{c.cfg.DefaultMarkerWeight, weights})
+	sum := sha256.Sum256(raw)
+
+	panel := []string{}
+	for _, m := range c.cfg.FlareMarkers {
+		panel = append(panel, c.reg.Canonicalize(m))
+	}
+	return &ConfigSnapshot{
+		TrustThreshold: c.cfg.TrustThreshold,
+		FlareMarkers:   targetGenes(panel),
+		WeightsHash:    hex.EncodeToString(sum[:]),
+		ConfigHash:     c.hash,
+	}
+}
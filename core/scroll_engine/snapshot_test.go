@@ -0,0 +1,64 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func simulateSnapshot(t *testing.T, cfg SimulationConfig, query, body string) *ConfigSnapshot {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	NewServer(cfg).Handler().ServeHTTP(rec, newTenantRequest(http.MethodPost, "/simulate"+query, strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("simulate: %d %s", rec.Code, rec.Body)
+	}
+	var resp simulateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Branch == "" {
+		t.Fatalf("expected the plan fields alongside the snapshot, got %s", rec.Body)
+	}
+	return resp.ConfigSnapshot
+}
+
+func TestSimulate_ConfigSnapshotOptIn(t *testing.T) {
+	body := `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`
+	if snap := simulateSnapshot(t, DefaultConfig(), "", body); snap != nil {
+		t.Fatalf("expected no snapshot by default, got %+v", snap)
+	}
+
+	cfg := DefaultConfig()
+	cfg.FlareMarkers = []string{"nod2", "IL23R"}
+	snap := simulateSnapshot(t, cfg, "?include_config=true", body)
+	if snap == nil || snap.TrustThreshold != 0.7 || strings.Join(snap.FlareMarkers, ",") != "IL23R,NOD2" {
+		t.Fatalf("unexpected snapshot %+v", snap)
+	}
+	if snap.ConfigHash != cfg.Hash() || snap.WeightsHash == "" {
+		t.Fatalf("expected config and weights hashes, got %+v", snap)
+	}
+}
+
+func TestSimulate_ConfigSnapshotTracksConfig(t *testing.T) {
+	body := `{"id":"s1","trust_score":0.9}`
+	base := simulateSnapshot(t, DefaultConfig(), "?include_config=true", body)
+
+	cfg := DefaultConfig()
+	cfg.TrustThreshold = 0.8
+	raised := simulateSnapshot(t, cfg, "?include_config=true", body)
+	if raised.ConfigHash == base.ConfigHash {
+		t.Fatalf("expected the config hash to change with the threshold")
+	}
+	if raised.WeightsHash != base.WeightsHash {
+		t.Fatalf("expected the weights hash to ignore the threshold")
+	}
+
+	overridden := simulateSnapshot(t, DefaultConfig(), "?include_config=true",
+		`{"id":"s1","trust_score":0.9,"weight_overrides":{"NOD2":{"relief":0.1,"suppression":0.1}}}`)
+	if overridden.WeightsHash == base.WeightsHash {
+		t.Fatalf("expected weight overrides to change the weights hash")
+	}
+}
@@ -0,0 +1,579 @@
+package scroll_engine
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqliteMigrations are applied in order on open; PRAGMA user_version records
+// how many have run. Append new migrations, never edit shipped ones.
+var sqliteMigrations = []string{
+	`CREATE TABLE scrolls (
+		seq            INTEGER PRIMARY KEY AUTOINCREMENT,
+		id             TEXT    NOT NULL UNIQUE,
+		trust_score    REAL    NOT NULL,
+		is_flare_event INTEGER NOT NULL,
+		markers        TEXT    NOT NULL,
+		timestamp      INTEGER,
+		signature      TEXT    NOT NULL DEFAULT '',
+		composted_at   INTEGER,
+		compost_reason TEXT
+	);
+	CREATE INDEX scrolls_timestamp ON scrolls (timestamp);
+	CREATE TABLE plans (
+		scroll_id TEXT PRIMARY KEY,
+		plan      TEXT NOT NULL
+	);`,
+	`ALTER TABLE scrolls ADD COLUMN parent_id TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE audit (
+		seq       INTEGER PRIMARY KEY AUTOINCREMENT,
+		scroll_id TEXT    NOT NULL,
+		entry     TEXT    NOT NULL
+	);
+	CREATE INDEX audit_scroll_id ON audit (scroll_id);
+	CREATE TRIGGER audit_no_update BEFORE UPDATE ON audit
+	BEGIN SELECT RAISE(ABORT, 'audit entries are immutable'); END;
+	CREATE TRIGGER audit_no_delete BEFORE DELETE ON audit
+	BEGIN SELECT RAISE(ABORT, 'audit entries are immutable'); END;`,
+	// Scope every table by tenant. Rows written before tenants existed
+	// belong to DefaultTenant.
+	`CREATE TABLE scrolls_v4 (
+		seq            INTEGER PRIMARY KEY AUTOINCREMENT,
+		tenant         TEXT    NOT NULL,
+		id             TEXT    NOT NULL,
+		trust_score    REAL    NOT NULL,
+		is_flare_event INTEGER NOT NULL,
+		markers        TEXT    NOT NULL,
+		timestamp      INTEGER,
+		signature      TEXT    NOT NULL DEFAULT '',
+		composted_at   INTEGER,
+		compost_reason TEXT,
+		parent_id      TEXT    NOT NULL DEFAULT '',
+		UNIQUE (tenant, id)
+	);
+	INSERT INTO scrolls_v4 (seq, tenant, id, trust_score, is_flare_event, markers, timestamp, signature, composted_at, compost_reason, parent_id)
+		SELECT seq, 'default', id, trust_score, is_flare_event, markers, timestamp, signature, composted_at, compost_reason, parent_id FROM scrolls;
+	DROP TABLE scrolls;
+	ALTER TABLE scrolls_v4 RENAME TO scrolls;
+	CREATE INDEX scrolls_timestamp ON scrolls (tenant, timestamp);
+	CREATE TABLE plans_v4 (
+		tenant    TEXT NOT NULL,
+		scroll_id TEXT NOT NULL,
+		plan      TEXT NOT NULL,
+		PRIMARY KEY (tenant, scroll_id)
+	);
+	INSERT INTO plans_v4 (tenant, scroll_id, plan) SELECT 'default', scroll_id, plan FROM plans;
+	DROP TABLE plans;
+	ALTER TABLE plans_v4 RENAME TO plans;
+	ALTER TABLE audit ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';
+	DROP INDEX audit_scroll_id;
+	CREATE INDEX audit_scroll_id ON audit (tenant, scroll_id);`,
+	`ALTER TABLE scrolls ADD COLUMN tags TEXT NOT NULL DEFAULT '[]';`,
+	`ALTER TABLE scrolls ADD COLUMN version INTEGER NOT NULL DEFAULT 1;`,
+	`ALTER TABLE scrolls ADD COLUMN frozen INTEGER NOT NULL DEFAULT 0;`,
+	`ALTER TABLE scrolls ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 0;`,
+}
+
+// SQLiteStore is a ScrollStore persisted in a SQLite database. Timestamps
+// are stored as Unix nanoseconds (NULL when unset) and markers and tags as
+// JSON arrays.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens the database at dsn, creating or migrating its
+// schema as needed. Use ":memory:" for a throwaway database.
+func OpenSQLiteStore(dsn string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite serializes writers anyway, and a single connection keeps an
+	// in-memory database from splitting across pooled connections.
+	db.SetMaxOpenConns(1)
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func nullableUnixNano(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.UnixNano(), Valid: true}
+}
+
+func fromUnixNano(n sql.NullInt64) time.Time {
+	if !n.Valid {
+		return time.Time{}
+	}
+	return time.Unix(0, n.Int64).UTC()
+}
+
+const scrollColumns = `id, trust_score, is_flare_event, markers, timestamp, signature, parent_id, tags, version, frozen, schema_version`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanScroll(row rowScanner, extra ...any) (types.Scroll, error) {
+	var (
+		scroll  types.Scroll
+		markers string
+		tags    string
+		ts      sql.NullInt64
+	)
+	dest := append([]any{&scroll.ID, &scroll.TrustScore, &scroll.IsFlareEvent, &markers, &ts, &scroll.Signature, &scroll.ParentID, &tags, &scroll.Version, &scroll.Frozen, &scroll.SchemaVersion}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return types.Scroll{}, err
+	}
+	if err := json.Unmarshal([]byte(markers), &scroll.GeneticMarkers); err != nil {
+		return types.Scroll{}, fmt.Errorf("decode markers for %q: %w", scroll.ID, err)
+	}
+	if err := json.Unmarshal([]byte(tags), &scroll.Tags); err != nil {
+		return types.Scroll{}, fmt.Errorf("decode tags for %q: %w", scroll.ID, err)
+	}
+	if len(scroll.Tags) == 0 {
+		scroll.Tags = nil
+	}
+	scroll.Timestamp = fromUnixNano(ts)
+	return scroll, nil
+}
+
+func (s *SQLiteStore) SaveScroll(tenant string, scroll types.Scroll) error {
+	markers, err := json.Marshal(scroll.GeneticMarkers)
+	if err != nil {
+		return err
+	}
+	tags, err := encodeTags(scroll.Tags)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(`
+		INSERT INTO scrolls (tenant, `+scrollColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tenant, id) DO UPDATE SET
+			trust_score = excluded.trust_score,
+			is_flare_event = excluded.is_flare_event,
+			markers = excluded.markers,
+			timestamp = excluded.timestamp,
+			signature = excluded.signature,
+			parent_id = excluded.parent_id,
+			tags = excluded.tags,
+			version = excluded.version,
+			frozen = excluded.frozen,
+			schema_version = excluded.schema_version,
+			composted_at = NULL,
+			compost_reason = NULL
+		WHERE scrolls.frozen = 0`,
+		tenant, scroll.ID, scroll.TrustScore, scroll.IsFlareEvent, string(markers),
+		nullableUnixNano(scroll.Timestamp), scroll.Signature, scroll.ParentID, tags, scroll.Version, scroll.Frozen, scroll.SchemaVersion)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrFrozen
+	}
+	return nil
+}
+
+// missingOrFrozen explains a write to tenant's scroll id that changed no
+// rows: ErrFrozen if the scroll is stored and frozen, ErrNotFound otherwise.
+func (s *SQLiteStore) missingOrFrozen(tenant, id string) error {
+	var frozen bool
+	err := s.db.QueryRow(`SELECT frozen FROM scrolls WHERE tenant = ? AND id = ? AND composted_at IS NULL`, tenant, id).Scan(&frozen)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case err != nil:
+		return err
+	case frozen:
+		return ErrFrozen
+	}
+	return ErrNotFound
+}
+
+func encodeTags(tags []string) (string, error) {
+	if tags == nil {
+		tags = []string{}
+	}
+	raw, err := json.Marshal(tags)
+	return string(raw), err
+}
+
+func (s *SQLiteStore) UpdateTags(tenant, id string, add, remove []string) (types.Scroll, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Scroll{}, err
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`SELECT `+scrollColumns+` FROM scrolls WHERE tenant = ? AND id = ? AND composted_at IS NULL`, tenant, id)
+	scroll, err := scanScroll(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Scroll{}, ErrNotFound
+	}
+	if err != nil {
+		return types.Scroll{}, err
+	}
+	if scroll.Frozen {
+		return types.Scroll{}, ErrFrozen
+	}
+	scroll.Tags = mergeTags(scroll.Tags, add, remove)
+	tags, err := encodeTags(scroll.Tags)
+	if err != nil {
+		return types.Scroll{}, err
+	}
+	if _, err := tx.Exec(`UPDATE scrolls SET tags = ? WHERE tenant = ? AND id = ?`, tags, tenant, id); err != nil {
+		return types.Scroll{}, err
+	}
+	return scroll, tx.Commit()
+}
+
+func (s *SQLiteStore) SetFrozen(tenant, id string, frozen bool) (types.Scroll, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return types.Scroll{}, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE scrolls SET frozen = ? WHERE tenant = ? AND id = ? AND composted_at IS NULL`, frozen, tenant, id)
+	if err != nil {
+		return types.Scroll{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return types.Scroll{}, err
+	} else if n == 0 {
+		return types.Scroll{}, ErrNotFound
+	}
+	scroll, err := scanScroll(tx.QueryRow(`SELECT `+scrollColumns+` FROM scrolls WHERE tenant = ? AND id = ?`, tenant, id))
+	if err != nil {
+		return types.Scroll{}, err
+	}
+	return scroll, tx.Commit()
+}
+
+func (s *SQLiteStore) GetScroll(tenant, id string) (types.Scroll, error) {
+	row := s.db.QueryRow(`SELECT `+scrollColumns+` FROM scrolls WHERE tenant = ? AND id = ? AND composted_at IS NULL`, tenant, id)
+	scroll, err := scanScroll(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Scroll{}, ErrNotFound
+	}
+	return scroll, err
+}
+
+// scrollFilter renders q's filters as a WHERE clause over tenant's active
+// scrolls.
+func scrollFilter(tenant string, q ScrollQuery) (string, []any) {
+	where := []string{"tenant = ?", "composted_at IS NULL"}
+	args := []any{tenant}
+	if !q.From.IsZero() {
+		where = append(where, "timestamp >= ?")
+		args = append(args, q.From.UnixNano())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "timestamp <= ?")
+		args = append(args, q.To.UnixNano())
+	}
+	for _, tag := range q.Tags {
+		where = append(where, "EXISTS (SELECT 1 FROM json_each(tags) WHERE value = ?)")
+		args = append(args, tag)
+	}
+	return " WHERE " + strings.Join(where, " AND "), args
+}
+
+func (s *SQLiteStore) ListScrolls(tenant string, q ScrollQuery) ([]types.Scroll, error) {
+	where, args := scrollFilter(tenant, q)
+	query := `SELECT ` + scrollColumns + ` FROM scrolls` + where + ` ORDER BY seq`
+	if q.Limit > 0 || q.Offset > 0 {
+		limit := q.Limit
+		if limit <= 0 {
+			limit = -1
+		}
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, q.Offset)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	scrolls := []types.Scroll{}
+	for rows.Next() {
+		scroll, err := scanScroll(rows)
+		if err != nil {
+			return nil, err
+		}
+		scrolls = append(scrolls, scroll)
+	}
+	return scrolls, rows.Err()
+}
+
+func (s *SQLiteStore) CountScrolls(tenant string, q ScrollQuery) (int, error) {
+	where, args := scrollFilter(tenant, q)
+	var n int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM scrolls`+where, args...).Scan(&n)
+	return n, err
+}
+
+func (s *SQLiteStore) SavePlan(tenant, scrollID string, plan types.GeneInterventionPlan) error {
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.Exec(`
+		INSERT INTO plans (tenant, scroll_id, plan)
+		SELECT ?, ?, ? WHERE NOT EXISTS (
+			SELECT 1 FROM scrolls WHERE tenant = ? AND id = ? AND frozen = 1
+		)
+		ON CONFLICT (tenant, scroll_id) DO UPDATE SET plan = excluded.plan`,
+		tenant, scrollID, string(raw), tenant, scrollID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrFrozen
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetPlan(tenant, scrollID string) (types.GeneInterventionPlan, error) {
+	var raw string
+	err := s.db.QueryRow(`SELECT plan FROM plans WHERE tenant = ? AND scroll_id = ?`, tenant, scrollID).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.GeneInterventionPlan{}, ErrNotFound
+	}
+	if err != nil {
+		return types.GeneInterventionPlan{}, err
+	}
+	var plan types.GeneInterventionPlan
+	err = json.Unmarshal([]byte(raw), &plan)
+	return plan, err
+}
+
+func (s *SQLiteStore) EachPlan(tenant string, fn func(types.GeneInterventionPlan) error) error {
+	rows, err := s.db.Query(`SELECT plan FROM plans WHERE tenant = ?`, tenant)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var raw string
+		if err := rows.Scan(&raw); err != nil {
+			return err
+		}
+		var plan types.GeneInterventionPlan
+		if err := json.Unmarshal([]byte(raw), &plan); err != nil {
+			return err
+		}
+		if err := fn(plan); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *SQLiteStore) CountRebirthEligible(tenant string) (int, error) {
+	var n int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM scrolls
+		JOIN plans ON plans.tenant = scrolls.tenant AND plans.scroll_id = scrolls.id
+		WHERE scrolls.tenant = ? AND composted_at IS NULL
+			AND json_extract(plans.plan, '$.rebirth_eligible') = 1`,
+		tenant).Scan(&n)
+	return n, err
+}
+
+func (s *SQLiteStore) CompostScroll(tenant, id string, reason CompostReason, at time.Time) error {
+	res, err := s.db.Exec(`
+		UPDATE scrolls SET composted_at = ?, compost_reason = ?
+		WHERE tenant = ? AND id = ? AND composted_at IS NULL AND frozen = 0`,
+		at.UnixNano(), reason, tenant, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return s.missingOrFrozen(tenant, id)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) ListCompost(tenant string) ([]CompostedScroll, error) {
+	rows, err := s.db.Query(`
+		SELECT `+scrollColumns+`, composted_at, compost_reason, tenant FROM scrolls
+		WHERE tenant = ? AND composted_at IS NOT NULL ORDER BY composted_at, seq`, tenant)
+	if err != nil {
+		return nil, err
+	}
+	return scanCompost(rows)
+}
+
+// scanCompost reads compost entries from rows selecting scrollColumns,
+// composted_at, compost_reason, and tenant, closing rows.
+func scanCompost(rows *sql.Rows) ([]CompostedScroll, error) {
+	defer rows.Close()
+	out := []CompostedScroll{}
+	for rows.Next() {
+		var (
+			at     sql.NullInt64
+			reason CompostReason
+			tenant string
+		)
+		scroll, err := scanScroll(rows, &at, &reason, &tenant)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, CompostedScroll{Tenant: tenant, Scroll: scroll, Reason: reason, CompostedAt: fromUnixNano(at)})
+	}
+	return out, rows.Err()
+}
+
+func (s *SQLiteStore) ListAllCompost() ([]CompostedScroll, error) {
+	rows, err := s.db.Query(`
+		SELECT ` + scrollColumns + `, composted_at, compost_reason, tenant FROM scrolls
+		WHERE composted_at IS NOT NULL ORDER BY composted_at, seq`)
+	if err != nil {
+		return nil, err
+	}
+	return scanCompost(rows)
+}
+
+func (s *SQLiteStore) RestoreScroll(tenant, id string) error {
+	res, err := s.db.Exec(`
+		UPDATE scrolls SET composted_at = NULL, compost_reason = NULL
+		WHERE tenant = ? AND id = ? AND composted_at IS NOT NULL`,
+		tenant, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return s.missingOrLive(tenant, id)
+	}
+	return nil
+}
+
+// missingOrLive explains a restore of tenant's scroll id that changed no
+// rows: ErrExists if the scroll is stored and live, ErrNotFound otherwise.
+func (s *SQLiteStore) missingOrLive(tenant, id string) error {
+	var n int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM scrolls WHERE tenant = ? AND id = ? AND composted_at IS NULL`, tenant, id).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrExists
+	}
+	return ErrNotFound
+}
+
+func (s *SQLiteStore) PurgeCompost(before time.Time) ([]CompostedScroll, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT `+scrollColumns+`, composted_at, compost_reason, tenant FROM scrolls
+		WHERE composted_at < ? ORDER BY composted_at, seq`, before.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	purged, err := scanCompost(rows)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`
+		DELETE FROM plans WHERE EXISTS (
+			SELECT 1 FROM scrolls
+			WHERE scrolls.tenant = plans.tenant AND scrolls.id = plans.scroll_id AND composted_at < ?)`,
+		before.UnixNano()); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`DELETE FROM scrolls WHERE composted_at < ?`, before.UnixNano()); err != nil {
+		return nil, err
+	}
+	return purged, tx.Commit()
+}
+
+func (s *SQLiteStore) AppendAudit(tenant string, entry AuditEntry) error {
+	entry.Seq = 0
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO audit (tenant, scroll_id, entry) VALUES (?, ?, ?)`, tenant, entry.ScrollID, string(raw))
+	return err
+}
+
+func (s *SQLiteStore) ListAudit(tenant, scrollID string) ([]AuditEntry, error) {
+	rows, err := s.db.Query(`SELECT seq, entry FROM audit WHERE tenant = ? AND scroll_id = ? ORDER BY seq`, tenant, scrollID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []AuditEntry{}
+	for rows.Next() {
+		var (
+			seq   int64
+			raw   string
+			entry AuditEntry
+		)
+		if err := rows.Scan(&seq, &raw); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil {
+			return nil, fmt.Errorf("decode audit entry %d: %w", seq, err)
+		}
+		entry.Seq = seq
+		out = append(out, entry)
+	}
+	return out, rows.Err()
+}
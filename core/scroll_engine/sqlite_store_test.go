@@ -0,0 +1,339 @@
+package scroll_engine
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func openTestSQLite(t *testing.T) *SQLiteStore {
+	t.Helper()
+	store, err := OpenSQLiteStore(":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestSQLiteStore_ScrollRoundTrip(t *testing.T) {
+	store := openTestSQLite(t)
+	want := types.Scroll{
+		ID:             "s1",
+		TrustScore:     0.82,
+		IsFlareEvent:   true,
+		GeneticMarkers: []string{"NOD2", "IL23R", "ATG16L1"},
+		Timestamp:      day(3),
+		Signature:      "abc123",
+		Version:        3,
+	}
+	if err := store.SaveScroll(testTenant, want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	got, err := store.GetScroll(testTenant, "s1")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch\nwant %+v\ngot  %+v", want, got)
+	}
+
+	if _, err := store.GetScroll(testTenant, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSQLiteStore_MarkerSerialization(t *testing.T) {
+	store := openTestSQLite(t)
+	for _, s := range []types.Scroll{
+		{ID: "none", TrustScore: 0.5},
+		{ID: "empty", TrustScore: 0.5, GeneticMarkers: []string{}},
+		{ID: "quoted", TrustScore: 0.5, GeneticMarkers: []string{`a"b`, "c,d", "ünï"}},
+	} {
+		if err := store.SaveScroll(testTenant, s); err != nil {
+			t.Fatalf("save %s: %v", s.ID, err)
+		}
+		got, err := store.GetScroll(testTenant, s.ID)
+		if err != nil {
+			t.Fatalf("get %s: %v", s.ID, err)
+		}
+		if !reflect.DeepEqual(got.GeneticMarkers, s.GeneticMarkers) {
+			t.Fatalf("%s: markers %#v, want %#v", s.ID, got.GeneticMarkers, s.GeneticMarkers)
+		}
+	}
+}
+
+func TestSQLiteStore_ListFiltersAndPages(t *testing.T) {
+	store := openTestSQLite(t)
+	for _, s := range []types.Scroll{
+		{ID: "jan1", TrustScore: 0.5, Timestamp: day(1)},
+		{ID: "jan5", TrustScore: 0.5, Timestamp: day(5)},
+		{ID: "undated", TrustScore: 0.5},
+		{ID: "jan9", TrustScore: 0.5, Timestamp: day(9)},
+	} {
+		_ = store.SaveScroll(testTenant, s)
+	}
+	// Re-saving keeps the original insertion position.
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "jan1", TrustScore: 0.6, Timestamp: day(1)})
+
+	ids := func(q ScrollQuery) []string {
+		t.Helper()
+		scrolls, err := store.ListScrolls(testTenant, q)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		out := []string{}
+		for _, s := range scrolls {
+			out = append(out, s.ID)
+		}
+		return out
+	}
+
+	if got := ids(ScrollQuery{}); !reflect.DeepEqual(got, []string{"jan1", "jan5", "undated", "jan9"}) {
+		t.Fatalf("unfiltered: %v", got)
+	}
+	if got := ids(ScrollQuery{Limit: 2, Offset: 1}); !reflect.DeepEqual(got, []string{"jan5", "undated"}) {
+		t.Fatalf("paged: %v", got)
+	}
+	if got := ids(ScrollQuery{Offset: 3}); !reflect.DeepEqual(got, []string{"jan9"}) {
+		t.Fatalf("offset only: %v", got)
+	}
+	q := ScrollQuery{From: day(2), To: day(9)}
+	if got := ids(q); !reflect.DeepEqual(got, []string{"jan5", "jan9"}) {
+		t.Fatalf("ranged: %v", got)
+	}
+	if n, _ := store.CountScrolls(testTenant, q); n != 2 {
+		t.Fatalf("expected count 2, got %d", n)
+	}
+}
+
+func TestSQLiteStore_PlansAndCompost(t *testing.T) {
+	store := openTestSQLite(t)
+	scroll := types.Scroll{ID: "s1", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2"}}
+	plan := mustSimulate(t, scroll, DefaultConfig())
+	_ = store.SaveScroll(testTenant, scroll)
+	if err := store.SavePlan(testTenant, "s1", plan); err != nil {
+		t.Fatalf("save plan: %v", err)
+	}
+	got, err := store.GetPlan(testTenant, "s1")
+	if err != nil {
+		t.Fatalf("get plan: %v", err)
+	}
+	if !reflect.DeepEqual(got, plan) {
+		t.Fatalf("plan mismatch\nwant %+v\ngot  %+v", plan, got)
+	}
+
+	if err := store.CompostScroll(testTenant, "s1", ReasonLowTrust, day(4)); err != nil {
+		t.Fatalf("compost: %v", err)
+	}
+	if _, err := store.GetScroll(testTenant, "s1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected composted scroll to be gone, got %v", err)
+	}
+	if err := store.CompostScroll(testTenant, "s1", ReasonManual, day(5)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound composting twice, got %v", err)
+	}
+	bin, _ := store.ListCompost(testTenant)
+	if len(bin) != 1 || bin[0].Reason != ReasonLowTrust || !bin[0].CompostedAt.Equal(day(4)) || bin[0].Scroll.ID != "s1" {
+		t.Fatalf("unexpected compost bin: %+v", bin)
+	}
+}
+
+func TestSQLiteStore_ReopensExistingSchema(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "scrolls.db")
+	store, err := OpenSQLiteStore(dsn)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "kept", TrustScore: 0.4})
+	store.Close()
+
+	store, err = OpenSQLiteStore(dsn)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+	if _, err := store.GetScroll(testTenant, "kept"); err != nil {
+		t.Fatalf("expected scroll to survive reopen: %v", err)
+	}
+}
+
+func TestParseConfig_Store(t *testing.T) {
+	cfg, err := ParseConfig([]byte(`{"store":{"driver":"sqlite","dsn":"scrolls.db"}}`))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if cfg.Store != (StoreConfig{Driver: "sqlite", DSN: "scrolls.db"}) {
+		t.Fatalf("unexpected store config: %+v", cfg.Store)
+	}
+
+	for raw, key := range map[string]string{
+		`{"store":{"driver":"sqlite"}}`:   "store.dsn",
+		`{"store":{"driver":"postgres"}}`: "store.driver",
+	} {
+		var cerr *ConfigError
+		if _, err := ParseConfig([]byte(raw)); !errors.As(err, &cerr) || cerr.Key != key {
+			t.Fatalf("%s: expected error on %s, got %v", raw, key, err)
+		}
+	}
+}
+
+func TestSQLiteStore_ParentID(t *testing.T) {
+	store := openTestSQLite(t)
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "root", TrustScore: 0.3})
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "child", TrustScore: 0.8, ParentID: "root"})
+
+	chain, err := Lineage(store, testTenant, "child")
+	if err != nil {
+		t.Fatalf("lineage: %v", err)
+	}
+	if got := lineageIDs(chain); !reflect.DeepEqual(got, []string{"root", "child"}) {
+		t.Fatalf("lineage %v", got)
+	}
+}
+
+func TestSQLiteStore_AuditAppendOnly(t *testing.T) {
+	store := openTestSQLite(t)
+	for range 2 {
+		if err := store.AppendAudit(testTenant, AuditEntry{ScrollID: "s", Branch: BranchFlare, At: day(1)}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	entries, err := store.ListAudit(testTenant, "s")
+	if err != nil || len(entries) != 2 || entries[0].Seq >= entries[1].Seq || entries[1].Branch != BranchFlare {
+		t.Fatalf("unexpected entries %+v, %v", entries, err)
+	}
+	if _, err := store.db.Exec(`UPDATE audit SET entry = '{}'`); err == nil {
+		t.Fatalf("expected audit rows to reject updates")
+	}
+	if _, err := store.db.Exec(`DELETE FROM audit`); err == nil {
+		t.Fatalf("expected audit rows to reject deletes")
+	}
+}
+
+func TestSQLiteStore_PurgeCompost(t *testing.T) {
+	store := openTestSQLite(t)
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "stale", TrustScore: 0.1})
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "fresh", TrustScore: 0.1})
+	_ = store.CompostScroll(testTenant, "stale", ReasonDrift, day(1))
+	_ = store.CompostScroll(testTenant, "fresh", ReasonDrift, day(9))
+
+	purged, err := store.PurgeCompost(day(5))
+	if err != nil || len(purged) != 1 || purged[0].Scroll.ID != "stale" || purged[0].Reason != ReasonDrift {
+		t.Fatalf("unexpected purge %+v, %v", purged, err)
+	}
+	bin, _ := store.ListCompost(testTenant)
+	if len(bin) != 1 || bin[0].Scroll.ID != "fresh" {
+		t.Fatalf("expected only fresh to remain, got %+v", bin)
+	}
+}
+
+func TestSQLiteStore_TenantsIsolated(t *testing.T) {
+	store := openTestSQLite(t)
+	_ = store.SaveScroll("a", types.Scroll{ID: "s1", TrustScore: 0.9})
+	_ = store.SaveScroll("b", types.Scroll{ID: "s1", TrustScore: 0.1})
+	_ = store.SavePlan("a", "s1", types.GeneInterventionPlan{Branch: BranchFlare})
+
+	got, _ := store.GetScroll("a", "s1")
+	if got.TrustScore != 0.9 {
+		t.Fatalf("tenant b's save overwrote tenant a's scroll: %+v", got)
+	}
+	if _, err := store.GetPlan("b", "s1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected no plan for tenant b, got %v", err)
+	}
+	_ = store.CompostScroll("b", "s1", ReasonDrift, day(1))
+	if n, _ := store.CountScrolls("a", ScrollQuery{}); n != 1 {
+		t.Fatalf("tenant b's compost removed tenant a's scroll")
+	}
+	if bin, _ := store.ListCompost("a"); len(bin) != 0 {
+		t.Fatalf("tenant a sees tenant b's compost: %+v", bin)
+	}
+}
+
+func TestSQLiteStore_MigratesLegacyRowsToDefaultTenant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	for i, m := range sqliteMigrations[:3] {
+		if _, err := db.Exec(m); err != nil {
+			t.Fatalf("migration %d: %v", i+1, err)
+		}
+	}
+	_, _ = db.Exec(`PRAGMA user_version = 3`)
+	_, _ = db.Exec(`INSERT INTO scrolls (id, trust_score, is_flare_event, markers) VALUES ('old', 0.4, 0, '["NOD2"]')`)
+	_, _ = db.Exec(`INSERT INTO plans (scroll_id, plan) VALUES ('old', '{"branch":"compost"}')`)
+	db.Close()
+
+	store, err := OpenSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	defer store.Close()
+	if s, err := store.GetScroll(DefaultTenant, "old"); err != nil || s.TrustScore != 0.4 {
+		t.Fatalf("expected legacy scroll under the default tenant, got %+v, %v", s, err)
+	}
+	if p, err := store.GetPlan(DefaultTenant, "old"); err != nil || p.Branch != BranchCompost {
+		t.Fatalf("expected legacy plan under the default tenant, got %+v, %v", p, err)
+	}
+}
+
+func TestSQLiteStore_Tags(t *testing.T) {
+	store := openTestSQLite(t)
+	_ = store.SaveScroll("a", types.Scroll{ID: "s1", TrustScore: 0.5, Tags: []string{"pilot"}})
+	_ = store.SaveScroll("a", types.Scroll{ID: "s2", TrustScore: 0.5})
+
+	got, err := store.UpdateTags("a", "s2", []string{"pilot", "eu"}, nil)
+	if err != nil || !reflect.DeepEqual(got.Tags, []string{"eu", "pilot"}) {
+		t.Fatalf("update tags: %v %v", got.Tags, err)
+	}
+	if _, err := store.UpdateTags("b", "s2", []string{"eu"}, nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound across tenants, got %v", err)
+	}
+
+	both, _ := store.ListScrolls("a", ScrollQuery{Tags: []string{"eu", "pilot"}})
+	if len(both) != 1 || both[0].ID != "s2" {
+		t.Fatalf("expected only s2 to carry both tags, got %+v", both)
+	}
+	if n, _ := store.CountScrolls("a", ScrollQuery{Tags: []string{"pilot"}}); n != 2 {
+		t.Fatalf("expected 2 pilot scrolls, got %d", n)
+	}
+
+	got, _ = store.UpdateTags("a", "s1", nil, []string{"pilot"})
+	if got.Tags != nil {
+		t.Fatalf("expected no tags left, got %v", got.Tags)
+	}
+}
+
+func TestSQLiteStore_RestoreScroll(t *testing.T) {
+	store := openTestSQLite(t)
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "s1", TrustScore: 0.9})
+	_ = store.SaveScroll("b", types.Scroll{ID: "s2", TrustScore: 0.9})
+	_ = store.CompostScroll(testTenant, "s1", ReasonDrift, day(2))
+	_ = store.CompostScroll("b", "s2", ReasonDrift, day(1))
+
+	all, err := store.ListAllCompost()
+	if err != nil || len(all) != 2 || all[0].Tenant != "b" || all[1].Tenant != testTenant {
+		t.Fatalf("expected both tenants' compost in composting order, got %+v (%v)", all, err)
+	}
+
+	if err := store.RestoreScroll(testTenant, "s1"); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := store.GetScroll(testTenant, "s1"); err != nil {
+		t.Fatalf("expected restored scroll listed again, got %v", err)
+	}
+	if bin, _ := store.ListCompost(testTenant); len(bin) != 0 {
+		t.Fatalf("expected restored scroll out of the bin, got %+v", bin)
+	}
+	if err := store.RestoreScroll(testTenant, "s1"); !errors.Is(err, ErrExists) {
+		t.Fatalf("expected ErrExists restoring a live scroll, got %v", err)
+	}
+	if err := store.RestoreScroll(testTenant, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound restoring an unknown scroll, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package scroll_engine
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// topMarkerCount is how many of the most common markers StoreStats lists.
+const topMarkerCount = 10
+
+// Trigger labels used when counting scrolls by what raised them.
+const (
+	TriggerFlare  = "flare"
+	TriggerMemory = "memory"
+)
+
+// MarkerCount is a genetic marker and the number of scrolls carrying it.
+type MarkerCount struct {
+	Marker string `json:"marker"`
+	Count  int    `json:"count"`
+}
+
+// StoreStats summarizes the contents of a ScrollStore.
+type StoreStats struct {
+	TotalScrolls    int            `json:"total_scrolls"`
+	ByTrigger       map[string]int `json:"by_trigger"`
+	Composted       int            `json:"composted"`
+	RebirthEligible int            `json:"rebirth_eligible"`
+	AverageTrust    float64        `json:"average_trust"`
+	TopMarkers      []MarkerCount  `json:"top_markers"`
+}
+
+// ComputeStats summarizes tenant's records in store in a single pass over
+// its active scrolls. Markers are counted once per scroll in canonical
+// form; a scroll counts as rebirth-eligible when its stored plan says so.
+func ComputeStats(store ScrollStore, tenant string, registry *MarkerRegistry) (StoreStats, error) {
+	scrolls, err := store.ListScrolls(tenant, ScrollQuery{})
+	if err != nil {
+		return StoreStats{}, err
+	}
+	compost, err := store.ListCompost(tenant)
+	if err != nil {
+		return StoreStats{}, err
+	}
+	eligible, err := store.CountRebirthEligible(tenant)
+	if err != nil {
+		return StoreStats{}, err
+	}
+
+	stats := StoreStats{
+		TotalScrolls:    len(scrolls),
+		ByTrigger:       map[string]int{TriggerFlare: 0, TriggerMemory: 0},
+		Composted:       len(compost),
+		RebirthEligible: eligible,
+	}
+	markers := make(map[string]int)
+	var trustSum float64
+	for _, s := range scrolls {
+		if s.IsFlareEvent {
+			stats.ByTrigger[TriggerFlare]++
+		} else {
+			stats.ByTrigger[TriggerMemory]++
+		}
+		trustSum += s.TrustScore
+
+		seen := make(map[string]bool, len(s.GeneticMarkers))
+		for _, m := range s.GeneticMarkers {
+			m = registry.Canonicalize(m)
+			if !seen[m] {
+				seen[m] = true
+				markers[m]++
+			}
+		}
+	}
+	if len(scrolls) > 0 {
+		stats.AverageTrust = trustSum / float64(len(scrolls))
+	}
+	stats.TopMarkers = topMarkers(markers, topMarkerCount)
+	return stats, nil
+}
+
+// topMarkers returns the n most common markers, ties broken by name.
+func topMarkers(counts map[string]int, n int) []MarkerCount {
+	out := make([]MarkerCount, 0, len(counts))
+	for m, c := range counts {
+		out = append(out, MarkerCount{Marker: m, Count: c})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Marker < out[j].Marker
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+// statsCache holds the last computed StoreStats for each tenant for ttl so
+// dashboard polls do not rescan the store. A zero ttl disables caching.
+type statsCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cachedStats
+}
+
+type cachedStats struct {
+	stats    StoreStats
+	computed time.Time
+}
+
+func newStatsCache(ttl time.Duration) *statsCache {
+	return &statsCache{ttl: ttl, now: time.Now, entries: make(map[string]cachedStats)}
+}
+
+// get returns tenant's cached stats, recomputing them with compute once
+// stale.
+func (c *statsCache) get(tenant string, compute func() (StoreStats, error)) (StoreStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if e, ok := c.entries[tenant]; ok && now.Sub(e.computed) < c.ttl {
+		return e.stats, nil
+	}
+	stats, err := compute()
+	if err != nil {
+		return StoreStats{}, err
+	}
+	c.entries[tenant] = cachedStats{stats: stats, computed: now}
+	return stats, nil
+}
@@ -0,0 +1,106 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func seedStatsStore(t *testing.T, store ScrollStore) {
+	t.Helper()
+	for _, s := range []types.Scroll{
+		{ID: "f1", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2", "IL23R"}},
+		{ID: "f2", TrustScore: 0.8, IsFlareEvent: true, GeneticMarkers: []string{"nod2", "NOD2 "}},
+		{ID: "m1", TrustScore: 0.4, GeneticMarkers: []string{"ATG16L1", "IL23R"}},
+		{ID: "m2", TrustScore: 0.3},
+		{ID: "gone", TrustScore: 0.1},
+	} {
+		if err := store.SaveScroll(testTenant, s); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	_ = store.SavePlan(testTenant, "m1", types.GeneInterventionPlan{Branch: BranchCompost, RebirthEligible: true})
+	_ = store.SavePlan(testTenant, "m2", types.GeneInterventionPlan{Branch: BranchCompost})
+	_ = store.SavePlan(testTenant, "gone", types.GeneInterventionPlan{Branch: BranchCompost, RebirthEligible: true})
+	if err := store.CompostScroll(testTenant, "gone", ReasonDrift, day(1)); err != nil {
+		t.Fatalf("compost: %v", err)
+	}
+}
+
+func TestComputeStats_CountsSeededStore(t *testing.T) {
+	for name, store := range map[string]ScrollStore{"memory": NewMemoryStore(), "sqlite": openTestSQLite(t)} {
+		seedStatsStore(t, store)
+
+		stats, err := ComputeStats(store, testTenant, NewMarkerRegistry(nil))
+		if err != nil {
+			t.Fatalf("%s: stats: %v", name, err)
+		}
+		if stats.TotalScrolls != 4 || stats.Composted != 1 || stats.RebirthEligible != 1 {
+			t.Fatalf("%s: unexpected totals: %+v", name, stats)
+		}
+		if stats.ByTrigger[TriggerFlare] != 2 || stats.ByTrigger[TriggerMemory] != 2 {
+			t.Fatalf("%s: unexpected trigger counts: %v", name, stats.ByTrigger)
+		}
+		if math.Abs(stats.AverageTrust-0.6) > 1e-9 {
+			t.Fatalf("%s: expected average trust 0.6, got %v", name, stats.AverageTrust)
+		}
+		want := []MarkerCount{{"IL23R", 2}, {"NOD2", 2}, {"ATG16L1", 1}}
+		if !reflect.DeepEqual(stats.TopMarkers, want) {
+			t.Fatalf("%s: top markers %v, want %v", name, stats.TopMarkers, want)
+		}
+	}
+}
+
+func TestComputeStats_TopMarkersCapped(t *testing.T) {
+	store := NewMemoryStore()
+	markers := make([]string, 0, 15)
+	for i := range 15 {
+		markers = append(markers, string(rune('A'+i)))
+	}
+	_ = store.SaveScroll(testTenant, types.Scroll{ID: "s", TrustScore: 0.5, GeneticMarkers: markers})
+
+	stats, _ := ComputeStats(store, testTenant, NewMarkerRegistry(nil))
+	if len(stats.TopMarkers) != topMarkerCount {
+		t.Fatalf("expected %d top markers, got %d", topMarkerCount, len(stats.TopMarkers))
+	}
+}
+
+func TestStatsEndpoint_CachesWithinTTL(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	seedStatsStore(t, srv.store)
+	now := time.Now()
+	srv.stats.now = func() time.Time { return now }
+	h := srv.Handler()
+
+	get := func() StoreStats {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/stats", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		var stats StoreStats
+		if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return stats
+	}
+
+	if got := get().TotalScrolls; got != 4 {
+		t.Fatalf("expected 4 scrolls, got %d", got)
+	}
+	_ = srv.store.SaveScroll(testTenant, types.Scroll{ID: "new", TrustScore: 0.5})
+	if got := get().TotalScrolls; got != 4 {
+		t.Fatalf("expected cached total 4 within TTL, got %d", got)
+	}
+	now = now.Add(time.Duration(srv.config().StatsCacheTTL))
+	if got := get().TotalScrolls; got != 5 {
+		t.Fatalf("expected recomputed total 5 after TTL, got %d", got)
+	}
+}
@@ -0,0 +1,419 @@
+package scroll_engine
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// ErrNotFound is returned by a ScrollStore when no record exists for an ID.
+var ErrNotFound = errors.New("not found")
+
+// ErrFrozen is returned by a ScrollStore asked to change a frozen scroll or
+// its plan.
+var ErrFrozen = errors.New("scroll is frozen")
+
+// ErrExists is returned by a ScrollStore asked to restore a scroll whose ID
+// is already in use by a live scroll.
+var ErrExists = errors.New("scroll already exists")
+
+// ScrollQuery selects a page of stored scrolls. A zero Limit means no limit.
+// From and To, when non-zero, bound scroll Timestamps inclusively; scrolls
+// without a timestamp fall outside any bounded range.
+type ScrollQuery struct {
+	Limit  int
+	Offset int
+	From   time.Time
+	To     time.Time
+	// Tags matches scrolls carrying every listed tag. Tags must already be
+	// normalized.
+	Tags []string
+}
+
+// matches reports whether scroll satisfies the query's filters.
+func (q ScrollQuery) matches(scroll types.Scroll) bool {
+	for _, tag := range q.Tags {
+		if !slices.Contains(scroll.Tags, tag) {
+			return false
+		}
+	}
+	if q.From.IsZero() && q.To.IsZero() {
+		return true
+	}
+	ts := scroll.Timestamp
+	if ts.IsZero() {
+		return false
+	}
+	if !q.From.IsZero() && ts.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && ts.After(q.To) {
+		return false
+	}
+	return true
+}
+
+// page applies the query's offset and limit to scrolls.
+func (q ScrollQuery) page(scrolls []types.Scroll) []types.Scroll {
+	if q.Offset >= len(scrolls) {
+		return []types.Scroll{}
+	}
+	scrolls = scrolls[q.Offset:]
+	if q.Limit > 0 && q.Limit < len(scrolls) {
+		scrolls = scrolls[:q.Limit]
+	}
+	return scrolls
+}
+
+// ScrollStore persists scrolls and the plans simulated from them. Every
+// record belongs to a tenant; operations given a tenant see only that
+// tenant's records, and IDs need only be unique within a tenant. Writes
+// that would change a frozen scroll or its plan return ErrFrozen.
+type ScrollStore interface {
+	// SaveScroll stores scroll, replacing any scroll with its ID. Saving
+	// the ID of a composted scroll takes it out of the compost bin.
+	SaveScroll(tenant string, scroll types.Scroll) error
+	GetScroll(tenant, id string) (types.Scroll, error)
+	// ListScrolls returns the stored scrolls matching q in insertion order.
+	ListScrolls(tenant string, q ScrollQuery) ([]types.Scroll, error)
+	// CountScrolls returns how many stored scrolls match q's filters,
+	// ignoring its Limit and Offset.
+	CountScrolls(tenant string, q ScrollQuery) (int, error)
+	// UpdateTags atomically adds and then removes normalized tags on a
+	// stored scroll, returning the updated scroll. It returns ErrNotFound
+	// if the scroll is not stored.
+	UpdateTags(tenant, id string, add, remove []string) (types.Scroll, error)
+	// SetFrozen freezes or unfreezes a stored scroll, returning the updated
+	// scroll. It returns ErrNotFound if the scroll is not stored.
+	SetFrozen(tenant, id string, frozen bool) (types.Scroll, error)
+	SavePlan(tenant, scrollID string, plan types.GeneInterventionPlan) error
+	GetPlan(tenant, scrollID string) (types.GeneInterventionPlan, error)
+	// EachPlan calls fn with each of tenant's stored plans, in no
+	// particular order, stopping at and returning fn's first error. fn must
+	// not call back into the store.
+	EachPlan(tenant string, fn func(types.GeneInterventionPlan) error) error
+	// CountRebirthEligible returns how many of tenant's listed scrolls have
+	// a stored plan marked rebirth-eligible.
+	CountRebirthEligible(tenant string) (int, error)
+	// CompostScroll moves a stored scroll into the compost bin, removing it
+	// from listings. It returns ErrNotFound if the scroll is not stored.
+	CompostScroll(tenant, id string, reason CompostReason, at time.Time) error
+	// ListCompost returns the compost bin in the order scrolls were composted.
+	ListCompost(tenant string) ([]CompostedScroll, error)
+	// ListAllCompost returns every tenant's compost bin in the order
+	// scrolls were composted. It is for maintenance jobs such as compost
+	// rebirth.
+	ListAllCompost() ([]CompostedScroll, error)
+	// RestoreScroll moves a scroll out of the compost bin and back into
+	// listings. It returns ErrExists if a live scroll already has its ID,
+	// and ErrNotFound if the scroll is not in the bin.
+	RestoreScroll(tenant, id string) error
+	// PurgeCompost permanently removes compost entries composted before
+	// the given time, and their plans, across every tenant, and returns
+	// them. It is for maintenance jobs such as compost decay.
+	PurgeCompost(before time.Time) ([]CompostedScroll, error)
+	// AppendAudit appends entry to the audit log, assigning its Seq. Audit
+	// entries are never updated or removed.
+	AppendAudit(tenant string, entry AuditEntry) error
+	// ListAudit returns the audit entries for a scroll in append order.
+	ListAudit(tenant, scrollID string) ([]AuditEntry, error)
+}
+
+// OpenStore opens the ScrollStore backend cfg selects.
+func OpenStore(cfg StoreConfig) (ScrollStore, error) {
+	switch cfg.Driver {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "sqlite":
+		return OpenSQLiteStore(cfg.DSN)
+	}
+	return nil, fmt.Errorf("unknown store driver %q", cfg.Driver)
+}
+
+// CompostedScroll is a scroll held in the compost bin.
+type CompostedScroll struct {
+	Tenant      string        `json:"tenant"`
+	Scroll      types.Scroll  `json:"scroll"`
+	Reason      CompostReason `json:"reason"`
+	CompostedAt time.Time     `json:"composted_at"`
+}
+
+// MemoryStore is a ScrollStore held entirely in process memory.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	tenants map[string]*memoryTenant
+}
+
+// memoryTenant holds one tenant's records.
+type memoryTenant struct {
+	scrolls map[string]types.Scroll
+	order   []string
+	plans   map[string]types.GeneInterventionPlan
+	compost []CompostedScroll
+	audit   []AuditEntry
+}
+
+var emptyMemoryTenant = &memoryTenant{}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tenants: make(map[string]*memoryTenant)}
+}
+
+// read returns tenant's records for reading; an unknown tenant has none.
+// The caller must hold m.mu.
+func (m *MemoryStore) read(tenant string) *memoryTenant {
+	if t, ok := m.tenants[tenant]; ok {
+		return t
+	}
+	return emptyMemoryTenant
+}
+
+// write returns tenant's records, creating them if needed. The caller must
+// hold m.mu for writing.
+func (m *MemoryStore) write(tenant string) *memoryTenant {
+	t, ok := m.tenants[tenant]
+	if !ok {
+		t = &memoryTenant{
+			scrolls: make(map[string]types.Scroll),
+			plans:   make(map[string]types.GeneInterventionPlan),
+		}
+		m.tenants[tenant] = t
+	}
+	return t
+}
+
+func (m *MemoryStore) SaveScroll(tenant string, scroll types.Scroll) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.write(tenant)
+	existing, exists := t.scrolls[scroll.ID]
+	if existing.Frozen {
+		return ErrFrozen
+	}
+	if !exists {
+		t.order = append(t.order, scroll.ID)
+	}
+	t.scrolls[scroll.ID] = scroll
+	t.compost = slices.DeleteFunc(t.compost, func(c CompostedScroll) bool { return c.Scroll.ID == scroll.ID })
+	return nil
+}
+
+func (m *MemoryStore) GetScroll(tenant, id string) (types.Scroll, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	scroll, ok := m.read(tenant).scrolls[id]
+	if !ok {
+		return types.Scroll{}, ErrNotFound
+	}
+	return scroll, nil
+}
+
+func (m *MemoryStore) ListScrolls(tenant string, q ScrollQuery) ([]types.Scroll, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t := m.read(tenant)
+	all := make([]types.Scroll, 0, len(t.order))
+	for _, id := range t.order {
+		if scroll := t.scrolls[id]; q.matches(scroll) {
+			all = append(all, scroll)
+		}
+	}
+	return q.page(all), nil
+}
+
+func (m *MemoryStore) CountScrolls(tenant string, q ScrollQuery) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t := m.read(tenant)
+	n := 0
+	for _, id := range t.order {
+		if q.matches(t.scrolls[id]) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryStore) UpdateTags(tenant, id string, add, remove []string) (types.Scroll, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.write(tenant)
+	scroll, ok := t.scrolls[id]
+	if !ok {
+		return types.Scroll{}, ErrNotFound
+	}
+	if scroll.Frozen {
+		return types.Scroll{}, ErrFrozen
+	}
+	scroll.Tags = mergeTags(scroll.Tags, add, remove)
+	t.scrolls[id] = scroll
+	return scroll, nil
+}
+
+func (m *MemoryStore) SetFrozen(tenant, id string, frozen bool) (types.Scroll, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.write(tenant)
+	scroll, ok := t.scrolls[id]
+	if !ok {
+		return types.Scroll{}, ErrNotFound
+	}
+	scroll.Frozen = frozen
+	t.scrolls[id] = scroll
+	return scroll, nil
+}
+
+func (m *MemoryStore) SavePlan(tenant, scrollID string, plan types.GeneInterventionPlan) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.write(tenant)
+	if t.scrolls[scrollID].Frozen {
+		return ErrFrozen
+	}
+	t.plans[scrollID] = plan
+	return nil
+}
+
+func (m *MemoryStore) GetPlan(tenant, scrollID string) (types.GeneInterventionPlan, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	plan, ok := m.read(tenant).plans[scrollID]
+	if !ok {
+		return types.GeneInterventionPlan{}, ErrNotFound
+	}
+	return plan, nil
+}
+
+func (m *MemoryStore) EachPlan(tenant string, fn func(types.GeneInterventionPlan) error) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, plan := range m.read(tenant).plans {
+		if err := fn(plan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStore) CountRebirthEligible(tenant string) (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t := m.read(tenant)
+	n := 0
+	for _, id := range t.order {
+		if plan, ok := t.plans[id]; ok && plan.RebirthEligible {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MemoryStore) CompostScroll(tenant, id string, reason CompostReason, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.write(tenant)
+	scroll, ok := t.scrolls[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if scroll.Frozen {
+		return ErrFrozen
+	}
+	delete(t.scrolls, id)
+	for i, oid := range t.order {
+		if oid == id {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+	t.compost = append(t.compost, CompostedScroll{Tenant: tenant, Scroll: scroll, Reason: reason, CompostedAt: at})
+	return nil
+}
+
+func (m *MemoryStore) ListCompost(tenant string) ([]CompostedScroll, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t := m.read(tenant)
+	out := make([]CompostedScroll, len(t.compost))
+	copy(out, t.compost)
+	return out, nil
+}
+
+func (m *MemoryStore) ListAllCompost() ([]CompostedScroll, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := []CompostedScroll{}
+	for _, t := range m.tenants {
+		out = append(out, t.compost...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].CompostedAt.Before(out[j].CompostedAt) })
+	return out, nil
+}
+
+func (m *MemoryStore) RestoreScroll(tenant, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.write(tenant)
+	if _, ok := t.scrolls[id]; ok {
+		return ErrExists
+	}
+	i := slices.IndexFunc(t.compost, func(c CompostedScroll) bool { return c.Scroll.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	scroll := t.compost[i].Scroll
+	t.compost = slices.Delete(t.compost, i, i+1)
+	t.scrolls[id] = scroll
+	t.order = append(t.order, id)
+	return nil
+}
+
+func (m *MemoryStore) PurgeCompost(before time.Time) ([]CompostedScroll, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	purged := []CompostedScroll{}
+	for _, t := range m.tenants {
+		kept := t.compost[:0]
+		for _, c := range t.compost {
+			if c.CompostedAt.Before(before) {
+				purged = append(purged, c)
+				if _, live := t.scrolls[c.Scroll.ID]; !live {
+					delete(t.plans, c.Scroll.ID)
+				}
+			} else {
+				kept = append(kept, c)
+			}
+		}
+		t.compost = kept
+	}
+	sort.SliceStable(purged, func(i, j int) bool { return purged[i].CompostedAt.Before(purged[j].CompostedAt) })
+	return purged, nil
+}
+
+func (m *MemoryStore) AppendAudit(tenant string, entry AuditEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.write(tenant)
+	entry.Seq = int64(len(t.audit) + 1)
+	entry.Plan.TargetedGenes = slices.Clone(entry.Plan.TargetedGenes)
+	t.audit = append(t.audit, entry)
+	return nil
+}
+
+func (m *MemoryStore) ListAudit(tenant, scrollID string) ([]AuditEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := []AuditEntry{}
+	for _, e := range m.read(tenant).audit {
+		if e.ScrollID == scrollID {
+			e.Plan.TargetedGenes = slices.Clone(e.Plan.TargetedGenes)
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
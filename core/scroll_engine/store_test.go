@@ -0,0 +1,122 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func day(d int) time.Time { return time.Date(2026, 1, d, 12, 0, 0, 0, time.UTC) }
+
+func seedTimedServer() http.Handler {
+	srv := NewServer(DefaultConfig())
+	for _, s := range []types.Scroll{
+		{ID: "jan1", TrustScore: 0.5, Timestamp: day(1)},
+		{ID: "jan5", TrustScore: 0.5, Timestamp: day(5)},
+		{ID: "jan9", TrustScore: 0.5, Timestamp: day(9)},
+	} {
+		_ = srv.store.SaveScroll(testTenant, s)
+	}
+	return srv.Handler()
+}
+
+func listScrolls(t *testing.T, h http.Handler, query string) (int, ScrollPage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(http.MethodGet, "/scrolls?"+query, nil))
+	var page ScrollPage
+	if rec.Code == http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+	}
+	return rec.Code, page
+}
+
+func pageIDs(page ScrollPage) string {
+	ids := make([]string, len(page.Scrolls))
+	for i, s := range page.Scrolls {
+		ids[i] = s.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestListScrolls_TimeRange(t *testing.T) {
+	h := seedTimedServer()
+	ts := func(d int) string { return day(d).Format(time.RFC3339) }
+	cases := []struct {
+		name, query, want string
+	}{
+		{"unbounded", "", "jan1,jan5,jan9"},
+		{"from only", "from=" + ts(5), "jan5,jan9"},
+		{"to only", "to=" + ts(5), "jan1,jan5"},
+		{"both", "from=" + ts(2) + "&to=" + ts(8), "jan5"},
+		{"with paging", "from=" + ts(1) + "&limit=1&offset=1", "jan5"},
+	}
+	for _, c := range cases {
+		code, page := listScrolls(t, h, c.query)
+		if code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", c.name, code)
+		}
+		if got := pageIDs(page); got != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, got)
+		}
+	}
+
+	if _, page := listScrolls(t, h, "from="+ts(1)+"&limit=1"); page.Total != 3 {
+		t.Fatalf("expected total to count the whole range, got %d", page.Total)
+	}
+}
+
+func TestListScrolls_RejectsBadRange(t *testing.T) {
+	h := seedTimedServer()
+	for _, q := range []string{
+		"from=" + day(9).Format(time.RFC3339) + "&to=" + day(1).Format(time.RFC3339),
+		"from=yesterday",
+	} {
+		if code, _ := listScrolls(t, h, q); code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", q, code)
+		}
+	}
+}
+
+// TestScrollStores_Agree runs the same writes against every backend, so
+// behaviour one store gets right and the other drifts from is caught.
+func TestScrollStores_Agree(t *testing.T) {
+	for name, store := range map[string]ScrollStore{"memory": NewMemoryStore(), "sqlite": openTestSQLite(t)} {
+		t.Run(name, func(t *testing.T) {
+			saved := types.Scroll{ID: "s1", TrustScore: 0.2, SchemaVersion: types.CurrentScrollSchemaVersion}
+			if err := store.SaveScroll(testTenant, saved); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			if got, _ := store.GetScroll(testTenant, "s1"); got.SchemaVersion != saved.SchemaVersion {
+				t.Fatalf("expected schema version %d kept, got %d", saved.SchemaVersion, got.SchemaVersion)
+			}
+
+			// Re-saving a composted ID brings it back and empties its bin entry.
+			if err := store.CompostScroll(testTenant, "s1", ReasonLowTrust, compostNow); err != nil {
+				t.Fatalf("compost: %v", err)
+			}
+			if err := store.SaveScroll(testTenant, types.Scroll{ID: "s1", TrustScore: 0.9}); err != nil {
+				t.Fatalf("re-save: %v", err)
+			}
+			if bin, _ := store.ListCompost(testTenant); len(bin) != 0 {
+				t.Fatalf("expected the re-saved scroll out of the compost bin, got %+v", bin)
+			}
+			if all, _ := store.ListAllCompost(); len(all) != 0 {
+				t.Fatalf("expected no compost across tenants, got %+v", all)
+			}
+			if got, err := store.GetScroll(testTenant, "s1"); err != nil || got.TrustScore != 0.9 || got.SchemaVersion != 0 {
+				t.Fatalf("expected the re-saved scroll stored as given, got %+v (%v)", got, err)
+			}
+			if n, _ := store.CountScrolls(testTenant, ScrollQuery{}); n != 1 {
+				t.Fatalf("expected s1 listed once, got %d scrolls", n)
+			}
+		})
+	}
+}
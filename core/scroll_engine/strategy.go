@@ -0,0 +1,108 @@
+package scroll_engine
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// Score is a scoring strategy's prediction for intervening on a scroll's
+// targeted genes. Contributions, when the strategy can attribute its scores,
+// lists the weight each target carried. Fallback is set when the strategy
+// could not score as intended and substituted another, and says why.
+type Score struct {
+	PredictedRelief  float64
+	FlareSuppression float64
+	Contributions    []types.MarkerContribution
+	Fallback         string
+}
+
+// validate checks that the score is finite and within the range a plan
+// allows.
+func (s Score) validate() error {
+	return types.GeneInterventionPlan{PredictedRelief: s.PredictedRelief, FlareSuppression: s.FlareSuppression}.Validate()
+}
+
+// ScoringStrategy predicts the effect of an intervention on targets. It
+// must return ctx.Err() promptly once ctx is done.
+type ScoringStrategy interface {
+	Score(ctx context.Context, scroll types.Scroll, targets []string) (Score, error)
+}
+
+// MarkerWeight is a single marker's contribution to relief and flare
+// suppression.
+type MarkerWeight struct {
+	Relief      float64 `json:"relief"`
+	Suppression float64 `json:"suppression"`
+}
+
+// WeightedStrategy scores an intervention as the mean weight of its targets,
+// using Default for any target without an entry in Weights.
+type WeightedStrategy struct {
+	Weights map[string]MarkerWeight
+	Default MarkerWeight
+}
+
+func (w WeightedStrategy) Score(ctx context.Context, _ types.Scroll, targets []string) (Score, error) {
+	if err := ctx.Err(); err != nil {
+		return Score{}, err
+	}
+	if len(targets) == 0 {
+		return Score{}, nil
+	}
+
+	var s Score
+	for _, g := range targets {
+		mw, ok := w.Weights[g]
+		if !ok {
+			mw = w.Default
+		}
+		s.PredictedRelief += mw.Relief
+		s.FlareSuppression += mw.Suppression
+		s.Contributions = append(s.Contributions, types.MarkerContribution{
+			Gene: g, Relief: mw.Relief, Suppression: mw.Suppression,
+		})
+	}
+	n := float64(len(targets))
+	s.PredictedRelief /= n
+	s.FlareSuppression /= n
+	return s, nil
+}
+
+// scoringWith returns the configured strategy: Scoring if set, otherwise an
+// HTTPScoringStrategy when ScoringURL is set, otherwise a WeightedStrategy
+// over the configured marker weights, IDF-weighted by idf under IDF scoring.
+// Weights are canonicalized through reg and external scoring is guarded by
+// breaker; breaker and idf may be nil.
+func (c SimulationConfig) scoringWith(reg *MarkerRegistry, breaker *CircuitBreaker, idf *MarkerIDF) ScoringStrategy {
+	if c.Scoring != nil {
+		return c.Scoring
+	}
+	// Targets arrive canonicalized, so weights are keyed the same way.
+	weights := make(map[string]MarkerWeight, len(c.MarkerWeights))
+	for marker, w := range c.MarkerWeights {
+		weights[reg.Canonicalize(marker)] = w
+	}
+	for marker, w := range c.WeightOverrides {
+		weights[reg.Canonicalize(marker)] = w
+	}
+	local := WeightedStrategy{Weights: weights, Default: c.DefaultMarkerWeight}
+	if c.ScoringURL != "" {
+		return HTTPScoringStrategy{URL: c.ScoringURL, Client: scoringClient, Retry: c.ScoringRetry, Fallback: local, Breaker: breaker}
+	}
+	if c.ScoringKind == ScoringIDF {
+		return IDFWeightedStrategy{Base: local, IDF: idf}
+	}
+	return local
+}
+
+// weightedScoring reports whether scoring is the WeightedStrategy built from
+// the configured marker weights, the only scoring weight overrides apply to.
+func (c SimulationConfig) weightedScoring() bool {
+	return c.Scoring == nil && c.ScoringURL == ""
+}
+
+// scoringClient is shared by HTTP scoring strategies built from config.
+var scoringClient = &http.Client{Timeout: 10 * time.Second}
@@ -0,0 +1,150 @@
+package scroll_engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// blockingStrategy never scores; it waits for its context to end.
+type blockingStrategy struct{}
+
+func (blockingStrategy) Score(ctx context.Context, _ types.Scroll, _ []string) (Score, error) {
+	<-ctx.Done()
+	return Score{}, ctx.Err()
+}
+
+var flareScroll = types.Scroll{ID: "f", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"g1"}}
+
+func TestWeightedStrategy_MeanOfWeights(t *testing.T) {
+	w := WeightedStrategy{
+		Weights: map[string]MarkerWeight{"A": {Relief: 0.2, Suppression: 0.4}},
+		Default: MarkerWeight{Relief: 0.6, Suppression: 0.8},
+	}
+
+	s, err := w.Score(context.Background(), types.Scroll{}, []string{"A", "B"})
+	if err != nil {
+		t.Fatalf("score: %v", err)
+	}
+	if math.Abs(s.PredictedRelief-0.4) > 1e-9 || math.Abs(s.FlareSuppression-0.6) > 1e-9 {
+		t.Fatalf("expected relief 0.4 / suppression 0.6, got %+v", s)
+	}
+}
+
+func TestSimulateWithConfig_CancelledStrategyReturnsPromptly(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Scoring = blockingStrategy{}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := SimulateWithConfig(ctx, flareScroll, cfg)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected prompt return after deadline, took %s", elapsed)
+	}
+}
+
+func TestSimulateHandler_ContextErrors(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Scoring = blockingStrategy{}
+	h := NewServer(cfg).Handler()
+	body := `{"id":"f","trust_score":0.9,"is_flare_event":true,"genetic_markers":["g1"]}`
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := newTenantRequest(http.MethodPost, "/simulate", strings.NewReader(body)).WithContext(cancelled)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != StatusClientClosedRequest {
+		t.Fatalf("expected 499 for disconnected client, got %d", rec.Code)
+	}
+
+	expiring, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req = newTenantRequest(http.MethodPost, "/simulate", strings.NewReader(body)).WithContext(expiring)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 for exceeded deadline, got %d", rec.Code)
+	}
+}
+
+func decodePlan(t *testing.T, rec *httptest.ResponseRecorder) types.GeneInterventionPlan {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body)
+	}
+	var plan types.GeneInterventionPlan
+	if err := json.NewDecoder(rec.Body).Decode(&plan); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return plan
+}
+
+func TestSimulate_WeightOverridesApplyToOneRequest(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	plain := `{"id":"f","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`
+	overridden := `{"id":"f","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"],
+		"weight_overrides":{"nod2":{"relief":0.25,"suppression":0.5}}}`
+
+	base := decodePlan(t, postSimulate(h, plain, ""))
+	plan := decodePlan(t, postSimulate(h, overridden, ""))
+	if plan.PredictedRelief != 0.25 || plan.FlareSuppression != 0.5 {
+		t.Fatalf("expected overridden scores, got relief %v suppression %v", plan.PredictedRelief, plan.FlareSuppression)
+	}
+	reason, ok := findReason(plan, ExplainWeightOverrides)
+	if !ok || len(reason.Markers) != 1 || reason.Markers[0].Gene != "NOD2" || reason.Markers[0].Relief != 0.25 {
+		t.Fatalf("expected explanation to note the NOD2 override, got %+v", plan.Explanation)
+	}
+
+	after := decodePlan(t, postSimulate(h, plain, ""))
+	if after.PredictedRelief != base.PredictedRelief {
+		t.Fatalf("expected override not to persist: relief %v, want %v", after.PredictedRelief, base.PredictedRelief)
+	}
+	if _, ok := findReason(after, ExplainWeightOverrides); ok {
+		t.Fatalf("expected no override reason on a plain request")
+	}
+}
+
+func TestSimulate_WeightOverridesValidated(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	rec := postSimulate(h, `{"id":"f","trust_score":0.9,"weight_overrides":{"NOD2":{"relief":-0.1,"suppression":0.5}}}`, "")
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a negative override, got %d", rec.Code)
+	}
+	body := decodeErrorResponse(t, rec)
+	if body.Code != CodeInvalidScroll || body.Field != "weight_overrides.NOD2.relief" {
+		t.Fatalf("unexpected error: %+v", body)
+	}
+}
+
+func TestSimulate_WeightOverridesRejectedWithoutWeightedScoring(t *testing.T) {
+	custom := DefaultConfig()
+	custom.Scoring = slowStrategy{}
+	external := DefaultConfig()
+	external.ScoringURL = "http://127.0.0.1:1/score"
+	body := `{"id":"f","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"],"weight_overrides":{"NOD2":{"relief":0.1,"suppression":0.1}}}`
+
+	for name, cfg := range map[string]SimulationConfig{"custom": custom, "external": external} {
+		h := NewServer(cfg).Handler()
+		rec := postSimulate(h, body, "")
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", name, rec.Code, rec.Body)
+		}
+		if e := decodeErrorResponse(t, rec); e.Code != CodeInvalidScroll || e.Field != "weight_overrides" {
+			t.Fatalf("%s: unexpected error: %+v", name, e)
+		}
+	}
+}
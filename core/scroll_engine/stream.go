@@ -0,0 +1,90 @@
+package scroll_engine
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON.
+const ndjsonContentType = "application/x-ndjson"
+
+// maxStreamLine bounds one scroll in an NDJSON stream.
+const maxStreamLine = 1 << 20
+
+// StreamError is the line written in place of a plan when a line of an
+// NDJSON simulation stream cannot be simulated. Line is 1-based.
+type StreamError struct {
+	Line  int       `json:"line"`
+	Error ErrorBody `json:"error"`
+}
+
+func isNDJSON(r *http.Request) bool {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mt == ndjsonContentType
+}
+
+// simulateStream simulates each line of an NDJSON body as a scroll and
+// writes back, one per line, the plan /simulate would respond with,
+// flushing after each so large ingests never buffer. A line that fails gets
+// a StreamError and the stream continues; blank lines are skipped.
+// Idempotency-Key is not honored here.
+func (s *Server) simulateStream(w http.ResponseWriter, r *http.Request, tenant string) {
+	rc := http.NewResponseController(w)
+	// Without full duplex, HTTP/1 would drain the whole body before the
+	// first plan is written.
+	_ = rc.EnableFullDuplex()
+
+	w.Header().Set("Content-Type", ndjsonContentType)
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	emit := func(v any) bool {
+		return enc.Encode(v) == nil && rc.Flush() == nil
+	}
+
+	sc := bufio.NewScanner(r.Body)
+	sc.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+	line := 0
+	for sc.Scan() {
+		line++
+		raw := sc.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		resp, rerr := s.simulateLine(r, raw, tenant)
+		var out any = resp
+		if rerr != nil {
+			out = StreamError{Line: line, Error: rerr.body}
+		}
+		if !emit(out) || r.Context().Err() != nil {
+			return
+		}
+	}
+	if err := sc.Err(); err != nil {
+		emit(StreamError{Line: line + 1, Error: decodeError(err).body})
+	}
+}
+
+// simulateLine runs one streamed scroll through the same path as a single
+// /simulate request.
+func (s *Server) simulateLine(r *http.Request, raw []byte, tenant string) (simulateResponse, *requestError) {
+	req, rerr := s.parseSimulateRequest(raw, tenant)
+	if rerr != nil {
+		return simulateResponse{}, rerr
+	}
+	return s.simulateAndRecord(r.Context(), req)
+}
+
+// acceptsNDJSON reports whether the client asked for an NDJSON response.
+func acceptsNDJSON(r *http.Request) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mt, _, err := mime.ParseMediaType(strings.TrimSpace(v)); err == nil && mt == ndjsonContentType {
+			return true
+		}
+	}
+	return false
+}
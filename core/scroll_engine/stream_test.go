@@ -0,0 +1,101 @@
+package scroll_engine
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestSimulateStream_PlansPerLineWithErrorsInline(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+
+	body := strings.Join([]string{
+		`{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`,
+		`{"id":"s2","trust_score":`,
+		``,
+		`{"id":"s3","trust_score":2}`,
+		`{"id":"s4","trust_score":0.3}`,
+	}, "\n") + "\n"
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/simulate", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set(TenantHeader, testTenant)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("expected application/x-ndjson, got %q", ct)
+	}
+
+	var lines []string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 response lines, got %d: %q", len(lines), lines)
+	}
+
+	var plan types.GeneInterventionPlan
+	if err := json.Unmarshal([]byte(lines[0]), &plan); err != nil || plan.Branch != BranchFlare {
+		t.Fatalf("line 1: expected a flare plan, got %s", lines[0])
+	}
+	for i, want := range []struct {
+		line int
+		code string
+	}{{2, CodeMalformedJSON}, {4, CodeInvalidScroll}} {
+		var se StreamError
+		if err := json.Unmarshal([]byte(lines[i+1]), &se); err != nil || se.Line != want.line || se.Error.Code != want.code {
+			t.Fatalf("response %d: expected %s on line %d, got %s", i+2, want.code, want.line, lines[i+1])
+		}
+	}
+	plan = types.GeneInterventionPlan{}
+	if err := json.Unmarshal([]byte(lines[3]), &plan); err != nil || plan.Branch == "" {
+		t.Fatalf("line 5: expected a plan after the errors, got %s", lines[3])
+	}
+
+	if n, _ := srv.store.CountScrolls(testTenant, ScrollQuery{}); n != 2 {
+		t.Fatalf("expected the 2 valid scrolls stored, got %d", n)
+	}
+}
+
+func TestSimulateStream_DegradesAndNotifiesLikeSingleRequests(t *testing.T) {
+	hook, events := webhookRecorder(t, 0)
+	cfg := DefaultConfig()
+	cfg.StoreFailure = StoreFailOpen
+	cfg.WebhookURL = hook.URL
+	srv := NewServerWithStore(cfg, downStore{NewMemoryStore()})
+
+	req := newTenantRequest(http.MethodPost, "/simulate", strings.NewReader(budgetFlareBody+"\n"))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+
+	var resp simulateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Branch != BranchFlare {
+		t.Fatalf("expected a flare plan line, got %s", rec.Body)
+	}
+	if resp.Persisted {
+		t.Fatalf("expected the streamed plan marked as not persisted")
+	}
+	if got := srv.metrics.Counter("store_write_failures_total"); got != 1 {
+		t.Fatalf("expected one store write failure counted, got %v", got)
+	}
+	select {
+	case e := <-events:
+		if e.ScrollID != "f" {
+			t.Fatalf("expected a webhook for scroll f, got %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the streamed scroll's webhook to fire")
+	}
+}
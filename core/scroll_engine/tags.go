@@ -0,0 +1,110 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// maxTagLength bounds a normalized tag.
+const maxTagLength = 64
+
+// NormalizeTags trims and lowercases tags, drops duplicates, and sorts the
+// result. It rejects tags that are empty once trimmed or longer than 64
+// bytes.
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		switch {
+		case tag == "":
+			return nil, &types.ValidationError{Field: "tags", Message: "tags must not be empty"}
+		case len(tag) > maxTagLength:
+			return nil, &types.ValidationError{Field: "tags", Message: fmt.Sprintf("tag %q is longer than %d bytes", tag, maxTagLength)}
+		}
+		out = append(out, tag)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+// mergeTags returns the normalized tags with add included and remove
+// excluded, sorted. Inputs must already be normalized.
+func mergeTags(tags, add, remove []string) []string {
+	merged := append(slices.Clone(tags), add...)
+	merged = slices.DeleteFunc(merged, func(t string) bool { return slices.Contains(remove, t) })
+	slices.Sort(merged)
+	merged = slices.Compact(merged)
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// tagsRequest is the body of POST /scrolls/{id}/tags.
+type tagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+func (s *Server) addTagsHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	var req tagsRequest
+	if err := decodeBody(r.Body, &req); err != nil {
+		writeDecodeError(w, err)
+		return
+	}
+	tags, err := NormalizeTags(req.Tags)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	if len(tags) == 0 {
+		writeError(w, http.StatusBadRequest, CodeInvalidInput, "at least one tag is required", "tags")
+		return
+	}
+	s.updateTags(w, tenant, r.PathValue("id"), tags, nil)
+}
+
+func (s *Server) removeTagHandler(w http.ResponseWriter, r *http.Request) {
+	tenant, ok := tenantID(w, r)
+	if !ok {
+		return
+	}
+	tags, err := NormalizeTags([]string{r.PathValue("tag")})
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	s.updateTags(w, tenant, r.PathValue("id"), nil, tags)
+}
+
+// updateTags applies a tag change and writes the updated scroll.
+func (s *Server) updateTags(w http.ResponseWriter, tenant, id string, add, remove []string) {
+	scroll, err := s.store.UpdateTags(tenant, id, add, remove)
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, CodeNotFound, "scroll not found", "id")
+		return
+	}
+	if errors.Is(err, ErrFrozen) {
+		persistError(err).write(w)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error(), "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(scroll)
+}
@@ -0,0 +1,73 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func tagRequest(t *testing.T, h http.Handler, method, target, body string) (int, types.Scroll) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newTenantRequest(method, target, strings.NewReader(body)))
+	var scroll types.Scroll
+	_ = json.Unmarshal(rec.Body.Bytes(), &scroll)
+	return rec.Code, scroll
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := NormalizeTags([]string{" Cohort-B", "cohort-a", "COHORT-B", "cohort-a "})
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if want := []string{"cohort-a", "cohort-b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, err := NormalizeTags([]string{"ok", "  "}); err == nil {
+		t.Fatalf("expected a blank tag to be rejected")
+	}
+}
+
+func TestScrollTags_AddRemoveAndFilter(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+	for _, s := range []types.Scroll{
+		{ID: "a", TrustScore: 0.5},
+		{ID: "b", TrustScore: 0.5, Tags: []string{"Pilot"}},
+		{ID: "c", TrustScore: 0.5},
+	} {
+		body, _ := json.Marshal(s)
+		postSimulate(h, string(body), "")
+	}
+
+	code, scroll := tagRequest(t, h, http.MethodPost, "/scrolls/a/tags", `{"tags":[" Pilot ","EU","eu"]}`)
+	if code != http.StatusOK || !reflect.DeepEqual(scroll.Tags, []string{"eu", "pilot"}) {
+		t.Fatalf("add tags: %d %v", code, scroll.Tags)
+	}
+	tagRequest(t, h, http.MethodPost, "/scrolls/b/tags", `{"tags":["eu"]}`)
+	tagRequest(t, h, http.MethodPost, "/scrolls/c/tags", `{"tags":["EU"]}`)
+
+	if _, page := listScrolls(t, h, "tag=EU&tag=pilot"); page.Total != 2 || page.Scrolls[0].ID != "a" || page.Scrolls[1].ID != "b" {
+		t.Fatalf("expected a and b to carry both tags, got %+v", page)
+	}
+
+	code, scroll = tagRequest(t, h, http.MethodDelete, "/scrolls/b/tags/PILOT", "")
+	if code != http.StatusOK || !reflect.DeepEqual(scroll.Tags, []string{"eu"}) {
+		t.Fatalf("remove tag: %d %v", code, scroll.Tags)
+	}
+	if _, page := listScrolls(t, h, "tag=eu&tag=pilot"); page.Total != 1 || page.Scrolls[0].ID != "a" {
+		t.Fatalf("expected only a after removing b's tag, got %+v", page)
+	}
+
+	if code, _ := tagRequest(t, h, http.MethodPost, "/scrolls/missing/tags", `{"tags":["eu"]}`); code != http.StatusNotFound {
+		t.Fatalf("expected 404 tagging a missing scroll, got %d", code)
+	}
+	if code, _ := tagRequest(t, h, http.MethodPost, "/scrolls/a/tags", `{"tags":[]}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for no tags, got %d", code)
+	}
+}
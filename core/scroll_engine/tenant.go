@@ -0,0 +1,32 @@
+package scroll_engine
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// TenantHeader names the tenant a request acts for. Every store-backed
+// endpoint requires it and sees only that tenant's records.
+const TenantHeader = "X-Tenant-ID"
+
+// DefaultTenant owns records written before stores were tenant-scoped, and
+// is the tenant command-line tools act for unless told otherwise.
+const DefaultTenant = "default"
+
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// tenantID returns the request's tenant, writing a 400 and returning false
+// if the header is missing or malformed.
+func tenantID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	tenant := r.Header.Get(TenantHeader)
+	if tenant == "" {
+		writeError(w, http.StatusBadRequest, CodeMissingTenant, TenantHeader+" header is required", TenantHeader)
+		return "", false
+	}
+	if !tenantPattern.MatchString(tenant) {
+		writeError(w, http.StatusBadRequest, CodeMissingTenant,
+			TenantHeader+" must be 1-64 letters, digits, '.', '_' or '-'", TenantHeader)
+		return "", false
+	}
+	return tenant, true
+}
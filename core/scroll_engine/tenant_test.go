@@ -0,0 +1,151 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// testTenant is the tenant test requests act for unless they say otherwise.
+const testTenant = "test"
+
+// newTenantRequest is httptest.NewRequest with the tenant header set to
+// testTenant.
+func newTenantRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set(TenantHeader, testTenant)
+	return req
+}
+
+func TestTenant_MissingOrMalformedHeaderRejected(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	for _, tc := range []struct {
+		name, tenant string
+	}{
+		{"missing", ""},
+		{"malformed", "a/b"},
+		{"too long", strings.Repeat("x", 65)},
+	} {
+		for _, path := range []string{"/scrolls", "/stats", "/scrolls/search?marker=NOD2", "/audit?scroll_id=s1"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			if tc.tenant != "" {
+				req.Header.Set(TenantHeader, tc.tenant)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("%s %s: expected 400, got %d", tc.name, path, rec.Code)
+			}
+			var resp ErrorResponse
+			_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+			if resp.Error.Code != CodeMissingTenant || resp.Error.Field != TenantHeader {
+				t.Fatalf("%s %s: unexpected error %+v", tc.name, path, resp.Error)
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(`{"id":"s1","trust_score":0.5}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("simulate without tenant: expected 400, got %d", rec.Code)
+	}
+}
+
+func TestTenant_ScrollsIsolated(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	do := func(tenant, method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(TenantHeader, tenant)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := do("acme", http.MethodPost, "/simulate", `{"id":"shared","trust_score":0.9,"is_flare_event":true,"genetic_markers":["NOD2"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("simulate for acme: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("acme", http.MethodPost, "/simulate", `{"id":"acme-only","trust_score":0.4,"genetic_markers":["IL23R"]}`); rec.Code != http.StatusOK {
+		t.Fatalf("simulate for acme: %d %s", rec.Code, rec.Body)
+	}
+	if rec := do("globex", http.MethodPost, "/simulate", `{"id":"shared","trust_score":0.2}`); rec.Code != http.StatusOK {
+		t.Fatalf("simulate for globex: %d %s", rec.Code, rec.Body)
+	}
+
+	var page ScrollPage
+	_ = json.Unmarshal(do("globex", http.MethodGet, "/scrolls", "").Body.Bytes(), &page)
+	if page.Total != 1 || page.Scrolls[0].ID != "shared" || page.Scrolls[0].TrustScore != 0.2 {
+		t.Fatalf("globex should see only its own scroll, got %+v", page)
+	}
+
+	if rec := do("globex", http.MethodGet, "/scrolls/acme-only", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("globex fetched acme's scroll: %d", rec.Code)
+	}
+	var scroll types.Scroll
+	_ = json.Unmarshal(do("acme", http.MethodGet, "/scrolls/shared", "").Body.Bytes(), &scroll)
+	if scroll.TrustScore != 0.9 {
+		t.Fatalf("acme's scroll was overwritten by globex's: %+v", scroll)
+	}
+
+	page = ScrollPage{}
+	_ = json.Unmarshal(do("globex", http.MethodGet, "/scrolls/search?marker=IL23R", "").Body.Bytes(), &page)
+	if page.Total != 0 {
+		t.Fatalf("globex search matched acme's scrolls: %+v", page)
+	}
+
+	var stats StoreStats
+	_ = json.Unmarshal(do("acme", http.MethodGet, "/stats", "").Body.Bytes(), &stats)
+	if stats.TotalScrolls != 2 {
+		t.Fatalf("acme stats: expected 2 scrolls, got %+v", stats)
+	}
+	stats = StoreStats{}
+	_ = json.Unmarshal(do("globex", http.MethodGet, "/stats", "").Body.Bytes(), &stats)
+	if stats.TotalScrolls != 1 {
+		t.Fatalf("globex stats: expected 1 scroll, got %+v", stats)
+	}
+}
+
+func TestTenant_IdempotencyKeysScoped(t *testing.T) {
+	h := NewServer(DefaultConfig()).Handler()
+	post := func(tenant, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(body))
+		req.Header.Set(TenantHeader, tenant)
+		req.Header.Set("Idempotency-Key", "k1")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	post("acme", `{"id":"a","trust_score":0.5}`)
+	rec := post("globex", `{"id":"b","trust_score":0.5}`)
+	if rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("another tenant's key should not collide: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestMemoryStore_TenantsIsolated(t *testing.T) {
+	store := NewMemoryStore()
+	_ = store.SaveScroll("a", types.Scroll{ID: "s1", TrustScore: 0.1})
+	_ = store.SavePlan("a", "s1", types.GeneInterventionPlan{Branch: BranchCompost})
+	_ = store.AppendAudit("a", AuditEntry{ScrollID: "s1"})
+
+	if _, err := store.GetScroll("b", "s1"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound across tenants, got %v", err)
+	}
+	if _, err := store.GetPlan("b", "s1"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for plan across tenants, got %v", err)
+	}
+	if err := store.CompostScroll("b", "s1", ReasonDrift, day(1)); err != ErrNotFound {
+		t.Fatalf("tenant b composted tenant a's scroll: %v", err)
+	}
+	if n, _ := store.CountScrolls("b", ScrollQuery{}); n != 0 {
+		t.Fatalf("tenant b counted %d scrolls", n)
+	}
+	if entries, _ := store.ListAudit("b", "s1"); len(entries) != 0 {
+		t.Fatalf("tenant b saw %d audit entries", len(entries))
+	}
+}
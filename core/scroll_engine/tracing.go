@@ -0,0 +1,79 @@
+package scroll_engine
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// tracerName is the instrumentation scope of the engine's spans.
+const tracerName = "Maple-OS/modem_os/core/scroll_engine"
+
+// Span attribute keys.
+const (
+	attrScrollID = attribute.Key("scroll.id")
+	attrTrust    = attribute.Key("scroll.trust_score")
+	attrBranch   = attribute.Key("plan.branch")
+	attrTenant   = attribute.Key("tenant.id")
+)
+
+// traceContext propagates W3C traceparent and tracestate headers.
+var traceContext = propagation.TraceContext{}
+
+// SetTracerProvider makes the server trace requests with tp. Until it is
+// called, the server traces with a no-op provider.
+func (s *Server) SetTracerProvider(tp trace.TracerProvider) {
+	s.tracer = tp.Tracer(tracerName)
+}
+
+func noopTracer() trace.Tracer {
+	return noop.NewTracerProvider().Tracer(tracerName)
+}
+
+// startRequestSpan starts the server span for r, continuing the trace its
+// traceparent header names, if any.
+func (s *Server) startRequestSpan(r *http.Request, name string) (context.Context, trace.Span) {
+	ctx := traceContext.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	return s.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+}
+
+// startSpan starts a child of the span in ctx, traced by that span's
+// provider, so engine code needs no tracer of its own: without a span in
+// ctx, it traces nothing.
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(tracerName)
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan records err, if any, on span and ends it.
+func endSpan(span trace.Span, err error) {
+	recordSpanError(span, err)
+	span.End()
+}
+
+// recordSpanError marks span failed with err, if any.
+func recordSpanError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// tracedWrite runs write, a store write, in a span named name.
+func tracedWrite(ctx context.Context, name, scrollID string, write func() error) error {
+	_, span := startSpan(ctx, name, attrScrollID.String(scrollID))
+	err := write()
+	endSpan(span, err)
+	return err
+}
+
+func scrollAttrs(scroll types.Scroll) []attribute.KeyValue {
+	return []attribute.KeyValue{attrScrollID.String(scroll.ID), attrTrust.Float64(scroll.TrustScore)}
+}
@@ -0,0 +1,75 @@
+package scroll_engine
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracing_SimulateSpanHierarchy(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	srv := NewServer(DefaultConfig())
+	srv.SetTracerProvider(tp)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := newTenantRequest(http.MethodPost, "/simulate", strings.NewReader(budgetFlareBody))
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, req)
+	if resp := decodeSimulateResponse(t, rec); resp.Branch != BranchFlare {
+		t.Fatalf("expected a flare plan, got %s", resp.Branch)
+	}
+
+	spans := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		spans[s.Name()] = s
+	}
+	root, ok := spans["POST /simulate"]
+	if !ok {
+		t.Fatalf("expected a request span, got %v", spanNames(recorder.Ended()))
+	}
+	if got := root.SpanContext().TraceID().String(); got != traceID {
+		t.Fatalf("expected the request span to continue trace %s, got %s", traceID, got)
+	}
+	if got := root.Parent().SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Fatalf("expected the request span's parent to be the incoming span, got %s", got)
+	}
+
+	parents := map[string]string{
+		"Simulate":    "POST /simulate",
+		"Score":       "Simulate",
+		"SaveScroll":  "POST /simulate",
+		"SavePlan":    "POST /simulate",
+		"AppendAudit": "POST /simulate",
+	}
+	for child, parent := range parents {
+		c, ok := spans[child]
+		if !ok {
+			t.Fatalf("expected a %s span, got %v", child, spanNames(recorder.Ended()))
+		}
+		if c.Parent().SpanID() != spans[parent].SpanContext().SpanID() {
+			t.Fatalf("expected %s to be a child of %s", child, parent)
+		}
+	}
+
+	attrs := map[string]string{}
+	for _, kv := range spans["Simulate"].Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+	if attrs["scroll.id"] != "f" || attrs["plan.branch"] != BranchFlare || attrs["scroll.trust_score"] != "0.9" {
+		t.Fatalf("expected scroll ID, trust, and branch on the Simulate span, got %v", attrs)
+	}
+}
+
+func spanNames(spans []sdktrace.ReadOnlySpan) []string {
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name()
+	}
+	return names
+}
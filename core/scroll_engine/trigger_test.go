@@ -0,0 +1,144 @@
+package scroll_engine
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestTriggerGeneIntervention_TargetsPanelMatches(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FlareMarkers = []string{"NOD2", "IL23R"}
+	scroll := types.Scroll{ID: "f", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"il23r", "TNFSF15", "NOD2"}}
+
+	plan, err := TriggerGeneIntervention(context.Background(), scroll, cfg)
+	if err != nil {
+		t.Fatalf("trigger: %v", err)
+	}
+	if want := []string{"IL23R", "NOD2"}; plan.Branch != BranchFlare || !reflect.DeepEqual(plan.TargetedGenes, want) {
+		t.Fatalf("expected flare plan targeting %v, got %s %v", want, plan.Branch, plan.TargetedGenes)
+	}
+}
+
+func TestTriggerGeneIntervention_NoPanelMatch(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FlareMarkers = []string{"NOD2"}
+	scroll := types.Scroll{ID: "f", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"TNFSF15"}}
+
+	if _, err := TriggerGeneIntervention(context.Background(), scroll, cfg); !errors.Is(err, ErrNoEligibleTargets) {
+		t.Fatalf("expected ErrNoEligibleTargets, got %v", err)
+	}
+
+	plan := mustSimulate(t, scroll, cfg)
+	if plan.Branch != BranchCompost || plan.RebirthEligible {
+		t.Fatalf("expected the scroll held in memory, got %s rebirth=%v", plan.Branch, plan.RebirthEligible)
+	}
+	if _, ok := findReason(plan, ExplainFlarePanel); !ok {
+		t.Fatalf("expected a flare_panel reason, got %+v", plan.Explanation)
+	}
+}
+
+func TestTriggerGeneIntervention_TargetDetailsMatchAggregate(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MarkerWeights = map[string]MarkerWeight{
+		"NOD2":  {Relief: 0.9, Suppression: 0.8},
+		"IL23R": {Relief: 0.5, Suppression: 0.6},
+	}
+	scroll := types.Scroll{ID: "f", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2", "IL23R", "TNFSF15"}}
+
+	plan, err := TriggerGeneIntervention(context.Background(), scroll, cfg)
+	if err != nil {
+		t.Fatalf("trigger: %v", err)
+	}
+	if len(plan.TargetDetails) != len(plan.TargetedGenes) {
+		t.Fatalf("expected a detail per targeted gene, got %+v for %v", plan.TargetDetails, plan.TargetedGenes)
+	}
+	var relief, suppression float64
+	for i, d := range plan.TargetDetails {
+		if d.Gene != plan.TargetedGenes[i] || !d.OnPanel {
+			t.Fatalf("detail %d: expected on-panel %s, got %+v", i, plan.TargetedGenes[i], d)
+		}
+		relief += d.Relief
+		suppression += d.Suppression
+	}
+	n := float64(len(plan.TargetDetails))
+	if math.Abs(relief/n-plan.PredictedRelief) > 1e-9 || math.Abs(suppression/n-plan.FlareSuppression) > 1e-9 {
+		t.Fatalf("expected details to average to relief %.4f and suppression %.4f, got %.4f and %.4f",
+			plan.PredictedRelief, plan.FlareSuppression, relief/n, suppression/n)
+	}
+}
+
+func TestSimulate_TargetDetailsMarkPanelMisses(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FlareMarkers = []string{"NOD2"}
+	scroll := types.Scroll{ID: "c", TrustScore: 0.2, GeneticMarkers: []string{"NOD2", "TNFSF15"}}
+
+	plan := mustSimulate(t, scroll, cfg)
+	want := []types.MarkerContribution{{Gene: "NOD2", OnPanel: true}, {Gene: "TNFSF15"}}
+	if plan.Branch != BranchCompost || !reflect.DeepEqual(plan.TargetDetails, want) {
+		t.Fatalf("expected compost details %+v, got %s %+v", want, plan.Branch, plan.TargetDetails)
+	}
+}
+
+func TestSimulate_MinFlareMarkersBoundary(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.FlareMarkers = []string{"NOD2", "IL23R", "ATG16L1"}
+	cfg.MinFlareMarkers = 2
+
+	atMin := types.Scroll{ID: "f", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2", "IL23R", "TNFSF15"}}
+	if plan := mustSimulate(t, atMin, cfg); plan.Branch != BranchFlare {
+		t.Fatalf("expected exactly %d panel markers to trigger the flare branch, got %s", cfg.MinFlareMarkers, plan.Branch)
+	}
+
+	belowMin := types.Scroll{ID: "f", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"NOD2", "TNFSF15"}}
+	plan := mustSimulate(t, belowMin, cfg)
+	if plan.Branch != BranchCompost || plan.RebirthEligible {
+		t.Fatalf("expected one panel marker below the minimum to be held in memory, got %s rebirth=%v", plan.Branch, plan.RebirthEligible)
+	}
+	reason, ok := findReason(plan, ExplainFlarePanel)
+	if !ok || reason.Passed || *reason.Value != 1 || *reason.Threshold != 2 {
+		t.Fatalf("expected a failed flare_panel reason with 1 matched of 2 required, got %+v", reason)
+	}
+	if !strings.Contains(reason.Message, "1 of 2 required") {
+		t.Fatalf("expected the message to state matched vs required, got %q", reason.Message)
+	}
+	if _, err := TriggerGeneIntervention(context.Background(), belowMin, cfg); !errors.Is(err, ErrNoEligibleTargets) {
+		t.Fatalf("expected ErrNoEligibleTargets below the minimum, got %v", err)
+	}
+}
+
+// BenchmarkLowTrustCompost simulates the common production case: a
+// low-trust scroll that is composted, and one with no markers that starts a
+// discovery loop.
+func BenchmarkLowTrustCompost(b *testing.B) {
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+	c := compileConfig(DefaultConfig())
+	ctx := context.Background()
+	for _, bc := range []struct {
+		name   string
+		scroll types.Scroll
+		branch string
+	}{
+		{"compost", types.Scroll{ID: "low", TrustScore: 0.2, GeneticMarkers: []string{"nod2", "IL23R", "ATG16L1", "NOD2", "tnfsf15"}}, BranchCompost},
+		{"discovery", types.Scroll{ID: "bare", TrustScore: 0.2}, BranchDiscovery},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				plan, err := c.simulate(ctx, bc.scroll)
+				if err != nil || plan.Branch != bc.branch {
+					b.Fatalf("unexpected plan %s, %v", plan.Branch, err)
+				}
+			}
+		})
+	}
+}
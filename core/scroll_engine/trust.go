@@ -0,0 +1,165 @@
+package scroll_engine
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// NormalizeTrust rescales trust scores within a cohort to [0,1] using
+// min-max normalization, so the lowest score in the cohort maps to 0 and the
+// highest to 1. The input slice is not modified; a new slice of copies with
+// adjusted scores is returned.
+//
+// When the cohort has zero variance (every score equal, including a cohort of
+// one) there is no spread to rescale against, and scores are returned
+// unchanged rather than collapsed to an arbitrary constant.
+func NormalizeTrust(scrolls []types.Scroll) []types.Scroll {
+	out := make([]types.Scroll, len(scrolls))
+	copy(out, scrolls)
+	if len(out) == 0 {
+		return out
+	}
+
+	lo, hi := trustRange(out)
+	if hi == lo {
+		return out
+	}
+
+	for i := range out {
+		out[i].TrustScore = (out[i].TrustScore - lo) / (hi - lo)
+	}
+	return out
+}
+
+// trustRange returns the lowest and highest trust among scrolls, which must
+// not be empty.
+func trustRange(scrolls []types.Scroll) (lo, hi float64) {
+	lo, hi = scrolls[0].TrustScore, scrolls[0].TrustScore
+	for _, s := range scrolls[1:] {
+		lo = min(lo, s.TrustScore)
+		hi = max(hi, s.TrustScore)
+	}
+	return lo, hi
+}
+
+// Recency kernel kinds.
+const (
+	RecencyExponential = "exponential"
+	RecencyLinear      = "linear"
+	RecencyUniform     = "uniform"
+)
+
+// RecencyKernel weights a scroll's trust by the scroll's age when trust is
+// aggregated:
+//
+//   - "exponential" halves the weight every HalfLife.
+//   - "linear" falls from 1 at age zero to 0 at Window, so its slope is
+//     1/Window; older scrolls carry no weight.
+//   - "uniform" weights every scroll equally, whatever its age.
+//
+// Only the parameter of the selected kind is consulted.
+type RecencyKernel struct {
+	Kind     string   `json:"kind"`
+	HalfLife Duration `json:"half_life"`
+	Window   Duration `json:"window"`
+}
+
+// Weight returns the kernel's weight, in [0,1], for a scroll of the given
+// age. A negative age — a scroll dated in the future — is clamped to zero and
+// so gets full weight.
+func (k RecencyKernel) Weight(age time.Duration) float64 {
+	age = max(age, 0)
+	switch k.Kind {
+	case RecencyExponential:
+		return math.Exp2(-float64(age) / float64(k.HalfLife))
+	case RecencyLinear:
+		return max(1-float64(age)/float64(k.Window), 0)
+	}
+	return 1
+}
+
+func (k RecencyKernel) validate(key string) error {
+	switch k.Kind {
+	case RecencyExponential:
+		if k.HalfLife <= 0 {
+			return &ConfigError{Key: key + ".half_life", Message: "must be positive"}
+		}
+	case RecencyLinear:
+		if k.Window <= 0 {
+			return &ConfigError{Key: key + ".window", Message: "must be positive"}
+		}
+	case RecencyUniform:
+	default:
+		return &ConfigError{Key: key + ".kind", Message: fmt.Sprintf("unknown kernel %q", k.Kind)}
+	}
+	return nil
+}
+
+// AggregateTrust is AggregateTrustWithKernel using cfg.TrustRecency.
+func AggregateTrust(scrolls []types.Scroll, cfg SimulationConfig) float64 {
+	return AggregateTrustWithKernel(scrolls, cfg.TrustRecency)
+}
+
+// AggregateTrustWithKernel returns the mean of the scrolls' trust scores,
+// each weighted by kernel for the scroll's age as of now. Future-dated
+// scrolls are clamped to now rather than weighted above fresh ones. Undated
+// scrolls are treated as infinitely old: only the uniform kernel weights
+// them. It returns 0 when no scroll carries any weight.
+func AggregateTrustWithKernel(scrolls []types.Scroll, kernel RecencyKernel) float64 {
+	return aggregateTrustAt(scrolls, kernel, time.Now())
+}
+
+func aggregateTrustAt(scrolls []types.Scroll, kernel RecencyKernel, now time.Time) float64 {
+	var sum, total float64
+	for _, s := range scrolls {
+		w := 1.0
+		if !s.Timestamp.IsZero() {
+			w = kernel.Weight(now.Sub(s.Timestamp))
+		} else if kernel.Kind != RecencyUniform {
+			w = 0
+		}
+		sum += w * s.TrustScore
+		total += w
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// Kinds of TrustTransform.
+const (
+	// TrustStepNormalize is min-max normalization against a cohort: Offset
+	// is the cohort's lowest trust and Factor one over its spread.
+	TrustStepNormalize = "normalize"
+	// TrustStepDecay is SimulationConfig.TrustDecay: Factor is the kernel's
+	// weight for the scroll's age.
+	TrustStepDecay = "decay"
+)
+
+// newTrustProvenance starts a provenance chain for a submitted trust score,
+// with nothing yet applied to it.
+func newTrustProvenance(raw float64) types.TrustProvenance {
+	return types.TrustProvenance{Raw: raw, Steps: []types.TrustTransform{}, Effective: raw}
+}
+
+// applyTrustStep transforms p's effective trust and records the step.
+func applyTrustStep(p *types.TrustProvenance, kind string, offset, factor float64) {
+	before := p.Effective
+	p.Effective = (before - offset) * factor
+	p.Steps = append(p.Steps, types.TrustTransform{
+		Kind: kind, Offset: offset, Factor: factor, Before: before, After: p.Effective,
+	})
+}
+
+// trustProvenance applies the configured trust transformations to scroll,
+// as of now, continuing the chain in p.
+func (c *compiledConfig) trustProvenance(p types.TrustProvenance, scroll types.Scroll, now time.Time) types.TrustProvenance {
+	if k := c.cfg.TrustDecay; k.Kind != "" && !scroll.Timestamp.IsZero() {
+		applyTrustStep(&p, TrustStepDecay, 0, k.Weight(now.Sub(scroll.Timestamp)))
+	}
+	return p
+}
@@ -0,0 +1,175 @@
+package scroll_engine
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+func TestNormalizeTrust_RescalesCohort(t *testing.T) {
+	in := []types.Scroll{
+		{ID: "a", TrustScore: 0.2},
+		{ID: "b", TrustScore: 0.4},
+		{ID: "c", TrustScore: 0.6},
+	}
+
+	out := NormalizeTrust(in)
+
+	want := []float64{0, 0.5, 1}
+	for i, w := range want {
+		if math.Abs(out[i].TrustScore-w) > 1e-9 {
+			t.Fatalf("scroll %s: expected %.2f, got %.4f", out[i].ID, w, out[i].TrustScore)
+		}
+	}
+	if in[1].TrustScore != 0.4 {
+		t.Fatalf("input cohort must not be modified, got %.2f", in[1].TrustScore)
+	}
+}
+
+func TestNormalizeTrust_ZeroVarianceUnchanged(t *testing.T) {
+	in := []types.Scroll{{ID: "a", TrustScore: 0.7}, {ID: "b", TrustScore: 0.7}}
+
+	out := NormalizeTrust(in)
+
+	for _, s := range out {
+		if s.TrustScore != 0.7 {
+			t.Fatalf("expected zero-variance cohort unchanged, got %.2f", s.TrustScore)
+		}
+	}
+}
+
+func TestSimulateInCohort_NormalizesBeforeThreshold(t *testing.T) {
+	cohort := []types.Scroll{{ID: "low", TrustScore: 0.1}, {ID: "high", TrustScore: 0.65}}
+	scroll := types.Scroll{ID: "s", TrustScore: 0.6, IsFlareEvent: true, GeneticMarkers: []string{"g1"}}
+
+	if out := mustSimulate(t, scroll, DefaultConfig()); out.TrustAligned {
+		t.Fatalf("expected raw 0.6 to miss the 0.7 threshold")
+	}
+	out, err := SimulateInCohort(context.Background(), scroll, cohort, DefaultConfig())
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if !out.TrustAligned {
+		t.Fatalf("expected 0.6 to align once normalized against its cohort")
+	}
+}
+
+var recencyNow = time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// agedScrolls has trust rising with age, so the more a kernel favors recent
+// scrolls, the lower its aggregate.
+func agedScrolls() []types.Scroll {
+	day := 24 * time.Hour
+	return []types.Scroll{
+		{ID: "today", TrustScore: 0.2, Timestamp: recencyNow},
+		{ID: "week", TrustScore: 0.5, Timestamp: recencyNow.Add(-5 * day)},
+		{ID: "fortnight", TrustScore: 0.9, Timestamp: recencyNow.Add(-10 * day)},
+	}
+}
+
+func TestAggregateTrust_KernelOrdering(t *testing.T) {
+	scrolls := agedScrolls()
+	exp := aggregateTrustAt(scrolls, RecencyKernel{Kind: RecencyExponential, HalfLife: Duration(48 * time.Hour)}, recencyNow)
+	lin := aggregateTrustAt(scrolls, RecencyKernel{Kind: RecencyLinear, Window: Duration(20 * 24 * time.Hour)}, recencyNow)
+	uni := aggregateTrustAt(scrolls, RecencyKernel{Kind: RecencyUniform}, recencyNow)
+
+	if !(exp < lin && lin < uni) {
+		t.Fatalf("expected exponential < linear < uniform, got %.4f, %.4f, %.4f", exp, lin, uni)
+	}
+	if want := (0.2 + 0.5 + 0.9) / 3; math.Abs(uni-want) > 1e-9 {
+		t.Fatalf("uniform aggregate %.4f, want the plain mean %.4f", uni, want)
+	}
+	if want := (0.2 + 0.75*0.5 + 0.5*0.9) / 2.25; math.Abs(lin-want) > 1e-9 {
+		t.Fatalf("linear aggregate %.4f, want %.4f", lin, want)
+	}
+}
+
+func TestAggregateTrust_ClampsFutureTimestamps(t *testing.T) {
+	kernel := RecencyKernel{Kind: RecencyExponential, HalfLife: Duration(time.Hour)}
+	if w := kernel.Weight(-time.Hour); w != 1 {
+		t.Fatalf("expected a future-dated scroll weighted 1, got %v", w)
+	}
+	scrolls := []types.Scroll{
+		{ID: "future", TrustScore: 0.8, Timestamp: recencyNow.Add(time.Hour)},
+		{ID: "now", TrustScore: 0.4, Timestamp: recencyNow},
+	}
+	if got := aggregateTrustAt(scrolls, kernel, recencyNow); math.Abs(got-0.6) > 1e-9 {
+		t.Fatalf("expected future and present scrolls weighted equally, got %.4f", got)
+	}
+}
+
+func TestAggregateTrust_UndatedAndEmpty(t *testing.T) {
+	undated := []types.Scroll{{ID: "a", TrustScore: 0.4}}
+	if got := aggregateTrustAt(undated, RecencyKernel{Kind: RecencyLinear, Window: Duration(time.Hour)}, recencyNow); got != 0 {
+		t.Fatalf("expected undated scrolls to carry no linear weight, got %v", got)
+	}
+	if got := aggregateTrustAt(undated, RecencyKernel{Kind: RecencyUniform}, recencyNow); got != 0.4 {
+		t.Fatalf("expected uniform kernel to weight undated scrolls, got %v", got)
+	}
+	if got := AggregateTrust(nil, DefaultConfig()); got != 0 {
+		t.Fatalf("expected 0 for no scrolls, got %v", got)
+	}
+}
+
+func TestTrustProvenance_EmptyWithoutTransforms(t *testing.T) {
+	scroll := types.Scroll{ID: "s", TrustScore: 0.8, Timestamp: time.Now().Add(-time.Hour)}
+
+	reason, ok := findReason(mustSimulate(t, scroll, DefaultConfig()), ExplainTrustThreshold)
+	if !ok || reason.Provenance == nil {
+		t.Fatalf("expected trust provenance on the threshold reason, got %+v", reason)
+	}
+	p := reason.Provenance
+	if len(p.Steps) != 0 || p.Raw != 0.8 || p.Effective != 0.8 {
+		t.Fatalf("expected an empty chain from 0.8 to 0.8, got %+v", p)
+	}
+}
+
+func TestTrustProvenance_DecayThenThreshold(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TrustDecay = RecencyKernel{Kind: RecencyExponential, HalfLife: Duration(24 * time.Hour)}
+	scroll := types.Scroll{
+		ID: "s", TrustScore: 0.9, IsFlareEvent: true, GeneticMarkers: []string{"g1"},
+		Timestamp: time.Now().Add(-24 * time.Hour),
+	}
+
+	plan := mustSimulate(t, scroll, cfg)
+	if plan.TrustAligned {
+		t.Fatalf("expected 0.9 decayed by a half-life to miss the %.2f threshold", cfg.TrustThreshold)
+	}
+	reason, _ := findReason(plan, ExplainTrustThreshold)
+	p := reason.Provenance
+	if p == nil || len(p.Steps) != 1 {
+		t.Fatalf("expected a single decay step, got %+v", p)
+	}
+	step := p.Steps[0]
+	if step.Kind != TrustStepDecay || math.Abs(step.Factor-0.5) > 1e-6 {
+		t.Fatalf("expected a decay factor of 0.5, got %+v", step)
+	}
+	if p.Raw != 0.9 || step.Before != 0.9 || step.After != p.Effective {
+		t.Fatalf("expected the chain to run from 0.9 through the step, got %+v", p)
+	}
+	if math.Abs(p.Effective-0.45) > 1e-6 || *reason.Value != p.Effective {
+		t.Fatalf("expected the threshold to compare the effective 0.45, got value %.4f, provenance %+v", *reason.Value, p)
+	}
+}
+
+func TestTrustProvenance_RecordsCohortNormalization(t *testing.T) {
+	cohort := []types.Scroll{{ID: "low", TrustScore: 0.1}, {ID: "high", TrustScore: 0.6}}
+	scroll := types.Scroll{ID: "s", TrustScore: 0.5}
+
+	out, err := SimulateInCohort(context.Background(), scroll, cohort, DefaultConfig())
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	reason, _ := findReason(out, ExplainTrustThreshold)
+	p := reason.Provenance
+	if p == nil || len(p.Steps) != 1 || p.Steps[0].Kind != TrustStepNormalize || p.Steps[0].Offset != 0.1 {
+		t.Fatalf("expected a normalize step offset by the cohort minimum, got %+v", p)
+	}
+	if math.Abs(p.Effective-0.8) > 1e-9 {
+		t.Fatalf("expected 0.5 to normalize to 0.8, got %.4f", p.Effective)
+	}
+}
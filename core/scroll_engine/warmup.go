@@ -0,0 +1,31 @@
+package scroll_engine
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Warmup compiles the active config — resolving alias chains, keying marker
+// weights canonically, building the flare panel, and hashing the config — so
+// the first request does not pay for it, then marks the server ready for
+// /readyz. It is safe to call more than once.
+func (s *Server) Warmup() {
+	start := time.Now()
+	s.active.Load().engine()
+	s.metrics.Add("warmup_seconds", time.Since(start).Seconds())
+	s.warm.Store(true)
+}
+
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	status, body := http.StatusOK, "ready"
+	switch {
+	case !s.warm.Load():
+		status, body = http.StatusServiceUnavailable, "warming"
+	case s.maintenance.Load():
+		status, body = http.StatusServiceUnavailable, "maintenance"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"status": body})
+}
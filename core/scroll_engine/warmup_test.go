@@ -0,0 +1,65 @@
+package scroll_engine
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReadyz_ReportsWarmup(t *testing.T) {
+	srv := NewServer(DefaultConfig())
+	h := srv.Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 before warm-up, got %d", rec.Code)
+	}
+
+	srv.Warmup()
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 after warm-up, got %d: %s", rec.Code, rec.Body)
+	}
+	if srv.active.Load().compiled == nil {
+		t.Fatalf("expected warm-up to compile the active config")
+	}
+}
+
+// warmupBenchConfig has enough aliases and weights for compiling it to show
+// up in first-request latency.
+func warmupBenchConfig() SimulationConfig {
+	cfg := DefaultConfig()
+	cfg.MarkerAliases = map[string]string{}
+	cfg.MarkerWeights = map[string]MarkerWeight{}
+	for i := range 5000 {
+		cfg.MarkerAliases[fmt.Sprintf("alias%d", i)] = fmt.Sprintf("GENE%d", i)
+		cfg.MarkerWeights[fmt.Sprintf("alias%d", i)] = MarkerWeight{Relief: 0.5, Suppression: 0.5}
+	}
+	return cfg
+}
+
+// BenchmarkFirstSimulate times the first /simulate a fresh server handles,
+// with and without Warmup having run beforehand.
+func BenchmarkFirstSimulate(b *testing.B) {
+	cfg := warmupBenchConfig()
+	body := `{"id":"s1","trust_score":0.9,"is_flare_event":true,"genetic_markers":["alias1","alias2"]}`
+	for _, warm := range []bool{false, true} {
+		b.Run(fmt.Sprintf("warmup=%t", warm), func(b *testing.B) {
+			for range b.N {
+				b.StopTimer()
+				srv := NewServer(cfg)
+				if warm {
+					srv.Warmup()
+				}
+				h := srv.Handler()
+				b.StartTimer()
+				if rec := postSimulate(h, body, ""); rec.Code != http.StatusOK {
+					b.Fatalf("simulate: %d %s", rec.Code, rec.Body)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package scroll_engine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"Maple-OS/modem_os/core/shared/types"
+)
+
+// webhookBuffer is how many deferred webhooks may wait for the webhook
+// worker before further ones are dropped.
+const webhookBuffer = 256
+
+// webhookTimeout bounds one delivery that has no request budget to respect:
+// every delivery by the webhook worker, and inline deliveries while the
+// request budget is off.
+const webhookTimeout = 10 * time.Second
+
+// webhookClient is shared by every webhook delivery.
+var webhookClient = &http.Client{}
+
+// WebhookEvent is POSTed as JSON to cfg.WebhookURL for each plan a
+// simulation produces.
+type WebhookEvent struct {
+	Tenant   string                     `json:"tenant"`
+	ScrollID string                     `json:"scroll_id"`
+	At       time.Time                  `json:"at"`
+	Plan     types.GeneInterventionPlan `json:"plan"`
+}
+
+// sendWebhook delivers e to url, signed with the configured webhook secret
+// if there is one.
+func (s *Server) sendWebhook(ctx context.Context, url string, e WebhookEvent) error {
+	secret := s.config().WebhookSecret
+	if secret == "" {
+		s.unsignedWebhooks.Do(func() {
+			log.Printf("warning: webhook_secret is not set; webhooks are sent unsigned and receivers cannot verify them")
+		})
+	}
+	return postWebhook(ctx, url, secret, e)
+}
+
+// postWebhook delivers e to url, signing it with secret unless secret is
+// empty, and treating any non-2xx reply as a failure.
+func postWebhook(ctx context.Context, url, secret string, e WebhookEvent) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		signWebhookRequest(req.Header, body, secret, time.Now())
+	}
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// notify delivers plan's webhook, if one is configured, within ctx, or
+// within webhookTimeout if ctx has no deadline. A delivery that fails or
+// that ctx's request budget cuts short is deferred to the webhook worker
+// instead of being dropped. It reports whether the budget was what stopped
+// it.
+func (s *Server) notify(ctx context.Context, tenant, scrollID string, plan types.GeneInterventionPlan) bool {
+	url := s.config().WebhookURL
+	if url == "" {
+		return false
+	}
+	e := WebhookEvent{Tenant: tenant, ScrollID: scrollID, At: time.Now().UTC(), Plan: plan}
+	if budgetSpent(ctx) {
+		s.deferWebhook(e)
+		return true
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, webhookTimeout)
+		defer cancel()
+	}
+	if err := s.sendWebhook(ctx, url, e); err != nil {
+		spent := budgetSpent(ctx)
+		if !spent {
+			log.Printf("webhook for scroll %s failed, deferring: %v", scrollID, err)
+		}
+		s.deferWebhook(e)
+		return spent
+	}
+	s.metrics.Inc("webhooks_delivered_total")
+	return false
+}
+
+// deferWebhook queues e for the webhook worker, dropping it if the queue is
+// full.
+func (s *Server) deferWebhook(e WebhookEvent) {
+	select {
+	case s.webhooks <- e:
+		s.metrics.Inc("webhooks_deferred_total")
+	default:
+		s.metrics.Inc("webhooks_dropped_total")
+		log.Printf("webhook queue full; dropped webhook for scroll %s", e.ScrollID)
+	}
+}
+
+// deliverDeferred runs the webhook worker: it delivers deferred webhooks,
+// one attempt each under webhookTimeout, until ctx is cancelled.
+func (s *Server) deliverDeferred(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-s.webhooks:
+			url := s.config().WebhookURL
+			if url == "" {
+				continue
+			}
+			dctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+			err := s.sendWebhook(dctx, url, e)
+			cancel()
+			if err != nil {
+				s.metrics.Inc("webhook_failures_total")
+				log.Printf("deferred webhook for scroll %s failed: %v", e.ScrollID, err)
+				continue
+			}
+			s.metrics.Inc("webhooks_delivered_total")
+		}
+	}
+}
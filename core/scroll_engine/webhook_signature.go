@@ -0,0 +1,79 @@
+package scroll_engine
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers carrying a signed webhook's signature and the Unix time, in
+// seconds, it was signed at. The signature is "sha256=" followed by the
+// hex-encoded HMAC-SHA256, under the webhook secret, of the timestamp, a
+// ".", and the body, so a captured body cannot be replayed under a new
+// timestamp.
+const (
+	WebhookSignatureHeader = "X-Modem-Signature"
+	WebhookTimestampHeader = "X-Modem-Timestamp"
+)
+
+// WebhookTolerance is how far a webhook's timestamp may be from the
+// receiver's clock before VerifyWebhook rejects it as a replay.
+const WebhookTolerance = 5 * time.Minute
+
+var (
+	ErrWebhookUnsigned  = errors.New("webhook signature or timestamp missing")
+	ErrWebhookSignature = errors.New("webhook signature mismatch")
+	ErrWebhookExpired   = errors.New("webhook timestamp outside tolerance")
+)
+
+// webhookSignaturePrefix names the signature's algorithm.
+const webhookSignaturePrefix = "sha256="
+
+// webhookMAC returns the HMAC-SHA256 of a webhook body signed at timestamp.
+func webhookMAC(body []byte, timestamp, secret string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// signWebhookRequest sets the signature and timestamp headers for body,
+// signed at now.
+func signWebhookRequest(h http.Header, body []byte, secret string, now time.Time) {
+	ts := strconv.FormatInt(now.Unix(), 10)
+	h.Set(WebhookTimestampHeader, ts)
+	h.Set(WebhookSignatureHeader, webhookSignaturePrefix+hex.EncodeToString(webhookMAC(body, ts, secret)))
+}
+
+// VerifyWebhook checks a received webhook: that headers carry a signature
+// of body under secret and a timestamp within WebhookTolerance of now.
+// Receivers should verify the raw body before decoding it.
+func VerifyWebhook(body []byte, headers http.Header, secret string) error {
+	return verifyWebhookAt(body, headers, secret, time.Now())
+}
+
+func verifyWebhookAt(body []byte, headers http.Header, secret string, now time.Time) error {
+	ts := headers.Get(WebhookTimestampHeader)
+	sig, ok := strings.CutPrefix(headers.Get(WebhookSignatureHeader), webhookSignaturePrefix)
+	if ts == "" || !ok {
+		return ErrWebhookUnsigned
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, webhookMAC(body, ts, secret)) {
+		return ErrWebhookSignature
+	}
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrWebhookSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > WebhookTolerance || age < -WebhookTolerance {
+		return ErrWebhookExpired
+	}
+	return nil
+}
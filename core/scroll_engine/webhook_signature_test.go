@@ -0,0 +1,83 @@
+package scroll_engine
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type receivedWebhook struct {
+	body    []byte
+	headers http.Header
+}
+
+// captureWebhook returns the URL of an endpoint that sends each webhook it
+// receives, raw, on the returned channel.
+func captureWebhook(t *testing.T) (string, <-chan receivedWebhook) {
+	t.Helper()
+	got := make(chan receivedWebhook, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- receivedWebhook{body: body, headers: r.Header.Clone()}
+	}))
+	t.Cleanup(hook.Close)
+	return hook.URL, got
+}
+
+func TestWebhook_SignedBodyVerifies(t *testing.T) {
+	url, got := captureWebhook(t)
+	cfg := DefaultConfig()
+	cfg.WebhookURL = url
+	cfg.WebhookSecret = "hook-secret"
+	postSimulate(NewServer(cfg).Handler(), budgetFlareBody, "")
+	hook := <-got
+
+	if err := VerifyWebhook(hook.body, hook.headers, "hook-secret"); err != nil {
+		t.Fatalf("expected the webhook to verify, got %v", err)
+	}
+	tampered := append([]byte{}, hook.body...)
+	tampered[len(tampered)/2] ^= 1
+	if err := VerifyWebhook(tampered, hook.headers, "hook-secret"); !errors.Is(err, ErrWebhookSignature) {
+		t.Fatalf("expected a tampered body to fail, got %v", err)
+	}
+	if err := VerifyWebhook(hook.body, hook.headers, "other-secret"); !errors.Is(err, ErrWebhookSignature) {
+		t.Fatalf("expected the wrong secret to fail, got %v", err)
+	}
+}
+
+func TestVerifyWebhook_RejectsReplayAndUnsigned(t *testing.T) {
+	body := []byte(`{"scroll_id":"f"}`)
+	signedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := http.Header{}
+	signWebhookRequest(h, body, "k", signedAt)
+
+	if err := verifyWebhookAt(body, h, "k", signedAt.Add(WebhookTolerance)); err != nil {
+		t.Fatalf("expected a webhook within tolerance to verify, got %v", err)
+	}
+	if err := verifyWebhookAt(body, h, "k", signedAt.Add(WebhookTolerance+time.Second)); !errors.Is(err, ErrWebhookExpired) {
+		t.Fatalf("expected a stale webhook to be rejected, got %v", err)
+	}
+	replayed := h.Clone()
+	replayed.Set(WebhookTimestampHeader, "1767326645")
+	if err := verifyWebhookAt(body, replayed, "k", signedAt); !errors.Is(err, ErrWebhookSignature) {
+		t.Fatalf("expected a re-stamped webhook to fail, got %v", err)
+	}
+	if err := verifyWebhookAt(body, http.Header{}, "k", signedAt); !errors.Is(err, ErrWebhookUnsigned) {
+		t.Fatalf("expected an unsigned webhook to be rejected, got %v", err)
+	}
+}
+
+func TestWebhook_UnsignedWithoutSecret(t *testing.T) {
+	url, got := captureWebhook(t)
+	cfg := DefaultConfig()
+	cfg.WebhookURL = url
+	postSimulate(NewServer(cfg).Handler(), budgetFlareBody, "")
+	hook := <-got
+
+	if hook.headers.Get(WebhookSignatureHeader) != "" || hook.headers.Get(WebhookTimestampHeader) != "" {
+		t.Fatalf("expected no signature without a secret, got %v", hook.headers)
+	}
+}
@@ -1,19 +1,134 @@
 package types
 
+import (
+	"math"
+	"time"
+)
+
+// CurrentScrollSchemaVersion is the Scroll wire shape this package defines.
+// Version 1 flagged flares with a "trigger" string instead of
+// is_flare_event.
+const CurrentScrollSchemaVersion = 2
+
+// Scroll is a record submitted for simulation. Its XML element names, like
+// GeneInterventionPlan's, follow the JSON keys, with lists nesting one
+// element per item.
 type Scroll struct {
-	ID             string   `json:"id"`
-	TrustScore     float64  `json:"trust_score"`
-	IsFlareEvent   bool     `json:"is_flare_event"`
-	GeneticMarkers []string `json:"genetic_markers"`
+	// SchemaVersion is the wire shape the scroll was sent in; zero means 1.
+	SchemaVersion  int       `json:"schema_version,omitempty" xml:"schema_version,omitempty"`
+	ID             string    `json:"id" xml:"id"`
+	TrustScore     float64   `json:"trust_score" xml:"trust_score"`
+	IsFlareEvent   bool      `json:"is_flare_event" xml:"is_flare_event"`
+	GeneticMarkers []string  `json:"genetic_markers" xml:"genetic_markers>marker"`
+	Timestamp      time.Time `json:"timestamp,omitzero" xml:"timestamp"`
+	Signature      string    `json:"signature,omitempty" xml:"signature,omitempty"`
+	ParentID       string    `json:"parent_id,omitempty" xml:"parent_id,omitempty"`
+	// Tags group the scroll into cohorts. Stored tags are trimmed,
+	// lowercased, deduplicated, and sorted.
+	Tags []string `json:"tags,omitempty" xml:"tags>tag,omitempty"`
+	// Version counts a stored scroll's revisions: 1 when first simulated,
+	// bumped by each patch. The server assigns it.
+	Version int `json:"version,omitempty" xml:"version,omitempty"`
+	// Frozen marks a scroll whose decision has been acted on: the store
+	// refuses to change it or its plan until it is unfrozen. The server
+	// assigns it.
+	Frozen bool `json:"frozen,omitempty" xml:"frozen,omitempty"`
 }
 
 type GeneInterventionPlan struct {
-	MutationLoopID      string   `json:"mutation_loop_id"`
-	TargetedGenes       []string `json:"targeted_genes"`
-	TrustAligned        bool     `json:"trust_aligned"`
-	RequiredRecalibrate bool     `json:"required_recalibrate"`
-
-	PredictedRelief  float64 `json:"predicted_relief,omitempty"`
-	FlareSuppression float64 `json:"flare_suppression,omitempty"`
-	RebirthEligible  bool    `json:"rebirth_eligible,omitempty"`
+	MutationLoopID string   `json:"mutation_loop_id" xml:"mutation_loop_id"`
+	Branch         string   `json:"branch" xml:"branch"`
+	TargetedGenes  []string `json:"targeted_genes" xml:"targeted_genes>gene"`
+	// TargetDetails breaks the plan down by targeted gene, in the order of
+	// TargetedGenes, which lists the same genes.
+	TargetDetails       []MarkerContribution `json:"target_details,omitempty" xml:"target_details>marker,omitempty"`
+	TrustAligned        bool                 `json:"trust_aligned" xml:"trust_aligned"`
+	RequiredRecalibrate bool                 `json:"required_recalibrate" xml:"required_recalibrate"`
+
+	PredictedRelief  float64    `json:"predicted_relief,omitempty" xml:"predicted_relief,omitempty"`
+	ReliefCI         [2]float64 `json:"relief_ci,omitzero" xml:"relief_ci>bound"`
+	FlareSuppression float64    `json:"flare_suppression,omitempty" xml:"flare_suppression,omitempty"`
+	RebirthEligible  bool       `json:"rebirth_eligible,omitempty" xml:"rebirth_eligible,omitempty"`
+	FlareSeverity    string     `json:"flare_severity,omitempty" xml:"flare_severity,omitempty"`
+
+	Explanation []ExplanationReason `json:"explanation,omitempty" xml:"explanation>reason,omitempty"`
+}
+
+// ExplanationReason records one decision the engine made while building a
+// plan and whether the scroll passed it.
+type ExplanationReason struct {
+	Kind      string               `json:"kind" xml:"kind,attr"`
+	Passed    bool                 `json:"passed" xml:"passed,attr"`
+	Message   string               `json:"message" xml:"message"`
+	Threshold *float64             `json:"threshold,omitempty" xml:"threshold,omitempty"`
+	Value     *float64             `json:"value,omitempty" xml:"value,omitempty"`
+	Markers   []MarkerContribution `json:"markers,omitempty" xml:"markers>marker,omitempty"`
+	// Provenance, on the trust threshold reason, records how the trust
+	// compared against the threshold was derived.
+	Provenance *TrustProvenance `json:"provenance,omitempty" xml:"provenance,omitempty"`
+}
+
+// TrustProvenance traces the trust a plan was decided on back to the
+// scroll's submitted score: Raw is the score as submitted, Steps the
+// transformations applied to it in order, and Effective the result. Steps
+// is empty, and Effective equals Raw, when no transformation applied.
+type TrustProvenance struct {
+	Raw       float64          `json:"raw" xml:"raw,attr"`
+	Steps     []TrustTransform `json:"steps" xml:"steps>step"`
+	Effective float64          `json:"effective" xml:"effective,attr"`
+}
+
+// TrustTransform is one transformation of trust, taking Before to
+// After = (Before - Offset) * Factor.
+type TrustTransform struct {
+	Kind   string  `json:"kind" xml:"kind,attr"`
+	Offset float64 `json:"offset" xml:"offset,attr"`
+	Factor float64 `json:"factor" xml:"factor,attr"`
+	Before float64 `json:"before" xml:"before,attr"`
+	After  float64 `json:"after" xml:"after,attr"`
+}
+
+// MarkerContribution is a targeted marker's weight in the plan's scores.
+type MarkerContribution struct {
+	Gene        string  `json:"gene" xml:"gene,attr"`
+	Relief      float64 `json:"relief" xml:"relief,attr"`
+	Suppression float64 `json:"suppression" xml:"suppression,attr"`
+	// OnPanel, in a plan's TargetDetails, reports whether the gene is on
+	// the flare panel; every gene is when the panel is empty.
+	OnPanel bool `json:"on_panel,omitempty" xml:"on_panel,attr,omitempty"`
+}
+
+// ValidationError reports a scroll field that failed validation.
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Validate checks that the scroll's fields are within their allowed ranges.
+func (s Scroll) Validate() error {
+	return unitInterval("trust_score", s.TrustScore)
+}
+
+// Validate checks that the plan's scores are within their allowed ranges.
+func (p GeneInterventionPlan) Validate() error {
+	if err := unitInterval("predicted_relief", p.PredictedRelief); err != nil {
+		return err
+	}
+	return unitInterval("flare_suppression", p.FlareSuppression)
+}
+
+// unitInterval rejects v unless it is a finite number in [0,1]. NaN fails
+// every comparison, so it must be ruled out before the range check.
+func unitInterval(field string, v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return &ValidationError{Field: field, Message: "must be a finite number"}
+	}
+	if v < 0 || v > 1 {
+		return &ValidationError{Field: field, Message: "must be between 0 and 1"}
+	}
+	return nil
 }